@@ -8,6 +8,8 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/Xcruser/MidiDaemon/internal/actions"
+	"github.com/Xcruser/MidiDaemon/internal/api"
 	"github.com/Xcruser/MidiDaemon/internal/config"
 	"github.com/Xcruser/MidiDaemon/internal/midi"
 	"github.com/Xcruser/MidiDaemon/pkg/utils"
@@ -30,6 +32,11 @@ func main() {
 	logLevel := flag.String("log-level", "", "Log-Level (debug, info, warn, error)")
 	generateCfg := flag.Bool("generate-config", false, "Erzeugt eine Standard-Konfigurationsdatei")
 	showVersion := flag.Bool("version", false, "Versionsinformationen anzeigen")
+	apiAddr := flag.String("api", "", "Adresse der Status-API (z.B. "+api.DefaultAddr+"), leer = deaktiviert")
+	showHistory := flag.Bool("history", false, "Verlauf des laufenden Daemons abfragen und ausgeben")
+	historyMapping := flag.String("history-mapping", "", "Verlauf auf ein Mapping beschränken")
+	historyErrors := flag.Bool("history-errors", false, "Nur fehlgeschlagene Aktionen ausgeben")
+	historyLimit := flag.Int("history-limit", 20, "Anzahl der ausgegebenen Einträge")
 
 	flag.Parse()
 
@@ -38,6 +45,24 @@ func main() {
 		return
 	}
 
+	if *showHistory {
+		addr := *apiAddr
+		if addr == "" {
+			addr = api.DefaultAddr
+		}
+		entries, err := api.FetchHistory(addr, actions.HistoryFilter{
+			Mapping:    *historyMapping,
+			ErrorsOnly: *historyErrors,
+			Limit:      *historyLimit,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Fehler beim Abfragen des Verlaufs: %v\n", err)
+			os.Exit(1)
+		}
+		api.PrintHistory(os.Stdout, entries)
+		return
+	}
+
 	if *generateCfg {
 		if err := config.GenerateDefaultFile(*configPath); err != nil {
 			fmt.Fprintf(os.Stderr, "Fehler beim Erzeugen der Konfiguration: %v\n", err)
@@ -75,12 +100,35 @@ func main() {
 
 	go func() {
 		sigCh := make(chan os.Signal, 1)
-		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
-		<-sigCh
-		logger.Info("Beende MidiDaemon ...")
-		cancel()
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+		for sig := range sigCh {
+			if sig == syscall.SIGHUP {
+				// Konfiguration neu laden, Zustände bleiben erhalten
+				newCfg, err := config.Load(*configPath)
+				if err != nil {
+					logger.Error("Fehler beim Neuladen der Konfiguration", "error", err)
+					continue
+				}
+				if err := handler.Reload(newCfg); err != nil {
+					logger.Error("Fehler beim Neuladen der Konfiguration", "error", err)
+				}
+				continue
+			}
+			logger.Info("Beende MidiDaemon ...")
+			cancel()
+			return
+		}
 	}()
 
+	if *apiAddr != "" {
+		server := api.NewServer(*apiAddr, handler, logger)
+		go func() {
+			if err := server.Start(ctx); err != nil {
+				logger.Error("Status-API beendet", "error", err)
+			}
+		}()
+	}
+
 	if err := handler.Start(ctx); err != nil {
 		logger.Error("Handler beendet", "error", err)
 	}
@@ -4,9 +4,11 @@
 package actions
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/Xcruser/MidiDaemon/internal/config"
@@ -28,18 +30,18 @@ func NewAppStartExecutor(logger utils.Logger) (*AppStartExecutor, error) {
 }
 
 // Execute führt eine App-Start-Aktion aus
-func (e *AppStartExecutor) Execute(action config.Action) error {
+func (e *AppStartExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
 	e.LogDebug("Führe App-Start-Aktion aus", "parameters", action.Parameters)
 
 	// Path-Parameter extrahieren
 	path, ok := action.Parameters["path"]
 	if !ok {
-		return fmt.Errorf("app_start-Aktion benötigt 'path' Parameter")
+		return Result{}, fmt.Errorf("app_start-Aktion benötigt 'path' Parameter")
 	}
 
 	pathStr, ok := path.(string)
 	if !ok {
-		return fmt.Errorf("'path' Parameter muss ein String sein")
+		return Result{}, fmt.Errorf("'path' Parameter muss ein String sein")
 	}
 
 	// Argumente extrahieren (optional)
@@ -71,9 +73,15 @@ func (e *AppStartExecutor) Execute(action config.Action) error {
 		}
 	}
 
+	// Abgebrochene Aktionen starten keine Anwendung mehr
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
 	// Anwendung starten
 	e.LogInfo("Starte Anwendung", "path", pathStr, "args", args, "working_dir", workingDir)
 
+	// Kein CommandContext: die Anwendung soll die Aktion überdauern
 	cmd := exec.Command(pathStr, args...)
 	if workingDir != "" {
 		cmd.Dir = workingDir
@@ -81,11 +89,11 @@ func (e *AppStartExecutor) Execute(action config.Action) error {
 
 	// Hintergrund ausführen (nicht auf Fertigstellung warten)
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("fehler beim Starten der Anwendung '%s': %w", pathStr, err)
+		return Result{}, fmt.Errorf("fehler beim Starten der Anwendung '%s': %w", pathStr, err)
 	}
 
 	e.LogInfo("Anwendung gestartet", "pid", cmd.Process.Pid)
-	return nil
+	return Result{Changed: true, Output: strconv.Itoa(cmd.Process.Pid)}, nil
 }
 
 // Validate überprüft eine App-Start-Aktion auf Gültigkeit
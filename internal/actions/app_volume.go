@@ -0,0 +1,291 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält den App-Volume-Executor für Lautstärke und Stummschaltung einzelner Anwendungen.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// errStreamGone meldet einen Stream, der zwischen Abfrage und Änderung beendet wurde
+var errStreamGone = errors.New("stream existiert nicht mehr")
+
+// AppStream ist ein Wiedergabe-Stream einer Anwendung
+type AppStream struct {
+	ID          string `json:"id"`
+	Application string `json:"application"` // Anwendungsname, z.B. "Spotify"
+	Binary      string `json:"binary"`      // Programmname, z.B. "spotify"
+	PID         int    `json:"pid"`
+	Name        string `json:"name"` // Name des Streams
+	Volume      int    `json:"volume"`
+	IsMuted     bool   `json:"is_muted"`
+}
+
+// AppAudioController wird von Audio-Controllern implementiert, die einzelne Anwendungs-Streams steuern können
+type AppAudioController interface {
+	GetAppStreams(ctx context.Context) ([]AppStream, error)
+	SetAppStreamVolume(ctx context.Context, streamID string, volume int) error
+	SetAppStreamMute(ctx context.Context, streamID string, muted bool) error
+	MoveAppStream(ctx context.Context, streamID, sinkID string) error
+}
+
+// AppVolumeExecutor steuert Lautstärke und Stummschaltung einzelner Anwendungen
+type AppVolumeExecutor struct {
+	BaseExecutor
+	audioController AudioController
+}
+
+// NewAppVolumeExecutor erstellt einen neuen App-Volume-Executor
+func NewAppVolumeExecutor(audio config.AudioConfig, logger utils.Logger) (*AppVolumeExecutor, error) {
+	controller, err := newAudioController(audio)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Erstellen des Audio-Controllers: %w", err)
+	}
+
+	executor := &AppVolumeExecutor{
+		BaseExecutor:    NewBaseExecutor("app_volume", logger),
+		audioController: controller,
+	}
+
+	return executor, nil
+}
+
+// Execute führt eine App-Volume-Aktion aus. Streams werden bei jeder Ausführung neu gesucht,
+// damit auch Anwendungen erfasst werden, die erst nach dem Start des Daemons abspielen.
+func (e *AppVolumeExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
+	e.LogDebug("Führe App-Volume-Aktion aus", "parameters", action.Parameters)
+
+	controller, ok := e.audioController.(AppAudioController)
+	if !ok {
+		return Result{}, fmt.Errorf("das Audio-Backend unterstützt keine Lautstärke pro Anwendung")
+	}
+
+	direction, _ := action.Parameters["direction"].(string)
+	percent, err := intParameter(action, "percent", 5)
+	if err != nil {
+		return Result{}, err
+	}
+	volume, err := intParameter(action, "volume", -1)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if direction == "set" && (volume < 0 || volume > 100) {
+		return Result{}, fmt.Errorf("volume muss zwischen 0 und 100 liegen, got: %d", volume)
+	}
+
+	streams, err := controller.GetAppStreams(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("fehler beim Abrufen der Anwendungs-Streams: %w", err)
+	}
+	streams = selectAppStreams(streams, action.Parameters)
+	if len(streams) == 0 {
+		e.LogDebug("Keine passenden Anwendungs-Streams", "parameters", action.Parameters)
+		return Result{Output: "0 Streams"}, nil
+	}
+
+	// toggle_mute schaltet alle stumm, sobald einer der Streams noch hörbar ist
+	if direction == "toggle_mute" {
+		direction = "unmute"
+		for _, stream := range streams {
+			if !stream.IsMuted {
+				direction = "mute"
+				break
+			}
+		}
+	}
+
+	changed := 0
+	state := ""
+	for _, stream := range streams {
+		var err error
+		switch direction {
+		case "up", "increase":
+			state = strconv.Itoa(clampPercent(stream.Volume + percent))
+			err = controller.SetAppStreamVolume(ctx, stream.ID, clampPercent(stream.Volume+percent))
+		case "down", "decrease":
+			state = strconv.Itoa(clampPercent(stream.Volume - percent))
+			err = controller.SetAppStreamVolume(ctx, stream.ID, clampPercent(stream.Volume-percent))
+		case "set":
+			state = strconv.Itoa(volume)
+			err = controller.SetAppStreamVolume(ctx, stream.ID, volume)
+		case "mute":
+			state = "muted"
+			err = controller.SetAppStreamMute(ctx, stream.ID, true)
+		case "unmute":
+			state = "unmuted"
+			err = controller.SetAppStreamMute(ctx, stream.ID, false)
+		default:
+			return Result{}, fmt.Errorf("ungültige direction: %s (erwartet: up, down, set, mute, unmute, toggle_mute)", direction)
+		}
+
+		if errors.Is(err, errStreamGone) {
+			e.LogDebug("Stream wurde inzwischen beendet", "stream", stream.ID, "application", stream.Application)
+			continue
+		}
+		if err != nil {
+			return Result{}, err
+		}
+		e.LogInfo("Ändere Anwendungs-Lautstärke", "application", stream.Application, "stream", stream.ID, "direction", direction, "state", state)
+		changed++
+	}
+
+	return Result{Changed: changed > 0, State: state, Output: fmt.Sprintf("%d Streams", changed)}, nil
+}
+
+// Validate überprüft eine App-Volume-Aktion auf Gültigkeit
+func (e *AppVolumeExecutor) Validate(action config.Action) error {
+	direction, ok := action.Parameters["direction"].(string)
+	if !ok {
+		return fmt.Errorf("app_volume-Aktion benötigt 'direction' Parameter")
+	}
+	switch direction {
+	case "up", "down", "increase", "decrease", "set", "mute", "unmute", "toggle_mute":
+	default:
+		return fmt.Errorf("ungültige direction: %s", direction)
+	}
+
+	// Mindestens ein Auswahlkriterium
+	app, _ := action.Parameters["app"].(string)
+	binary, _ := action.Parameters["binary"].(string)
+	pid, err := intParameter(action, "pid", 0)
+	if err != nil {
+		return err
+	}
+	if app == "" && binary == "" && pid <= 0 {
+		return fmt.Errorf("app_volume-Aktion benötigt 'app', 'binary' oder 'pid' Parameter")
+	}
+	if all, ok := action.Parameters["all"]; ok {
+		if _, ok := all.(bool); !ok {
+			return fmt.Errorf("'all' Parameter muss true oder false sein")
+		}
+	}
+
+	// Bei "set" muss die Lautstärke angegeben sein oder aus dem Event-Wert stammen
+	if direction == "set" && (action.Value == nil || action.Value.Parameter != "volume") {
+		if _, ok := action.Parameters["volume"]; !ok {
+			return fmt.Errorf("'set' direction benötigt 'volume' Parameter")
+		}
+	}
+
+	percent, err := intParameter(action, "percent", 5)
+	if err != nil {
+		return err
+	}
+	if percent <= 0 || percent > 100 {
+		return fmt.Errorf("percent muss zwischen 1 und 100 liegen, got: %d", percent)
+	}
+	return nil
+}
+
+// Close gibt die Verbindung zum Audio-Backend frei
+func (e *AppVolumeExecutor) Close() error {
+	return closeController(e.audioController)
+}
+
+// GetAppStreams gibt alle aktuellen Anwendungs-Streams zurück
+func (e *AppVolumeExecutor) GetAppStreams(ctx context.Context) ([]AppStream, error) {
+	controller, ok := e.audioController.(AppAudioController)
+	if !ok {
+		return nil, fmt.Errorf("das Audio-Backend unterstützt keine Lautstärke pro Anwendung")
+	}
+	return controller.GetAppStreams(ctx)
+}
+
+// selectAppStreams filtert Streams nach "app", "binary" und "pid". Standardmäßig werden alle
+// passenden Streams gewählt, mit "all": false nur der zuletzt gestartete.
+func selectAppStreams(streams []AppStream, params map[string]interface{}) []AppStream {
+	app, _ := params["app"].(string)
+	binary, _ := params["binary"].(string)
+	pid, _ := intParameter(config.Action{Parameters: params}, "pid", 0)
+
+	var matches []AppStream
+	for _, stream := range streams {
+		if app != "" && !matchPattern(app, stream.Application) {
+			continue
+		}
+		if binary != "" && !matchPattern(binary, stream.Binary) {
+			continue
+		}
+		if pid > 0 && stream.PID != pid {
+			continue
+		}
+		matches = append(matches, stream)
+	}
+
+	if all, ok := params["all"].(bool); ok && !all && len(matches) > 1 {
+		// Streams werden in der Reihenfolge ihrer Erstellung geliefert
+		return matches[len(matches)-1:]
+	}
+	return matches
+}
+
+// matchPattern vergleicht ohne Beachtung der Groß-/Kleinschreibung; Platzhalter wie "*chrom*" sind erlaubt
+func matchPattern(pattern, value string) bool {
+	pattern, value = strings.ToLower(pattern), strings.ToLower(value)
+	if matched, err := path.Match(pattern, value); err == nil && matched {
+		return true
+	}
+	return pattern == value
+}
+
+// intParameter liest einen ganzzahligen Parameter (int, float64 oder String)
+func intParameter(action config.Action, name string, fallback int) (int, error) {
+	value, ok := action.Parameters[name]
+	if !ok {
+		return fallback, nil
+	}
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case string:
+		parsed, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("ungültiger '%s' Parameter: %v", name, value)
+		}
+		return parsed, nil
+	default:
+		return 0, fmt.Errorf("ungültiger '%s' Parameter: %v", name, value)
+	}
+}
+
+// stringListParameter liest eine Liste als Array oder Komma-getrennten String
+func stringListParameter(action config.Action, name string) ([]string, error) {
+	value, ok := action.Parameters[name]
+	if !ok {
+		return nil, nil
+	}
+	var list []string
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			itemStr, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s %d muss ein String sein", name, i)
+			}
+			list = append(list, itemStr)
+		}
+	case []string:
+		list = v
+	case string:
+		// Komma-getrennte Liste
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("ungültiger '%s' Parameter: %v", name, value)
+	}
+	return list, nil
+}
@@ -0,0 +1,157 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// fakeAppController hält Streams im Speicher; gone simuliert zwischenzeitlich beendete Streams,
+// sinks merkt sich verschobene Streams
+type fakeAppController struct {
+	AudioController
+	mutex   sync.Mutex
+	streams []AppStream
+	gone    map[string]bool
+	sinks   map[string]string
+}
+
+func (c *fakeAppController) GetAppStreams(ctx context.Context) ([]AppStream, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]AppStream(nil), c.streams...), nil
+}
+
+func (c *fakeAppController) SetAppStreamVolume(ctx context.Context, streamID string, volume int) error {
+	return c.update(streamID, func(s *AppStream) { s.Volume = volume })
+}
+
+func (c *fakeAppController) SetAppStreamMute(ctx context.Context, streamID string, muted bool) error {
+	return c.update(streamID, func(s *AppStream) { s.IsMuted = muted })
+}
+
+func (c *fakeAppController) MoveAppStream(ctx context.Context, streamID, sinkID string) error {
+	return c.update(streamID, func(s *AppStream) { c.sinks[s.ID] = sinkID })
+}
+
+func (c *fakeAppController) update(streamID string, apply func(s *AppStream)) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.gone[streamID] {
+		return errStreamGone
+	}
+	for i := range c.streams {
+		if c.streams[i].ID == streamID {
+			apply(&c.streams[i])
+		}
+	}
+	return nil
+}
+
+func TestAppVolumeExecutor(t *testing.T) {
+	controller := &fakeAppController{
+		streams: []AppStream{
+			{ID: "7", Application: "Spotify", Binary: "spotify", PID: 4242, Volume: 80},
+			{ID: "9", Application: "Firefox", Binary: "firefox", PID: 3120, Volume: 50},
+			{ID: "12", Application: "Chromium", Binary: "chromium-browser", PID: 5000, Volume: 40, IsMuted: true},
+			{ID: "14", Application: "Firefox", Binary: "firefox", PID: 3120, Volume: 30},
+		},
+		gone: map[string]bool{},
+	}
+	executor := &AppVolumeExecutor{
+		BaseExecutor:    NewBaseExecutor("app_volume", utils.NewNullLogger()),
+		audioController: controller,
+	}
+	ctx := context.Background()
+	run := func(params map[string]interface{}) Result {
+		t.Helper()
+		action := config.Action{Type: "app_volume", Parameters: params}
+		if err := executor.Validate(action); err != nil {
+			t.Fatalf("Validate(%v): %v", params, err)
+		}
+		result, err := executor.Execute(ctx, action)
+		if err != nil {
+			t.Fatalf("Execute(%v): %v", params, err)
+		}
+		return result
+	}
+
+	// Alle Firefox-Streams werden gleichzeitig gesteuert
+	result := run(map[string]interface{}{"app": "firefox", "direction": "up", "percent": float64(10)})
+	if result.Output != "2 Streams" || controller.streams[1].Volume != 60 || controller.streams[3].Volume != 40 {
+		t.Fatalf("unexpected result %+v, streams %+v", result, controller.streams)
+	}
+
+	// Mit "all": false nur der neueste Stream
+	run(map[string]interface{}{"binary": "firefox", "direction": "set", "volume": float64(20), "all": false})
+	if controller.streams[1].Volume != 60 || controller.streams[3].Volume != 20 {
+		t.Fatalf("all=false changed wrong streams: %+v", controller.streams)
+	}
+
+	// toggle_mute schaltet alle stumm, solange einer hörbar ist
+	result = run(map[string]interface{}{"binary": "*fire*", "direction": "toggle_mute"})
+	if result.State != "muted" || !controller.streams[1].IsMuted || !controller.streams[3].IsMuted {
+		t.Fatalf("toggle_mute: %+v, %+v", result, controller.streams)
+	}
+	result = run(map[string]interface{}{"pid": float64(5000), "direction": "toggle_mute"})
+	if result.State != "unmuted" || controller.streams[2].IsMuted {
+		t.Fatalf("toggle_mute unmute: %+v, %+v", result, controller.streams)
+	}
+
+	// Beendete Streams werden übersprungen
+	controller.gone["9"] = true
+	result = run(map[string]interface{}{"app": "Firefox", "direction": "unmute"})
+	if result.Output != "1 Streams" || !result.Changed {
+		t.Fatalf("gone stream: %+v", result)
+	}
+
+	// Keine Treffer sind kein Fehler
+	result = run(map[string]interface{}{"app": "Discord", "direction": "mute"})
+	if result.Changed || result.Output != "0 Streams" {
+		t.Fatalf("no match: %+v", result)
+	}
+
+	if err := executor.Validate(config.Action{Parameters: map[string]interface{}{"direction": "up"}}); err == nil {
+		t.Fatalf("expected error without selector")
+	}
+}
+
+func TestPactlSinkInputs(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"pactl list sink-inputs":              fixture(t, "pactl_list_sink_inputs.txt"),
+		"pactl set-sink-input-volume 41 35%":  "",
+		"pactl set-sink-input-mute 57 0":      "",
+		"pactl move-sink-input 41 bluez_sink": "",
+	}}
+	c := newPactlController(runner)
+	ctx := context.Background()
+
+	streams, err := c.GetAppStreams(ctx)
+	if err != nil || len(streams) != 2 {
+		t.Fatalf("GetAppStreams = %+v, %v", streams, err)
+	}
+	spotify, firefox := streams[0], streams[1]
+	if spotify.ID != "41" || spotify.Application != "Spotify" || spotify.Binary != "spotify" || spotify.PID != 4242 || spotify.Volume != 80 || spotify.IsMuted {
+		t.Fatalf("unexpected spotify stream: %+v", spotify)
+	}
+	if firefox.ID != "57" || firefox.Volume != 50 || !firefox.IsMuted || firefox.Name != "AudioStream" {
+		t.Fatalf("unexpected firefox stream: %+v", firefox)
+	}
+
+	if err := c.SetAppStreamVolume(ctx, "41", 35); err != nil {
+		t.Fatalf("SetAppStreamVolume: %v", err)
+	}
+	if err := c.SetAppStreamMute(ctx, "57", false); err != nil {
+		t.Fatalf("SetAppStreamMute: %v", err)
+	}
+	if err := c.MoveAppStream(ctx, "41", "bluez_sink"); err != nil {
+		t.Fatalf("MoveAppStream: %v", err)
+	}
+	if err := pactlStreamError(errors.New("Failure: No such entity")); !errors.Is(err, errStreamGone) {
+		t.Fatalf("expected stream gone, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält das Lautstärke-Backend über den ALSA-Mixer (amixer).
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// amixerController steuert einen ALSA-Regler über amixer. Ausgabegeräte kann ALSA nicht wechseln.
+type amixerController struct {
+	runner  CommandRunner
+	card    string
+	control string
+}
+
+func newAmixerController(runner CommandRunner, card, control string) *amixerController {
+	if control == "" {
+		control = "Master"
+	}
+	return &amixerController{runner: runner, card: card, control: control}
+}
+
+func (c *amixerController) GetVolume(ctx context.Context) (int, error) {
+	volume, _, err := c.get(ctx)
+	return volume, err
+}
+
+func (c *amixerController) SetVolume(ctx context.Context, volume int) error {
+	return c.set(ctx, fmt.Sprintf("%d%%", clampPercent(volume)))
+}
+
+func (c *amixerController) IncreaseVolume(ctx context.Context, percent int) error {
+	current, err := c.GetVolume(ctx)
+	if err != nil {
+		return err
+	}
+	return c.SetVolume(ctx, clampPercent(current+percent))
+}
+
+func (c *amixerController) DecreaseVolume(ctx context.Context, percent int) error {
+	current, err := c.GetVolume(ctx)
+	if err != nil {
+		return err
+	}
+	return c.SetVolume(ctx, clampPercent(current-percent))
+}
+
+// GetMute gibt zurück, ob der Regler stummgeschaltet ist
+func (c *amixerController) GetMute(ctx context.Context) (bool, error) {
+	output, err := c.sget(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !amixerHasSwitch(output) {
+		return false, errMuteUnsupported
+	}
+	_, muted, err := parseAmixer(output)
+	return muted, err
+}
+
+// SetMute schaltet den Regler stumm oder wieder laut. Regler ohne Schalter (z.B. "PCM" vieler Karten)
+// melden errMuteUnsupported, damit stattdessen die Lautstärke verwendet wird.
+func (c *amixerController) SetMute(ctx context.Context, muted bool) error {
+	output, err := c.sget(ctx)
+	if err != nil {
+		return err
+	}
+	if !amixerHasSwitch(output) {
+		return errMuteUnsupported
+	}
+	if muted {
+		return c.set(ctx, "mute")
+	}
+	return c.set(ctx, "unmute")
+}
+
+// args gibt die gemeinsamen Argumente (Karte) zurück
+func (c *amixerController) args(args ...string) []string {
+	if c.card != "" {
+		return append([]string{"-c", c.card}, args...)
+	}
+	return args
+}
+
+// get liest Lautstärke und Stummschaltung des Reglers
+func (c *amixerController) get(ctx context.Context) (int, bool, error) {
+	output, err := c.sget(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	return parseAmixer(output)
+}
+
+// sget gibt die Ausgabe von "amixer sget" für den Regler zurück
+func (c *amixerController) sget(ctx context.Context) (string, error) {
+	output, err := c.runner.Run(ctx, "amixer", c.args("sget", c.control)...)
+	return string(output), err
+}
+
+// set setzt einen Wert des Reglers ("50%", "mute", "unmute")
+func (c *amixerController) set(ctx context.Context, value string) error {
+	_, err := c.runner.Run(ctx, "amixer", c.args("-q", "sset", c.control, value)...)
+	return err
+}
+
+// amixerHasSwitch prüft anhand der "Capabilities", ob der Regler stummgeschaltet werden kann.
+// Fehlt die Zeile, wird ein Schalter angenommen.
+func amixerHasSwitch(output string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		if capabilities, ok := strings.CutPrefix(strings.TrimSpace(line), "Capabilities:"); ok {
+			return strings.Contains(capabilities, "switch")
+		}
+	}
+	return true
+}
+
+// parseAmixer liest die Ausgabe von "amixer sget". Gemittelt werden alle Kanäle mit Prozentangabe;
+// stumm ist der Regler, wenn ein Kanal "[off]" meldet. Wiedergabe-Kanäle haben Vorrang vor Aufnahme-Kanälen.
+func parseAmixer(output string) (int, bool, error) {
+	var playback, other []string
+	for _, line := range strings.Split(output, "\n") {
+		_, values, ok := strings.Cut(line, ":")
+		if !ok || !percentPattern.MatchString(values) || !strings.Contains(values, "[") {
+			continue
+		}
+		if strings.Contains(values, "Playback") {
+			playback = append(playback, values)
+		} else {
+			other = append(other, values)
+		}
+	}
+
+	channels := playback
+	if len(channels) == 0 {
+		channels = other
+	}
+	if len(channels) == 0 {
+		return 0, false, fmt.Errorf("keine Lautstärke in der Ausgabe von amixer gefunden")
+	}
+
+	muted := false
+	for _, channel := range channels {
+		if strings.Contains(channel, "[off]") {
+			muted = true
+		}
+	}
+	return averagePercent(strings.Join(channels, " ")), muted, nil
+}
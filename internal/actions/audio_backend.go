@@ -0,0 +1,184 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält die Auswahl der Linux-Audio-Backends und die gemeinsame Ausführung externer Programme.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/internal/pulse"
+)
+
+// CommandRunner führt externe Programme aus. Tests ersetzen ihn durch aufgezeichnete Ausgaben.
+type CommandRunner interface {
+	// Run führt name mit args aus und gibt die Standardausgabe zurück
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+
+	// LookPath prüft ob ein Programm verfügbar ist
+	LookPath(name string) (string, error)
+}
+
+// execRunner führt Programme über os/exec aus
+type execRunner struct{}
+
+// Run führt ein Programm mit englischer Locale aus, damit die Ausgabe unabhängig von der Systemsprache geparst werden kann
+func (execRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return output, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, message)
+		}
+		return output, fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return output, nil
+}
+
+// LookPath sucht ein Programm im PATH
+func (execRunner) LookPath(name string) (string, error) {
+	return exec.LookPath(name)
+}
+
+// resolveAudioBackend bestimmt das zu verwendende Backend. Bei "auto" wird der native Socket bevorzugt,
+// danach wpctl, pactl und amixer; ist nichts davon vorhanden, bleibt es beim nativen Protokoll.
+func resolveAudioBackend(backend string, runner CommandRunner, socketPath string) string {
+	if backend != "" && backend != config.AudioBackendAuto {
+		return backend
+	}
+	if _, err := os.Stat(socketPath); err == nil {
+		return config.AudioBackendPulse
+	}
+	for _, name := range []string{config.AudioBackendWpctl, config.AudioBackendPactl, config.AudioBackendAmixer} {
+		if _, err := runner.LookPath(name); err == nil {
+			return name
+		}
+	}
+	return config.AudioBackendPulse
+}
+
+// pulseConnection hält eine Verbindung zum PulseAudio-Server und baut sie bei Bedarf neu auf
+type pulseConnection struct {
+	socketPath string
+	client     *pulse.Client
+	closed     bool
+	mutex      sync.Mutex
+}
+
+// newPulseConnection erstellt eine Verbindung, die erst bei der ersten Verwendung aufgebaut wird,
+// damit der Daemon auch ohne laufenden Sound-Server startet
+func newPulseConnection() *pulseConnection {
+	return &pulseConnection{socketPath: pulse.SocketPath()}
+}
+
+// get gibt die bestehende Verbindung zurück oder baut sie (erneut) auf
+func (c *pulseConnection) get(ctx context.Context) (*pulse.Client, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return nil, pulse.ErrClosed
+	}
+	if c.client != nil && c.client.Err() == nil {
+		return c.client, nil
+	}
+	if c.client != nil {
+		c.client.Close()
+		c.client = nil
+	}
+
+	client, err := pulse.Dial(ctx, c.socketPath, "MidiDaemon")
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Verbinden mit PulseAudio/PipeWire: %w", err)
+	}
+	c.client = client
+	return client, nil
+}
+
+// close trennt die Verbindung; danach wird sie nicht mehr aufgebaut
+func (c *pulseConnection) close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.closed = true
+	if c.client == nil {
+		return nil
+	}
+	err := c.client.Close()
+	c.client = nil
+	return err
+}
+
+// closeController gibt die Verbindung eines Controllers frei, falls er eine hält
+func closeController(controller interface{}) error {
+	if closer, ok := controller.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// errNoAudioDevices wird gemeldet, wenn ein Backend keine Geräte liefert
+var errNoAudioDevices = errors.New("keine Audiogeräte gefunden")
+
+// guessDeviceType leitet den Gerätetyp aus Namen und Eigenschaften ab. Wiedergabegeräte sind
+// "speakers", "headphones", "hdmi", "bluetooth" oder "usb", Aufnahmegeräte "microphone", "headset", "bluetooth" oder "usb".
+func guessDeviceType(direction AudioDirection, hints ...string) string {
+	text := strings.ToLower(strings.Join(hints, " "))
+	switch {
+	case strings.Contains(text, "bluez") || strings.Contains(text, "bluetooth"):
+		return "bluetooth"
+	case direction == AudioCapture && (strings.Contains(text, "headset") || strings.Contains(text, "headphone")):
+		return "headset"
+	case direction == AudioCapture && strings.Contains(text, "usb"):
+		return "usb"
+	case direction == AudioCapture:
+		return "microphone"
+	case strings.Contains(text, "hdmi") || strings.Contains(text, "displayport"):
+		return "hdmi"
+	case strings.Contains(text, "headphone") || strings.Contains(text, "headset"):
+		return "headphones"
+	case strings.Contains(text, "usb"):
+		return "usb"
+	default:
+		return "speakers"
+	}
+}
+
+// clampPercent begrenzt eine Lautstärke auf 0-100
+func clampPercent(volume int) int {
+	if volume < 0 {
+		return 0
+	}
+	if volume > 100 {
+		return 100
+	}
+	return volume
+}
+
+// findAudioSource sucht eine Audioquelle über ihre ID oder ihren Namen
+func findAudioSource(sources []AudioSource, sourceID string) (AudioSource, error) {
+	for _, source := range sources {
+		if source.ID == sourceID {
+			return source, nil
+		}
+	}
+	for _, source := range sources {
+		if strings.EqualFold(source.Name, sourceID) {
+			return source, nil
+		}
+	}
+	return AudioSource{}, fmt.Errorf("audioquelle '%s' nicht gefunden", sourceID)
+}
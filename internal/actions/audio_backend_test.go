@@ -0,0 +1,235 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+// fakeRunner beantwortet Befehle mit aufgezeichneten Ausgaben und merkt sich alle Aufrufe
+type fakeRunner struct {
+	outputs   map[string]string // "programm arg1 arg2" -> Ausgabe
+	available map[string]bool
+	calls     []string
+}
+
+func (r *fakeRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	call := strings.Join(append([]string{name}, args...), " ")
+	r.calls = append(r.calls, call)
+	output, ok := r.outputs[call]
+	if !ok {
+		return nil, fmt.Errorf("unerwarteter Befehl: %s", call)
+	}
+	return []byte(output), nil
+}
+
+func (r *fakeRunner) LookPath(name string) (string, error) {
+	if r.available[name] {
+		return "/usr/bin/" + name, nil
+	}
+	return "", fmt.Errorf("%s nicht gefunden", name)
+}
+
+func fixture(t *testing.T, name string) string {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("fixture %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestPactlController(t *testing.T) {
+	// Ohne "get-default-sink" (pactl < 15) muss der Standard-Sink aus "pactl info" kommen
+	runner := &fakeRunner{outputs: map[string]string{
+		"pactl list sinks": fixture(t, "pactl_list_sinks.txt"),
+		"pactl info":       fixture(t, "pactl_info.txt"),
+		"pactl set-sink-volume @DEFAULT_SINK@ 75%":                          "",
+		"pactl set-default-sink alsa_output.pci-0000_00_1f.3.analog-stereo": "",
+		"pactl set-sink-mute bluez_output.00_1B_66_AA_BB_CC.1 0":            "",
+		"pactl list sources": fixture(t, "pactl_list_sources.txt"),
+		"pactl set-default-source bluez_input.00_1B_66_AA_BB_CC.0":              "",
+		"pactl set-source-volume alsa_input.pci-0000_00_1f.3.analog-stereo 40%": "",
+	}}
+	c := newPactlController(runner)
+	ctx := context.Background()
+
+	if volume, err := c.GetVolume(ctx); err != nil || volume != 70 {
+		t.Fatalf("GetVolume = %d, %v", volume, err)
+	}
+	if muted, err := c.GetMute(ctx); err != nil || !muted {
+		t.Fatalf("GetMute = %v, %v", muted, err)
+	}
+	if err := c.IncreaseVolume(ctx, 5); err != nil {
+		t.Fatalf("IncreaseVolume: %v", err)
+	}
+
+	sources, err := c.GetAudioSources(ctx, AudioPlayback)
+	if err != nil || len(sources) != 2 {
+		t.Fatalf("GetAudioSources = %v, %v", sources, err)
+	}
+	builtin, bluetooth := sources[0], sources[1]
+	if builtin.Volume != 52 || builtin.Type != "speakers" || builtin.IsDefault || !builtin.IsAvailable {
+		t.Fatalf("unexpected built-in sink: %+v", builtin)
+	}
+	if bluetooth.Type != "bluetooth" || !bluetooth.IsDefault || !bluetooth.IsMuted || bluetooth.Name != "WH-1000XM4" {
+		t.Fatalf("unexpected bluetooth sink: %+v", bluetooth)
+	}
+
+	if err := c.SetDefaultAudioSource(ctx, AudioPlayback, "Built-in Audio Analog Stereo"); err != nil {
+		t.Fatalf("SetDefaultAudioSource: %v", err)
+	}
+	if err := c.UnmuteAudioSource(ctx, AudioPlayback, "bluez_output.00_1B_66_AA_BB_CC.1"); err != nil {
+		t.Fatalf("UnmuteAudioSource: %v", err)
+	}
+	if err := c.SetDefaultAudioSource(ctx, AudioPlayback, "hdmi"); err == nil {
+		t.Fatalf("expected error for unknown sink")
+	}
+
+	// Eingabegeräte ohne Monitor-Sources, Standard aus "Default Source"
+	inputs, err := c.GetAudioSources(ctx, AudioCapture)
+	if err != nil || len(inputs) != 2 {
+		t.Fatalf("GetAudioSources(capture) = %+v, %v", inputs, err)
+	}
+	mic, headset := inputs[0], inputs[1]
+	if !mic.IsDefault || mic.Type != "microphone" || mic.Volume != 70 || mic.Direction != AudioCapture {
+		t.Fatalf("unexpected microphone: %+v", mic)
+	}
+	if headset.Type != "bluetooth" || !headset.IsMuted || headset.IsDefault {
+		t.Fatalf("unexpected headset: %+v", headset)
+	}
+	if err := c.SetDefaultAudioSource(ctx, AudioCapture, "WH-1000XM4"); err != nil {
+		t.Fatalf("SetDefaultAudioSource(capture): %v", err)
+	}
+	if err := c.SetAudioSourceVolume(ctx, AudioCapture, "Built-in Audio Analog Stereo", 40); err != nil {
+		t.Fatalf("SetAudioSourceVolume(capture): %v", err)
+	}
+	if err := c.MuteAudioSource(ctx, AudioCapture, "alsa_output.pci-0000_00_1f.3.analog-stereo.monitor"); err == nil {
+		t.Fatalf("expected error for monitor source")
+	}
+}
+
+func TestPactlAvailability(t *testing.T) {
+	output := strings.Replace(fixture(t, "pactl_list_sinks.txt"),
+		"Active Port: analog-output-speaker", "Active Port: analog-output-headphones", 1)
+	sinks := parsePactlDevices(output, "Sink")
+	if len(sinks) != 2 || sinks[0].Available || !sinks[1].Available {
+		t.Fatalf("unexpected availability: %+v", sinks)
+	}
+}
+
+func TestWpctlController(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"wpctl get-volume @DEFAULT_AUDIO_SINK@":      "Volume: 0.70 [MUTED]\n",
+		"wpctl set-volume @DEFAULT_AUDIO_SINK@ 0.60": "",
+		"wpctl status":         fixture(t, "wpctl_status.txt"),
+		"wpctl set-default 48": "",
+	}}
+	c := newWpctlController(runner)
+	ctx := context.Background()
+
+	if volume, err := c.GetVolume(ctx); err != nil || volume != 70 {
+		t.Fatalf("GetVolume = %d, %v", volume, err)
+	}
+	if muted, err := c.GetMute(ctx); err != nil || !muted {
+		t.Fatalf("GetMute = %v, %v", muted, err)
+	}
+	if err := c.DecreaseVolume(ctx, 10); err != nil {
+		t.Fatalf("DecreaseVolume: %v", err)
+	}
+
+	// Nur die Sinks aus dem Abschnitt "Audio", nicht die aus "Video"
+	sources, err := c.GetAudioSources(ctx, AudioPlayback)
+	if err != nil || len(sources) != 2 {
+		t.Fatalf("GetAudioSources = %+v, %v", sources, err)
+	}
+	if sources[0].ID != "48" || sources[0].Volume != 50 || sources[0].IsDefault || sources[0].IsMuted {
+		t.Fatalf("unexpected first sink: %+v", sources[0])
+	}
+	if sources[1].ID != "66" || sources[1].Name != "WH-1000XM4" || !sources[1].IsDefault || !sources[1].IsMuted {
+		t.Fatalf("unexpected second sink: %+v", sources[1])
+	}
+
+	if err := c.SetDefaultAudioSource(ctx, AudioPlayback, "Built-in Audio Analog Stereo"); err != nil {
+		t.Fatalf("SetDefaultAudioSource: %v", err)
+	}
+
+	// Eingabegeräte stehen im Unterabschnitt "Sources" und haben eigene IDs
+	if input, err := c.GetDefaultAudioSource(ctx, AudioCapture); err != nil || input.ID != "49" || input.Type != "microphone" {
+		t.Fatalf("GetDefaultAudioSource(capture) = %+v, %v", input, err)
+	}
+	if err := c.SetDefaultAudioSource(ctx, AudioCapture, "WH-1000XM4"); err == nil {
+		t.Fatalf("expected error for playback device in capture direction")
+	}
+
+	if _, _, err := parseWpctlVolume("Error: failed to connect"); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
+func TestAmixerController(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"amixer -c 1 sget PCM":         fixture(t, "amixer_master.txt"),
+		"amixer -c 1 -q sset PCM mute": "",
+		"amixer -c 1 -q sset PCM 100%": "",
+	}}
+	c := newAmixerController(runner, "1", "PCM")
+	ctx := context.Background()
+
+	if volume, err := c.GetVolume(ctx); err != nil || volume != 63 {
+		t.Fatalf("GetVolume = %d, %v", volume, err)
+	}
+	if err := c.SetMute(ctx, true); err != nil {
+		t.Fatalf("SetMute: %v", err)
+	}
+	if err := c.IncreaseVolume(ctx, 50); err != nil {
+		t.Fatalf("IncreaseVolume: %v", err)
+	}
+
+	volume, muted, err := parseAmixer(fixture(t, "amixer_master_mono.txt"))
+	if err != nil || volume != 41 || !muted {
+		t.Fatalf("parseAmixer(mono) = %d, %v, %v", volume, muted, err)
+	}
+	if _, _, err := parseAmixer("amixer: Unable to find simple control 'Master',0\n"); err == nil {
+		t.Fatalf("expected parse error")
+	}
+
+	// Regler ohne Schalter können nicht stummgeschaltet werden
+	pcm := newAmixerController(&fakeRunner{outputs: map[string]string{
+		"amixer sget PCM": fixture(t, "amixer_pcm.txt"),
+	}}, "", "PCM")
+	if volume, err := pcm.GetVolume(ctx); err != nil || volume != 80 {
+		t.Fatalf("GetVolume(pcm) = %d, %v", volume, err)
+	}
+	if err := pcm.SetMute(ctx, true); !errors.Is(err, errMuteUnsupported) {
+		t.Fatalf("SetMute(pcm) = %v", err)
+	}
+}
+
+func TestResolveAudioBackend(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "native")
+	runner := &fakeRunner{available: map[string]bool{"pactl": true, "amixer": true}}
+
+	if backend := resolveAudioBackend(config.AudioBackendAuto, runner, missing); backend != config.AudioBackendPactl {
+		t.Fatalf("auto without socket = %s", backend)
+	}
+	if backend := resolveAudioBackend(config.AudioBackendAmixer, runner, missing); backend != config.AudioBackendAmixer {
+		t.Fatalf("explicit backend = %s", backend)
+	}
+	if backend := resolveAudioBackend("", &fakeRunner{}, missing); backend != config.AudioBackendPulse {
+		t.Fatalf("auto without anything = %s", backend)
+	}
+
+	socket := filepath.Join(t.TempDir(), "native")
+	if err := os.WriteFile(socket, nil, 0600); err != nil {
+		t.Fatalf("socket: %v", err)
+	}
+	if backend := resolveAudioBackend(config.AudioBackendAuto, runner, socket); backend != config.AudioBackendPulse {
+		t.Fatalf("auto with socket = %s", backend)
+	}
+}
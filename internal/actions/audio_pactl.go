@@ -0,0 +1,412 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält das Audio-Backend über die PulseAudio-Kommandozeile (pactl).
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pactlDefaultSink bezeichnet bei pactl den Standard-Sink
+const pactlDefaultSink = "@DEFAULT_SINK@"
+
+// percentPattern findet Prozentangaben wie "50%" oder "[50%]"
+var percentPattern = regexp.MustCompile(`(\d+)%`)
+
+// pactlController steuert Lautstärke und Ausgabegeräte über pactl
+type pactlController struct {
+	runner CommandRunner
+}
+
+// pactlDevice ist ein Sink oder eine Source aus der Ausgabe von "pactl list sinks|sources"
+type pactlDevice struct {
+	Name        string
+	Description string
+	Volume      int
+	Muted       bool
+	ActivePort  string
+	Available   bool
+	Monitor     bool // Source, die nur einen Sink mitschneidet
+	Properties  map[string]string
+}
+
+func newPactlController(runner CommandRunner) *pactlController {
+	return &pactlController{runner: runner}
+}
+
+func (c *pactlController) GetVolume(ctx context.Context) (int, error) {
+	sink, err := c.defaultSink(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return sink.Volume, nil
+}
+
+func (c *pactlController) SetVolume(ctx context.Context, volume int) error {
+	return c.run(ctx, "set-sink-volume", pactlDefaultSink, fmt.Sprintf("%d%%", clampPercent(volume)))
+}
+
+func (c *pactlController) IncreaseVolume(ctx context.Context, percent int) error {
+	current, err := c.GetVolume(ctx)
+	if err != nil {
+		return err
+	}
+	return c.SetVolume(ctx, clampPercent(current+percent))
+}
+
+func (c *pactlController) DecreaseVolume(ctx context.Context, percent int) error {
+	current, err := c.GetVolume(ctx)
+	if err != nil {
+		return err
+	}
+	return c.SetVolume(ctx, clampPercent(current-percent))
+}
+
+// GetMute gibt zurück, ob der Standard-Sink stummgeschaltet ist
+func (c *pactlController) GetMute(ctx context.Context) (bool, error) {
+	sink, err := c.defaultSink(ctx)
+	if err != nil {
+		return false, err
+	}
+	return sink.Muted, nil
+}
+
+// SetMute schaltet den Standard-Sink stumm oder wieder laut
+func (c *pactlController) SetMute(ctx context.Context, muted bool) error {
+	return c.run(ctx, "set-sink-mute", pactlDefaultSink, boolArg(muted))
+}
+
+func (c *pactlController) GetAudioSources(ctx context.Context, direction AudioDirection) ([]AudioSource, error) {
+	devices, err := c.devices(ctx, direction)
+	if err != nil {
+		return nil, err
+	}
+	defaultName, err := c.defaultName(ctx, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]AudioSource, 0, len(devices))
+	for _, device := range devices {
+		sources = append(sources, AudioSource{
+			ID:          device.Name,
+			Name:        device.Description,
+			Type:        guessDeviceType(direction, device.Name, device.ActivePort, device.Properties["device.bus"], device.Properties["device.form_factor"]),
+			Direction:   direction,
+			IsDefault:   device.Name == defaultName,
+			IsMuted:     device.Muted,
+			Volume:      device.Volume,
+			IsAvailable: device.Available,
+		})
+	}
+	return sources, nil
+}
+
+func (c *pactlController) SetDefaultAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	name, err := c.resolve(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	return c.run(ctx, "set-default-"+pactlKind(direction), name)
+}
+
+func (c *pactlController) GetDefaultAudioSource(ctx context.Context, direction AudioDirection) (AudioSource, error) {
+	sources, err := c.GetAudioSources(ctx, direction)
+	if err != nil {
+		return AudioSource{}, err
+	}
+	for _, source := range sources {
+		if source.IsDefault {
+			return source, nil
+		}
+	}
+	return AudioSource{}, errNoAudioDevices
+}
+
+func (c *pactlController) MuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	name, err := c.resolve(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	return c.run(ctx, "set-"+pactlKind(direction)+"-mute", name, "1")
+}
+
+func (c *pactlController) UnmuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	name, err := c.resolve(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	return c.run(ctx, "set-"+pactlKind(direction)+"-mute", name, "0")
+}
+
+func (c *pactlController) SetAudioSourceVolume(ctx context.Context, direction AudioDirection, sourceID string, volume int) error {
+	name, err := c.resolve(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	return c.run(ctx, "set-"+pactlKind(direction)+"-volume", name, fmt.Sprintf("%d%%", clampPercent(volume)))
+}
+
+// GetAppStreams listet die Wiedergabe-Streams aller Anwendungen auf
+func (c *pactlController) GetAppStreams(ctx context.Context) ([]AppStream, error) {
+	output, err := c.runner.Run(ctx, "pactl", "list", "sink-inputs")
+	if err != nil {
+		return nil, err
+	}
+	return parsePactlSinkInputs(string(output)), nil
+}
+
+// SetAppStreamVolume setzt die Lautstärke eines Streams
+func (c *pactlController) SetAppStreamVolume(ctx context.Context, streamID string, volume int) error {
+	return pactlStreamError(c.run(ctx, "set-sink-input-volume", streamID, fmt.Sprintf("%d%%", clampPercent(volume))))
+}
+
+// SetAppStreamMute schaltet einen Stream stumm oder wieder laut
+func (c *pactlController) SetAppStreamMute(ctx context.Context, streamID string, muted bool) error {
+	return pactlStreamError(c.run(ctx, "set-sink-input-mute", streamID, boolArg(muted)))
+}
+
+// MoveAppStream verschiebt einen Stream auf einen anderen Sink
+func (c *pactlController) MoveAppStream(ctx context.Context, streamID, sinkID string) error {
+	return pactlStreamError(c.run(ctx, "move-sink-input", streamID, sinkID))
+}
+
+// pactlStreamError erkennt Streams, die inzwischen beendet wurden
+func pactlStreamError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "No such entity") {
+		return fmt.Errorf("%w: %v", errStreamGone, err)
+	}
+	return err
+}
+
+// run führt einen pactl-Befehl ohne Ausgabe aus
+func (c *pactlController) run(ctx context.Context, args ...string) error {
+	_, err := c.runner.Run(ctx, "pactl", args...)
+	return err
+}
+
+// devices listet alle Sinks bzw. alle Sources ohne Monitor-Sources auf
+func (c *pactlController) devices(ctx context.Context, direction AudioDirection) ([]pactlDevice, error) {
+	output, err := c.runner.Run(ctx, "pactl", "list", pactlKind(direction)+"s")
+	if err != nil {
+		return nil, err
+	}
+	if direction != AudioCapture {
+		return parsePactlDevices(string(output), "Sink"), nil
+	}
+
+	var inputs []pactlDevice
+	for _, device := range parsePactlDevices(string(output), "Source") {
+		if !device.Monitor {
+			inputs = append(inputs, device)
+		}
+	}
+	return inputs, nil
+}
+
+// defaultName ermittelt den Namen des Standard-Sinks bzw. der Standard-Source
+// ("get-default-sink" und "get-default-source" gibt es erst ab pactl 15)
+func (c *pactlController) defaultName(ctx context.Context, direction AudioDirection) (string, error) {
+	kind := pactlKind(direction)
+	if output, err := c.runner.Run(ctx, "pactl", "get-default-"+kind); err == nil {
+		if name := strings.TrimSpace(string(output)); name != "" {
+			return name, nil
+		}
+	}
+
+	output, err := c.runner.Run(ctx, "pactl", "info")
+	if err != nil {
+		return "", err
+	}
+	field := "Default Sink"
+	if direction == AudioCapture {
+		field = "Default Source"
+	}
+	if name := parsePactlInfo(string(output), field); name != "" {
+		return name, nil
+	}
+	return "", fmt.Errorf("standardgerät (%s) nicht in der Ausgabe von pactl info gefunden", kind)
+}
+
+// defaultSink gibt den Standard-Sink zurück
+func (c *pactlController) defaultSink(ctx context.Context) (pactlDevice, error) {
+	name, err := c.defaultName(ctx, AudioPlayback)
+	if err != nil {
+		return pactlDevice{}, err
+	}
+	sinks, err := c.devices(ctx, AudioPlayback)
+	if err != nil {
+		return pactlDevice{}, err
+	}
+	for _, sink := range sinks {
+		if sink.Name == name {
+			return sink, nil
+		}
+	}
+	return pactlDevice{}, fmt.Errorf("standard-Sink '%s' nicht gefunden", name)
+}
+
+// resolve ermittelt den Geräte-Namen zu einer ID oder Beschreibung
+func (c *pactlController) resolve(ctx context.Context, direction AudioDirection, sourceID string) (string, error) {
+	sources, err := c.GetAudioSources(ctx, direction)
+	if err != nil {
+		return "", err
+	}
+	source, err := findAudioSource(sources, sourceID)
+	if err != nil {
+		return "", err
+	}
+	return source.ID, nil
+}
+
+// pactlKind gibt die Geräteart in den pactl-Befehlen zurück ("sink" oder "source")
+func pactlKind(direction AudioDirection) string {
+	if direction == AudioCapture {
+		return "source"
+	}
+	return "sink"
+}
+
+// pactlBlock ist ein Eintrag aus der Ausgabe von "pactl list" (z.B. "Sink #0")
+type pactlBlock struct {
+	Index      string
+	Fields     map[string]string
+	Properties map[string]string
+	Ports      map[string]bool // Port-Name -> verfügbar
+}
+
+// parsePactlBlocks zerlegt die Ausgabe von "pactl list <typ>" in Einträge, die mit header beginnen.
+// Unbekannte Felder werden nur gesammelt, damit ältere und neuere pactl-Versionen gleichermaßen funktionieren.
+func parsePactlBlocks(output, header string) []pactlBlock {
+	var blocks []pactlBlock
+	var current *pactlBlock
+	section := ""
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(line, header+" #") {
+			blocks = append(blocks, pactlBlock{
+				Index:      strings.TrimPrefix(line, header+" #"),
+				Fields:     make(map[string]string),
+				Properties: make(map[string]string),
+				Ports:      make(map[string]bool),
+			})
+			current = &blocks[len(blocks)-1]
+			section = ""
+			continue
+		}
+		if current == nil || trimmed == "" {
+			continue
+		}
+
+		depth := len(line) - len(strings.TrimLeft(line, "\t"))
+		if depth >= 2 {
+			switch section {
+			case "Properties":
+				if key, value, ok := strings.Cut(trimmed, " = "); ok {
+					current.Properties[key] = strings.Trim(value, `"`)
+				}
+			case "Ports":
+				if name, rest, ok := strings.Cut(trimmed, ": "); ok {
+					current.Ports[name] = !strings.Contains(rest, "not available")
+				}
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(trimmed, ":")
+		value = strings.TrimSpace(value)
+		section = ""
+		if value == "" && (key == "Properties" || key == "Ports") {
+			section = key
+			continue
+		}
+		// Fortsetzungszeilen (z.B. "balance 0.00") haben keinen Schlüssel
+		if _, exists := current.Fields[key]; !exists && strings.Contains(trimmed, ":") {
+			current.Fields[key] = value
+		}
+	}
+
+	return blocks
+}
+
+// parsePactlDevices liest die Ausgabe von "pactl list sinks" (header "Sink") bzw. "pactl list sources" (header "Source")
+func parsePactlDevices(output, header string) []pactlDevice {
+	var devices []pactlDevice
+	for _, block := range parsePactlBlocks(output, header) {
+		device := pactlDevice{
+			Name:        block.Fields["Name"],
+			Description: block.Fields["Description"],
+			Volume:      averagePercent(block.Fields["Volume"]),
+			Muted:       block.Fields["Mute"] == "yes",
+			ActivePort:  block.Fields["Active Port"],
+			Available:   true,
+			Properties:  block.Properties,
+		}
+		if monitorOf := block.Fields["Monitor of Sink"]; monitorOf != "" && monitorOf != "n/a" {
+			device.Monitor = true
+		}
+		if block.Properties["device.class"] == "monitor" {
+			device.Monitor = true
+		}
+		if available, ok := block.Ports[device.ActivePort]; ok {
+			device.Available = available
+		}
+		devices = append(devices, device)
+	}
+	return devices
+}
+
+// parsePactlSinkInputs liest die Ausgabe von "pactl list sink-inputs"
+func parsePactlSinkInputs(output string) []AppStream {
+	var streams []AppStream
+	for _, block := range parsePactlBlocks(output, "Sink Input") {
+		pid, _ := strconv.Atoi(block.Properties["application.process.id"])
+		streams = append(streams, AppStream{
+			ID:          block.Index,
+			Application: block.Properties["application.name"],
+			Binary:      block.Properties["application.process.binary"],
+			PID:         pid,
+			Name:        block.Properties["media.name"],
+			Volume:      averagePercent(block.Fields["Volume"]),
+			IsMuted:     block.Fields["Mute"] == "yes",
+		})
+	}
+	return streams
+}
+
+// parsePactlInfo liest ein Feld (z.B. "Default Sink") aus der Ausgabe von "pactl info"
+func parsePactlInfo(output, field string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(key) == field {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// averagePercent mittelt alle Prozentangaben eines Textes (z.B. pro Kanal)
+func averagePercent(text string) int {
+	matches := percentPattern.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, match := range matches {
+		value, _ := parseInt(match[1])
+		sum += value
+	}
+	return (sum + len(matches)/2) / len(matches)
+}
+
+// boolArg wandelt einen Wahrheitswert in das Argument "1" bzw. "0" um
+func boolArg(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
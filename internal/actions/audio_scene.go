@@ -0,0 +1,445 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält die Audio-Szenen (Schnappschüsse des Mixer-Zustands).
+
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// AudioScene ist ein gespeicherter Mixer-Zustand
+type AudioScene struct {
+	// Standardgeräte je Richtung
+	Playback string `json:"playback,omitempty"`
+	Capture  string `json:"capture,omitempty"`
+
+	Devices []SceneDevice `json:"devices"`
+	Apps    []SceneApp    `json:"apps,omitempty"`
+	Saved   time.Time     `json:"saved"`
+}
+
+// SceneDevice ist der gespeicherte Zustand eines Geräts
+type SceneDevice struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Direction AudioDirection `json:"direction"`
+	Volume    int            `json:"volume"`
+	Muted     bool           `json:"muted"`
+}
+
+// SceneApp ist der gespeicherte Zustand einer Anwendung. Stream-IDs ändern sich mit jedem
+// Abspielen, deshalb werden Anwendungen über Programm und Namen wiedererkannt.
+type SceneApp struct {
+	Application string `json:"application"`
+	Binary      string `json:"binary,omitempty"`
+	Volume      int    `json:"volume"`
+	Muted       bool   `json:"muted"`
+}
+
+// matches prüft, ob ein laufender Stream zur gespeicherten Anwendung gehört
+func (a SceneApp) matches(stream AppStream) bool {
+	return a.Application == stream.Application && a.Binary == stream.Binary
+}
+
+// SceneStore speichert Audio-Szenen; mit Dateipfad werden sie dauerhaft abgelegt
+type SceneStore struct {
+	file   string
+	scenes map[string]AudioScene
+	loaded bool
+	mutex  sync.Mutex
+}
+
+// NewSceneStore erstellt einen Szenenspeicher (leerer Pfad = nur im Speicher)
+func NewSceneStore(file string) *SceneStore {
+	return &SceneStore{
+		file:   file,
+		scenes: make(map[string]AudioScene),
+	}
+}
+
+// Get gibt eine gespeicherte Szene zurück
+func (s *SceneStore) Get(name string) (AudioScene, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(); err != nil {
+		return AudioScene{}, false, err
+	}
+	scene, ok := s.scenes[name]
+	return scene, ok, nil
+}
+
+// Names gibt die Namen aller Szenen sortiert zurück
+func (s *SceneStore) Names() ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(s.scenes))
+	for name := range s.scenes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Put speichert eine Szene
+func (s *SceneStore) Put(name string, scene AudioScene) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.scenes[name] = scene
+	return s.save()
+}
+
+// Delete entfernt eine Szene und meldet, ob sie existiert hat
+func (s *SceneStore) Delete(name string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.load(); err != nil {
+		return false, err
+	}
+	if _, ok := s.scenes[name]; !ok {
+		return false, nil
+	}
+	delete(s.scenes, name)
+	return true, s.save()
+}
+
+// load liest die Datei beim ersten Zugriff; eine fehlende Datei ergibt einen leeren Speicher
+func (s *SceneStore) load() error {
+	if s.loaded || s.file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.file)
+	if errors.Is(err, os.ErrNotExist) {
+		s.loaded = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fehler beim Lesen der Szenen-Datei: %w", err)
+	}
+	scenes := make(map[string]AudioScene)
+	if err := json.Unmarshal(data, &scenes); err != nil {
+		return fmt.Errorf("fehler beim Parsen der Szenen-Datei %s: %w", s.file, err)
+	}
+	s.scenes = scenes
+	s.loaded = true
+	return nil
+}
+
+// save schreibt alle Szenen über eine temporäre Datei, damit ein Abbruch die Datei nicht beschädigt
+func (s *SceneStore) save() error {
+	if s.file == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.scenes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("fehler beim Kodieren der Szenen: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.file), filepath.Base(s.file)+".*")
+	if err != nil {
+		return fmt.Errorf("fehler beim Schreiben der Szenen-Datei: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("fehler beim Schreiben der Szenen-Datei: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("fehler beim Schreiben der Szenen-Datei: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.file); err != nil {
+		return fmt.Errorf("fehler beim Schreiben der Szenen-Datei: %w", err)
+	}
+	return nil
+}
+
+// AudioSceneExecutor speichert und lädt Audio-Szenen
+type AudioSceneExecutor struct {
+	BaseExecutor
+	audioController AudioController
+	scenes          *SceneStore
+	fader           *fader
+}
+
+// NewAudioSceneExecutor erstellt einen neuen Audio-Scene-Executor
+func NewAudioSceneExecutor(audio config.AudioConfig, scenesFile string, logger utils.Logger) (*AudioSceneExecutor, error) {
+	controller, err := newAudioController(audio)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Erstellen des Audio-Controllers: %w", err)
+	}
+
+	executor := &AudioSceneExecutor{
+		BaseExecutor:    NewBaseExecutor("audio_scene", logger),
+		audioController: controller,
+		scenes:          NewSceneStore(scenesFile),
+		fader:           newFader(),
+	}
+
+	return executor, nil
+}
+
+// Execute führt eine Audio-Scene-Aktion aus
+func (e *AudioSceneExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
+	e.LogDebug("Führe Audio-Scene-Aktion aus", "parameters", action.Parameters)
+
+	name, _ := action.Parameters["scene"].(string)
+	operation, _ := action.Parameters["operation"].(string)
+
+	switch operation {
+	case "save":
+		scene, err := e.capture(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		if err := e.scenes.Put(name, scene); err != nil {
+			return Result{}, err
+		}
+		e.LogInfo("Audio-Szene gespeichert", "scene", name, "devices", len(scene.Devices), "apps", len(scene.Apps))
+		return Result{State: name, Output: fmt.Sprintf("%d Geräte, %d Anwendungen", len(scene.Devices), len(scene.Apps))}, nil
+
+	case "restore":
+		scene, ok, err := e.scenes.Get(name)
+		if err != nil {
+			return Result{}, err
+		}
+		if !ok {
+			return Result{}, fmt.Errorf("audio-szene '%s' nicht gefunden", name)
+		}
+		duration, easing, err := fadeTiming(action.Parameters, 0)
+		if err != nil {
+			return Result{}, err
+		}
+		e.LogInfo("Stelle Audio-Szene wieder her", "scene", name, "duration", duration)
+		return e.restore(ctx, name, scene, duration, easing)
+
+	case "delete":
+		deleted, err := e.scenes.Delete(name)
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Changed: deleted, State: name}, nil
+
+	default:
+		return Result{}, fmt.Errorf("ungültige Operation: %s (erwartet: save, restore, delete)", operation)
+	}
+}
+
+// capture erfasst Standardgeräte, Geräte-Lautstärken und – falls unterstützt – die Lautstärken der Anwendungen
+func (e *AudioSceneExecutor) capture(ctx context.Context) (AudioScene, error) {
+	scene := AudioScene{Saved: time.Now()}
+
+	for _, direction := range []AudioDirection{AudioPlayback, AudioCapture} {
+		sources, err := e.audioController.GetAudioSources(ctx, direction)
+		if err != nil {
+			return AudioScene{}, fmt.Errorf("fehler beim Abrufen der Audioquellen: %w", err)
+		}
+		for _, source := range sources {
+			if source.IsDefault {
+				if direction == AudioCapture {
+					scene.Capture = source.ID
+				} else {
+					scene.Playback = source.ID
+				}
+			}
+			scene.Devices = append(scene.Devices, SceneDevice{
+				ID:        source.ID,
+				Name:      source.Name,
+				Direction: direction,
+				Volume:    source.Volume,
+				Muted:     source.IsMuted,
+			})
+		}
+	}
+
+	controller, ok := e.audioController.(AppAudioController)
+	if !ok {
+		return scene, nil
+	}
+	streams, err := controller.GetAppStreams(ctx)
+	if err != nil {
+		return AudioScene{}, fmt.Errorf("fehler beim Abrufen der Streams: %w", err)
+	}
+	// Mehrere Streams einer Anwendung werden zusammengefasst, der neueste gewinnt
+	index := make(map[SceneApp]int)
+	for _, stream := range streams {
+		key := SceneApp{Application: stream.Application, Binary: stream.Binary}
+		app := SceneApp{Application: stream.Application, Binary: stream.Binary, Volume: stream.Volume, Muted: stream.IsMuted}
+		if i, ok := index[key]; ok {
+			scene.Apps[i] = app
+			continue
+		}
+		index[key] = len(scene.Apps)
+		scene.Apps = append(scene.Apps, app)
+	}
+	return scene, nil
+}
+
+// restore stellt eine Szene wieder her. Nicht angeschlossene Geräte und nicht laufende Anwendungen
+// werden übersprungen; mit duration werden alle Lautstärken gleichzeitig übergeblendet.
+func (e *AudioSceneExecutor) restore(ctx context.Context, name string, scene AudioScene, duration time.Duration, easing Easing) (Result, error) {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var errs []error
+	fail := func(err error) {
+		mutex.Lock()
+		errs = append(errs, err)
+		mutex.Unlock()
+	}
+	setVolume := func(target string, from, to int, set func(ctx context.Context, volume int) error) {
+		if duration == 0 || from == to {
+			if err := set(ctx, to); err != nil {
+				fail(err)
+			}
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := e.fader.fade(ctx, target, from, to, duration, easing, set); err != nil {
+				fail(err)
+			}
+		}()
+	}
+
+	devices, skipped := 0, 0
+	for _, direction := range []AudioDirection{AudioPlayback, AudioCapture} {
+		sources, err := e.audioController.GetAudioSources(ctx, direction)
+		if err != nil {
+			return Result{}, fmt.Errorf("fehler beim Abrufen der Audioquellen: %w", err)
+		}
+
+		defaultID := scene.Playback
+		if direction == AudioCapture {
+			defaultID = scene.Capture
+		}
+		if defaultID != "" {
+			if source, err := findAudioSource(sources, defaultID); err == nil {
+				if err := e.audioController.SetDefaultAudioSource(ctx, direction, source.ID); err != nil {
+					fail(err)
+				}
+			} else {
+				e.LogWarn("Standardgerät der Szene nicht gefunden", "scene", name, "device", defaultID)
+			}
+		}
+
+		for _, device := range scene.Devices {
+			if device.Direction != direction {
+				continue
+			}
+			// IDs mancher Backends (wpctl) ändern sich nach einem Neustart, dann hilft der Name
+			source, err := findAudioSource(sources, device.ID)
+			if err != nil {
+				source, err = findAudioSource(sources, device.Name)
+			}
+			if err != nil {
+				skipped++
+				continue
+			}
+			devices++
+
+			// Erst die Stummschaltung, damit ein Gerät beim Aufheben hörbar aufblendet
+			if source.IsMuted != device.Muted {
+				var err error
+				if device.Muted {
+					err = e.audioController.MuteAudioSource(ctx, direction, source.ID)
+				} else {
+					err = e.audioController.UnmuteAudioSource(ctx, direction, source.ID)
+				}
+				if err != nil {
+					fail(err)
+				}
+			}
+			sourceID := source.ID
+			setVolume(fadeTarget(direction, sourceID), source.Volume, device.Volume, func(ctx context.Context, volume int) error {
+				return e.audioController.SetAudioSourceVolume(ctx, direction, sourceID, volume)
+			})
+		}
+	}
+
+	streams := 0
+	if controller, ok := e.audioController.(AppAudioController); ok && len(scene.Apps) > 0 {
+		running, err := controller.GetAppStreams(ctx)
+		if err != nil {
+			fail(fmt.Errorf("fehler beim Abrufen der Streams: %w", err))
+		}
+		for _, app := range scene.Apps {
+			for _, stream := range running {
+				if !app.matches(stream) {
+					continue
+				}
+				streams++
+				if stream.IsMuted != app.Muted {
+					if err := controller.SetAppStreamMute(ctx, stream.ID, app.Muted); err != nil && !errors.Is(err, errStreamGone) {
+						fail(err)
+					}
+				}
+				streamID := stream.ID
+				setVolume("app:"+streamID, stream.Volume, app.Volume, func(ctx context.Context, volume int) error {
+					err := controller.SetAppStreamVolume(ctx, streamID, volume)
+					if errors.Is(err, errStreamGone) {
+						return nil
+					}
+					return err
+				})
+			}
+		}
+	}
+
+	wg.Wait()
+	if skipped > 0 {
+		e.LogWarn("Geräte der Szene nicht gefunden", "scene", name, "count", skipped)
+	}
+	if len(errs) > 0 {
+		return Result{Changed: true, State: name}, fmt.Errorf("fehler beim Wiederherstellen der Audio-Szene '%s': %w", name, errors.Join(errs...))
+	}
+	return Result{Changed: true, State: name, Output: fmt.Sprintf("%d Geräte, %d Streams", devices, streams)}, nil
+}
+
+// Validate überprüft eine Audio-Scene-Aktion auf Gültigkeit
+func (e *AudioSceneExecutor) Validate(action config.Action) error {
+	name, ok := action.Parameters["scene"].(string)
+	if !ok || name == "" {
+		return fmt.Errorf("audio_scene-Aktion benötigt 'scene' Parameter")
+	}
+
+	operation, ok := action.Parameters["operation"].(string)
+	if !ok {
+		return fmt.Errorf("audio_scene-Aktion benötigt 'operation' Parameter")
+	}
+	switch operation {
+	case "save", "delete":
+	case "restore":
+		if _, _, err := fadeTiming(action.Parameters, 0); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("ungültige Operation: %s (erwartet: save, restore, delete)", operation)
+	}
+	return nil
+}
+
+// Close bricht laufende Überblendungen ab und gibt die Verbindung zum Audio-Backend frei
+func (e *AudioSceneExecutor) Close() error {
+	e.fader.stopAll()
+	return closeController(e.audioController)
+}
+
+// GetScenes gibt die Namen der gespeicherten Szenen zurück
+func (e *AudioSceneExecutor) GetScenes() ([]string, error) {
+	return e.scenes.Names()
+}
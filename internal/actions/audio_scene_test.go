@@ -0,0 +1,88 @@
+package actions
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+func TestAudioScene(t *testing.T) {
+	audio := newFakeAudioController()
+	controller := &fakeAppController{
+		AudioController: audio,
+		streams: []AppStream{
+			{ID: "7", Application: "Spotify", Binary: "spotify", Volume: 80},
+			{ID: "9", Application: "Firefox", Binary: "firefox", Volume: 50, IsMuted: true},
+		},
+		gone:  map[string]bool{},
+		sinks: map[string]string{},
+	}
+	file := filepath.Join(t.TempDir(), "scenes.json")
+	e := &AudioSceneExecutor{
+		BaseExecutor:    NewBaseExecutor("audio_scene", utils.NewNullLogger()),
+		audioController: controller,
+		scenes:          NewSceneStore(file),
+		fader:           newFader(),
+	}
+	ctx := context.Background()
+	run := func(params map[string]interface{}) Result {
+		t.Helper()
+		action := config.Action{Type: "audio_scene", Parameters: params}
+		if err := e.Validate(action); err != nil {
+			t.Fatalf("Validate(%v): %v", params, err)
+		}
+		result, err := e.Execute(ctx, action)
+		if err != nil {
+			t.Fatalf("Execute(%v): %v", params, err)
+		}
+		return result
+	}
+
+	result := run(map[string]interface{}{"scene": "music", "operation": "save"})
+	if result.Output != "5 Geräte, 2 Anwendungen" {
+		t.Fatalf("save: %+v", result)
+	}
+
+	// Zustand verändern: anderes Standardgerät, Lautstärken, Stummschaltung, neuer Spotify-Stream
+	audio.SetDefaultAudioSource(ctx, AudioPlayback, "headset")
+	audio.SetAudioSourceVolume(ctx, AudioPlayback, "speakers", 10)
+	audio.MuteAudioSource(ctx, AudioCapture, "mic")
+	controller.streams = []AppStream{
+		{ID: "21", Application: "Spotify", Binary: "spotify", Volume: 20, IsMuted: true},
+		{ID: "9", Application: "Firefox", Binary: "firefox", Volume: 100},
+	}
+
+	// Wiederherstellen aus der Datei, mit Überblendung
+	e.scenes = NewSceneStore(file)
+	result = run(map[string]interface{}{"scene": "music", "operation": "restore", "duration": 40, "easing": "ease_out"})
+	if !result.Changed || result.Output != "5 Geräte, 2 Streams" {
+		t.Fatalf("restore: %+v", result)
+	}
+	if speakers, _ := audio.GetDefaultAudioSource(ctx, AudioPlayback); speakers.ID != "speakers" || speakers.Volume != 60 {
+		t.Fatalf("playback not restored: %+v", speakers)
+	}
+	if mic, _ := audio.find(AudioCapture, "mic"); mic.IsMuted {
+		t.Fatalf("mic still muted")
+	}
+	spotify, firefox := controller.streams[0], controller.streams[1]
+	if spotify.Volume != 80 || spotify.IsMuted || firefox.Volume != 50 || !firefox.IsMuted {
+		t.Fatalf("apps not restored: %+v", controller.streams)
+	}
+
+	action := config.Action{Type: "audio_scene", Parameters: map[string]interface{}{"scene": "meeting", "operation": "restore"}}
+	if _, err := e.Execute(ctx, action); err == nil {
+		t.Fatalf("expected error for unknown scene")
+	}
+	action.Parameters["operation"] = "load"
+	if err := e.Validate(action); err == nil {
+		t.Fatalf("expected error for invalid operation")
+	}
+
+	run(map[string]interface{}{"scene": "music", "operation": "delete"})
+	if names, err := NewSceneStore(file).Names(); err != nil || len(names) != 0 {
+		t.Fatalf("scene not deleted: %v, %v", names, err)
+	}
+}
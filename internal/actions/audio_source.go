@@ -4,10 +4,16 @@
 package actions
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/internal/pulse"
 	"github.com/Xcruser/MidiDaemon/pkg/utils"
 )
 
@@ -15,33 +21,47 @@ import (
 type AudioSourceExecutor struct {
 	BaseExecutor
 	audioController AudioController
+	fader           *fader
 }
 
-// AudioController definiert die Schnittstelle für plattformspezifische Audioquellen-Steuerung
+// AudioDirection unterscheidet Ausgabegeräte (Wiedergabe) von Eingabegeräten (Aufnahme)
+type AudioDirection string
+
+const (
+	AudioPlayback AudioDirection = "playback"
+	AudioCapture  AudioDirection = "capture"
+)
+
+// defaultSourceAlias bezeichnet im "source" Parameter das aktuelle Standardgerät
+const defaultSourceAlias = "default"
+
+// AudioController definiert die Schnittstelle für plattformspezifische Audioquellen-Steuerung.
+// Wiedergabe- und Aufnahmegeräte werden über direction getrennt verwaltet.
 type AudioController interface {
-	GetAudioSources() ([]AudioSource, error)
-	SetDefaultAudioSource(sourceID string) error
-	GetDefaultAudioSource() (AudioSource, error)
-	MuteAudioSource(sourceID string) error
-	UnmuteAudioSource(sourceID string) error
-	SetAudioSourceVolume(sourceID string, volume int) error
+	GetAudioSources(ctx context.Context, direction AudioDirection) ([]AudioSource, error)
+	SetDefaultAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error
+	GetDefaultAudioSource(ctx context.Context, direction AudioDirection) (AudioSource, error)
+	MuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error
+	UnmuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error
+	SetAudioSourceVolume(ctx context.Context, direction AudioDirection, sourceID string, volume int) error
 }
 
 // AudioSource repräsentiert eine Audioquelle
 type AudioSource struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Type        string `json:"type"` // "speakers", "headphones", "hdmi", "bluetooth", etc.
-	IsDefault   bool   `json:"is_default"`
-	IsMuted     bool   `json:"is_muted"`
-	Volume      int    `json:"volume"`
-	IsAvailable bool   `json:"is_available"`
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Type        string         `json:"type"` // "speakers", "headphones", "hdmi", "bluetooth", "microphone", "headset", etc.
+	Direction   AudioDirection `json:"direction"`
+	IsDefault   bool           `json:"is_default"`
+	IsMuted     bool           `json:"is_muted"`
+	Volume      int            `json:"volume"`
+	IsAvailable bool           `json:"is_available"`
 }
 
 // NewAudioSourceExecutor erstellt einen neuen Audio-Source-Executor
-func NewAudioSourceExecutor(logger utils.Logger) (*AudioSourceExecutor, error) {
+func NewAudioSourceExecutor(audio config.AudioConfig, logger utils.Logger) (*AudioSourceExecutor, error) {
 	// Plattformspezifischen Audio-Controller erstellen
-	controller, err := newAudioController()
+	controller, err := newAudioController(audio)
 	if err != nil {
 		return nil, fmt.Errorf("fehler beim Erstellen des Audio-Controllers: %w", err)
 	}
@@ -49,24 +69,25 @@ func NewAudioSourceExecutor(logger utils.Logger) (*AudioSourceExecutor, error) {
 	executor := &AudioSourceExecutor{
 		BaseExecutor:    NewBaseExecutor("audio_source", logger),
 		audioController: controller,
+		fader:           newFader(),
 	}
 
 	return executor, nil
 }
 
 // Execute führt eine Audio-Source-Aktion aus
-func (e *AudioSourceExecutor) Execute(action config.Action) error {
+func (e *AudioSourceExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
 	e.LogDebug("Führe Audio-Source-Aktion aus", "parameters", action.Parameters)
 
 	// Source-Parameter extrahieren
 	source, ok := action.Parameters["source"]
 	if !ok {
-		return fmt.Errorf("audio_source-Aktion benötigt 'source' Parameter")
+		return Result{}, fmt.Errorf("audio_source-Aktion benötigt 'source' Parameter")
 	}
 
 	sourceStr, ok := source.(string)
 	if !ok {
-		return fmt.Errorf("'source' Parameter muss ein String sein")
+		return Result{}, fmt.Errorf("'source' Parameter muss ein String sein")
 	}
 
 	// Aktionstyp bestimmen
@@ -77,19 +98,65 @@ func (e *AudioSourceExecutor) Execute(action config.Action) error {
 		}
 	}
 
+	// Richtung bestimmen (Standard: Wiedergabe)
+	direction, err := audioDirection(action.Parameters)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// "default" steht für das aktuelle Standardgerät der Richtung
+	if sourceStr == defaultSourceAlias && actionType != "cycle" {
+		current, err := e.audioController.GetDefaultAudioSource(ctx, direction)
+		if err != nil {
+			return Result{}, fmt.Errorf("fehler beim Abrufen der aktuellen Audioquelle: %w", err)
+		}
+		sourceStr = current.ID
+	}
+
 	// Audio-Source-Aktion ausführen
 	switch actionType {
 	case "switch":
-		e.LogInfo("Wechsle Audioquelle", "source", sourceStr)
-		return e.audioController.SetDefaultAudioSource(sourceStr)
+		e.LogInfo("Wechsle Audioquelle", "source", sourceStr, "direction", direction)
+		if err := e.audioController.SetDefaultAudioSource(ctx, direction, sourceStr); err != nil {
+			return Result{}, err
+		}
+		return Result{Changed: true, State: sourceStr}, nil
 
 	case "mute":
-		e.LogInfo("Stummschalten Audioquelle", "source", sourceStr)
-		return e.audioController.MuteAudioSource(sourceStr)
+		e.LogInfo("Stummschalten Audioquelle", "source", sourceStr, "direction", direction)
+		if err := e.audioController.MuteAudioSource(ctx, direction, sourceStr); err != nil {
+			return Result{}, err
+		}
+		return Result{Changed: true, State: "muted"}, nil
 
 	case "unmute":
-		e.LogInfo("Stummschaltung aufheben Audioquelle", "source", sourceStr)
-		return e.audioController.UnmuteAudioSource(sourceStr)
+		e.LogInfo("Stummschaltung aufheben Audioquelle", "source", sourceStr, "direction", direction)
+		if err := e.audioController.UnmuteAudioSource(ctx, direction, sourceStr); err != nil {
+			return Result{}, err
+		}
+		return Result{Changed: true, State: "unmuted"}, nil
+
+	case "toggle_mute":
+		sources, err := e.audioController.GetAudioSources(ctx, direction)
+		if err != nil {
+			return Result{}, fmt.Errorf("fehler beim Abrufen der Audioquellen: %w", err)
+		}
+		current, err := findAudioSource(sources, sourceStr)
+		if err != nil {
+			return Result{}, err
+		}
+		if current.IsMuted {
+			e.LogInfo("Stummschaltung aufheben Audioquelle", "source", current.ID, "direction", direction)
+			if err := e.audioController.UnmuteAudioSource(ctx, direction, current.ID); err != nil {
+				return Result{}, err
+			}
+			return Result{Changed: true, State: "unmuted"}, nil
+		}
+		e.LogInfo("Stummschalten Audioquelle", "source", current.ID, "direction", direction)
+		if err := e.audioController.MuteAudioSource(ctx, direction, current.ID); err != nil {
+			return Result{}, err
+		}
+		return Result{Changed: true, State: "muted"}, nil
 
 	case "volume":
 		// Lautstärke setzen
@@ -108,60 +175,221 @@ func (e *AudioSourceExecutor) Execute(action config.Action) error {
 		}
 
 		if volume < 0 || volume > 100 {
-			return fmt.Errorf("volume muss zwischen 0 und 100 liegen, got: %d", volume)
+			return Result{}, fmt.Errorf("volume muss zwischen 0 und 100 liegen, got: %d", volume)
 		}
 
-		e.LogInfo("Setze Audioquelle-Lautstärke", "source", sourceStr, "volume", volume)
-		return e.audioController.SetAudioSourceVolume(sourceStr, volume)
+		e.LogInfo("Setze Audioquelle-Lautstärke", "source", sourceStr, "direction", direction, "volume", volume)
+		e.fader.cancel(fadeTarget(direction, sourceStr))
+		if err := e.audioController.SetAudioSourceVolume(ctx, direction, sourceStr, volume); err != nil {
+			return Result{}, err
+		}
+		return Result{Changed: true, State: strconv.Itoa(volume)}, nil
+
+	case "fade":
+		return e.fadeAudioSource(ctx, direction, sourceStr, action)
 
 	case "cycle":
 		// Durch verfügbare Quellen wechseln
-		e.LogInfo("Wechsle zur nächsten Audioquelle")
-		return e.cycleAudioSource()
+		e.LogInfo("Wechsle zur nächsten Audioquelle", "direction", direction)
+		return e.cycleAudioSource(ctx, direction, action)
+
+	default:
+		return Result{}, fmt.Errorf("ungültiger Aktionstyp: %s (erwartet: switch, mute, unmute, toggle_mute, volume, fade, cycle)", actionType)
+	}
+}
 
+// audioDirection liest den "direction" Parameter ("playback" oder "capture")
+func audioDirection(params map[string]interface{}) (AudioDirection, error) {
+	value, ok := params["direction"]
+	if !ok {
+		return AudioPlayback, nil
+	}
+	switch direction, _ := value.(string); AudioDirection(direction) {
+	case AudioPlayback, AudioCapture:
+		return AudioDirection(direction), nil
 	default:
-		return fmt.Errorf("ungültiger Aktionstyp: %s (erwartet: switch, mute, unmute, volume, cycle)", actionType)
+		return "", fmt.Errorf("ungültige direction: %v (erwartet: playback, capture)", value)
 	}
 }
 
-// cycleAudioSource wechselt zur nächsten verfügbaren Audioquelle
-func (e *AudioSourceExecutor) cycleAudioSource() error {
-	sources, err := e.audioController.GetAudioSources()
+// fadeTarget gibt den Namen einer Audioquelle für Überblendungen zurück
+func fadeTarget(direction AudioDirection, sourceID string) string {
+	return string(direction) + ":" + sourceID
+}
+
+// fadeAudioSource blendet die Lautstärke einer Audioquelle zur Ziel-Lautstärke über
+func (e *AudioSourceExecutor) fadeAudioSource(ctx context.Context, direction AudioDirection, sourceID string, action config.Action) (Result, error) {
+	volume, duration, easing, err := fadeParameters(action.Parameters)
 	if err != nil {
-		return fmt.Errorf("fehler beim Abrufen der Audioquellen: %w", err)
+		return Result{}, err
+	}
+	from, err := intParameter(action, "from", -1)
+	if err != nil {
+		return Result{}, err
+	}
+	if from < 0 {
+		sources, err := e.audioController.GetAudioSources(ctx, direction)
+		if err != nil {
+			return Result{}, fmt.Errorf("fehler beim Abrufen der Audioquellen: %w", err)
+		}
+		source, err := findAudioSource(sources, sourceID)
+		if err != nil {
+			return Result{}, err
+		}
+		from = source.Volume
 	}
 
-	if len(sources) == 0 {
-		return fmt.Errorf("keine Audioquellen verfügbar")
+	e.LogInfo("Blende Audioquelle über", "source", sourceID, "from", from, "to", volume, "duration", duration)
+	err = e.fader.fade(ctx, fadeTarget(direction, sourceID), from, volume, duration, easing, func(ctx context.Context, volume int) error {
+		return e.audioController.SetAudioSourceVolume(ctx, direction, sourceID, volume)
+	})
+	if errors.Is(err, errFadeCanceled) {
+		e.LogDebug("Überblendung abgebrochen", "source", sourceID, "to", volume)
+		return Result{Changed: true, Output: "abgebrochen"}, nil
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Changed: from != volume, State: strconv.Itoa(volume)}, nil
+}
+
+// cycleAudioSource wechselt zur nächsten Audioquelle derselben Richtung. Die Kandidaten
+// lassen sich über "order", "include", "exclude" und "skip_unavailable" einschränken.
+func (e *AudioSourceExecutor) cycleAudioSource(ctx context.Context, direction AudioDirection, action config.Action) (Result, error) {
+	sources, err := e.audioController.GetAudioSources(ctx, direction)
+	if err != nil {
+		return Result{}, fmt.Errorf("fehler beim Abrufen der Audioquellen: %w", err)
+	}
+
+	candidates, err := cycleCandidates(sources, action)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(candidates) == 0 {
+		return Result{}, fmt.Errorf("keine Audioquellen zum Wechseln verfügbar")
 	}
 
 	// Aktuelle Standardquelle finden
-	currentSource, err := e.audioController.GetDefaultAudioSource()
+	currentSource, err := e.audioController.GetDefaultAudioSource(ctx, direction)
 	if err != nil {
-		return fmt.Errorf("fehler beim Abrufen der aktuellen Audioquelle: %w", err)
+		return Result{}, fmt.Errorf("fehler beim Abrufen der aktuellen Audioquelle: %w", err)
 	}
 
-	// Nächste verfügbare Quelle finden
-	var nextSource *AudioSource
-	for i, source := range sources {
+	// Nächste Quelle nach der aktuellen; ist die aktuelle kein Kandidat, beginnt der Zyklus vorne
+	nextSource := candidates[0]
+	for i, source := range candidates {
 		if source.ID == currentSource.ID {
-			// Nächste Quelle in der Liste
-			nextIndex := (i + 1) % len(sources)
-			nextSource = &sources[nextIndex]
+			nextSource = candidates[(i+1)%len(candidates)]
 			break
 		}
 	}
 
-	if nextSource == nil {
-		// Fallback: erste verfügbare Quelle
-		nextSource = &sources[0]
+	e.LogInfo("Wechsle zu Audioquelle", "from", currentSource.Name, "to", nextSource.Name)
+	if err := e.audioController.SetDefaultAudioSource(ctx, direction, nextSource.ID); err != nil {
+		return Result{}, err
 	}
+	result := Result{Changed: nextSource.ID != currentSource.ID, State: nextSource.ID}
 
-	e.LogInfo("Wechsle zu Audioquelle", "from", currentSource.Name, "to", nextSource.Name)
-	return e.audioController.SetDefaultAudioSource(nextSource.ID)
+	if move, _ := action.Parameters["move_streams"].(bool); move && direction == AudioPlayback {
+		moved, err := e.moveStreams(ctx, nextSource.ID)
+		if err != nil {
+			return result, err
+		}
+		result.Output = fmt.Sprintf("%d Streams verschoben", moved)
+	}
+	return result, nil
 }
 
-// Validate überprüft eine Audio-Source-Aktion auf Gültigkeit
+// cycleCandidates wählt die Quellen für "cycle" in der gewünschten Reihenfolge aus
+func cycleCandidates(sources []AudioSource, action config.Action) ([]AudioSource, error) {
+	order, err := stringListParameter(action, "order")
+	if err != nil {
+		return nil, err
+	}
+	include, err := stringListParameter(action, "include")
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := stringListParameter(action, "exclude")
+	if err != nil {
+		return nil, err
+	}
+	skipUnavailable, _ := action.Parameters["skip_unavailable"].(bool)
+
+	// Eine explizite Reihenfolge ersetzt die des Backends; fehlende Geräte (z.B. nicht eingesteckt) entfallen
+	ordered := sources
+	if len(order) > 0 {
+		ordered = nil
+		seen := make(map[string]bool)
+		for _, sourceID := range order {
+			source, err := findAudioSource(sources, sourceID)
+			if err != nil || seen[source.ID] {
+				continue
+			}
+			seen[source.ID] = true
+			ordered = append(ordered, source)
+		}
+	}
+
+	var candidates []AudioSource
+	for _, source := range ordered {
+		if skipUnavailable && !source.IsAvailable {
+			continue
+		}
+		if len(include) > 0 && !matchesAnySource(include, source) {
+			continue
+		}
+		if matchesAnySource(exclude, source) {
+			continue
+		}
+		candidates = append(candidates, source)
+	}
+	return candidates, nil
+}
+
+// matchesAnySource prüft, ob ein Muster auf ID oder Namen einer Quelle passt
+func matchesAnySource(patterns []string, source AudioSource) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, source.ID) || matchPattern(pattern, source.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// moveStreams verschiebt alle laufenden Anwendungs-Streams auf den neuen Standard-Sink
+func (e *AudioSourceExecutor) moveStreams(ctx context.Context, sinkID string) (int, error) {
+	controller, ok := e.audioController.(AppAudioController)
+	if !ok {
+		return 0, fmt.Errorf("das Audio-Backend unterstützt kein Verschieben von Streams")
+	}
+	streams, err := controller.GetAppStreams(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("fehler beim Abrufen der Streams: %w", err)
+	}
+
+	moved := 0
+	for _, stream := range streams {
+		if err := controller.MoveAppStream(ctx, stream.ID, sinkID); err != nil {
+			// Einzelne Streams (z.B. inzwischen beendet oder an ein Gerät gebunden) halten den Wechsel nicht auf
+			if !errors.Is(err, errStreamGone) {
+				e.LogWarn("Stream konnte nicht verschoben werden", "stream", stream.ID, "app", stream.Application, "error", err)
+			}
+			continue
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// Close bricht laufende Überblendungen ab und gibt die Verbindung zum Audio-Backend frei
+func (e *AudioSourceExecutor) Close() error {
+	e.fader.stopAll()
+	return closeController(e.audioController)
+}
+
+// Validate überprüft eine Audio-Source-Aktion auf Gültigkeit. Ob das Gerät vorhanden ist, zeigt sich
+// erst bei der Ausführung, damit ein abgestecktes Headset weder Start noch Neuladen verhindert.
 func (e *AudioSourceExecutor) Validate(action config.Action) error {
 	// Source-Parameter überprüfen
 	source, ok := action.Parameters["source"]
@@ -178,27 +406,38 @@ func (e *AudioSourceExecutor) Validate(action config.Action) error {
 		return fmt.Errorf("'source' Parameter darf nicht leer sein")
 	}
 
+	direction, err := audioDirection(action.Parameters)
+	if err != nil {
+		return err
+	}
+
 	// Type-Parameter überprüfen (falls vorhanden)
 	if typeParam, ok := action.Parameters["type"]; ok {
 		if typeStr, ok := typeParam.(string); ok {
 			validTypes := map[string]bool{
-				"switch": true,
-				"mute":   true,
-				"unmute": true,
-				"volume": true,
-				"cycle":  true,
+				"switch":      true,
+				"mute":        true,
+				"unmute":      true,
+				"toggle_mute": true,
+				"volume":      true,
+				"fade":        true,
+				"cycle":       true,
 			}
 			if !validTypes[typeStr] {
 				return fmt.Errorf("ungültiger Typ: %s", typeStr)
 			}
 
+			if typeStr == "fade" {
+				if _, _, _, err := fadeParameters(action.WithValue(0).Parameters); err != nil {
+					return err
+				}
+			}
+
 			// Bei "cycle" Typ ist source-Parameter optional
 			if typeStr == "cycle" {
 				// Source-Parameter wird ignoriert
-			} else {
-				// Source-Validierung (falls möglich)
-				if err := e.validateSource(sourceStr); err != nil {
-					return fmt.Errorf("ungültige Audioquelle: %w", err)
+				if err := validateCycle(direction, action); err != nil {
+					return err
 				}
 			}
 		} else {
@@ -232,42 +471,52 @@ func (e *AudioSourceExecutor) Validate(action config.Action) error {
 	return nil
 }
 
-// validateSource überprüft eine Audioquelle auf Gültigkeit
-func (e *AudioSourceExecutor) validateSource(sourceID string) error {
-	// Verfügbare Quellen abrufen
-	sources, err := e.audioController.GetAudioSources()
-	if err != nil {
-		// Bei Fehlern trotzdem erlauben (könnte ein neues Gerät sein)
-		return nil
+// validateCycle überprüft die Optionen des "cycle" Typs
+func validateCycle(direction AudioDirection, action config.Action) error {
+	for _, name := range []string{"order", "include", "exclude"} {
+		patterns, err := stringListParameter(action, name)
+		if err != nil {
+			return err
+		}
+		if name == "order" {
+			continue
+		}
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("ungültiges Muster in '%s': %s", name, pattern)
+			}
+		}
 	}
-
-	// Überprüfen ob die Quelle existiert
-	for _, source := range sources {
-		if source.ID == sourceID || source.Name == sourceID {
-			return nil
+	for _, name := range []string{"skip_unavailable", "move_streams"} {
+		if value, ok := action.Parameters[name]; ok {
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("'%s' Parameter muss ein Boolean sein", name)
+			}
 		}
 	}
-
-	return fmt.Errorf("audioquelle '%s' nicht gefunden", sourceID)
+	if move, _ := action.Parameters["move_streams"].(bool); move && direction == AudioCapture {
+		return fmt.Errorf("'move_streams' ist nur für Wiedergabegeräte möglich")
+	}
+	return nil
 }
 
-// GetAvailableSources gibt alle verfügbaren Audioquellen zurück
-func (e *AudioSourceExecutor) GetAvailableSources() ([]AudioSource, error) {
-	return e.audioController.GetAudioSources()
+// GetAvailableSources gibt alle verfügbaren Audioquellen einer Richtung zurück
+func (e *AudioSourceExecutor) GetAvailableSources(direction AudioDirection) ([]AudioSource, error) {
+	return e.audioController.GetAudioSources(context.Background(), direction)
 }
 
-// GetCurrentSource gibt die aktuelle Standard-Audioquelle zurück
-func (e *AudioSourceExecutor) GetCurrentSource() (AudioSource, error) {
-	return e.audioController.GetDefaultAudioSource()
+// GetCurrentSource gibt die aktuelle Standard-Audioquelle einer Richtung zurück
+func (e *AudioSourceExecutor) GetCurrentSource(direction AudioDirection) (AudioSource, error) {
+	return e.audioController.GetDefaultAudioSource(context.Background(), direction)
 }
 
 // newAudioController erstellt einen plattformspezifischen Audio-Controller
-func newAudioController() (AudioController, error) {
+func newAudioController(audio config.AudioConfig) (AudioController, error) {
 	switch runtime.GOOS {
 	case "windows":
 		return newWindowsAudioController()
 	case "linux":
-		return newLinuxAudioController()
+		return newLinuxAudioController(audio, execRunner{})
 	default:
 		return nil, fmt.Errorf("plattform %s wird nicht unterstützt", runtime.GOOS)
 	}
@@ -280,98 +529,314 @@ func newWindowsAudioController() (AudioController, error) {
 	return &windowsAudioController{}, nil
 }
 
-func (c *windowsAudioController) GetAudioSources() ([]AudioSource, error) {
+func (c *windowsAudioController) GetAudioSources(ctx context.Context, direction AudioDirection) ([]AudioSource, error) {
 	// TODO: Implementierung mit Windows Core Audio API
-	// - IMMDeviceEnumerator verwenden
+	// - IMMDeviceEnumerator verwenden (eRender bzw. eCapture)
 	// - Alle verfügbaren Audio-Endpunkte auflisten
+	if direction == AudioCapture {
+		return []AudioSource{
+			{ID: "microphone", Name: "Mikrofon", Type: "microphone", Direction: AudioCapture, IsDefault: true, IsMuted: false, Volume: 50, IsAvailable: true},
+			{ID: "headset", Name: "Headset-Mikrofon", Type: "headset", Direction: AudioCapture, IsDefault: false, IsMuted: false, Volume: 50, IsAvailable: true},
+		}, nil
+	}
 	return []AudioSource{
-		{ID: "speakers", Name: "Lautsprecher", Type: "speakers", IsDefault: true, IsMuted: false, Volume: 50, IsAvailable: true},
-		{ID: "headphones", Name: "Kopfhörer", Type: "headphones", IsDefault: false, IsMuted: false, Volume: 50, IsAvailable: true},
-		{ID: "hdmi", Name: "HDMI Audio", Type: "hdmi", IsDefault: false, IsMuted: false, Volume: 50, IsAvailable: true},
+		{ID: "speakers", Name: "Lautsprecher", Type: "speakers", Direction: AudioPlayback, IsDefault: true, IsMuted: false, Volume: 50, IsAvailable: true},
+		{ID: "headphones", Name: "Kopfhörer", Type: "headphones", Direction: AudioPlayback, IsDefault: false, IsMuted: false, Volume: 50, IsAvailable: true},
+		{ID: "hdmi", Name: "HDMI Audio", Type: "hdmi", Direction: AudioPlayback, IsDefault: false, IsMuted: false, Volume: 50, IsAvailable: true},
 	}, nil
 }
 
-func (c *windowsAudioController) SetDefaultAudioSource(sourceID string) error {
+func (c *windowsAudioController) SetDefaultAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
 	// TODO: Implementierung mit Windows Core Audio API
 	// - IMMDeviceEnumerator.SetDefaultEndpoint aufrufen
 	return nil
 }
 
-func (c *windowsAudioController) GetDefaultAudioSource() (AudioSource, error) {
+func (c *windowsAudioController) GetDefaultAudioSource(ctx context.Context, direction AudioDirection) (AudioSource, error) {
 	// TODO: Implementierung mit Windows Core Audio API
 	// - IMMDeviceEnumerator.GetDefaultAudioEndpoint aufrufen
-	return AudioSource{ID: "speakers", Name: "Lautsprecher", Type: "speakers", IsDefault: true, IsMuted: false, Volume: 50, IsAvailable: true}, nil
+	sources, err := c.GetAudioSources(ctx, direction)
+	if err != nil {
+		return AudioSource{}, err
+	}
+	return sources[0], nil
 }
 
-func (c *windowsAudioController) MuteAudioSource(sourceID string) error {
+func (c *windowsAudioController) MuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
 	// TODO: Implementierung mit Windows Core Audio API
 	// - IAudioEndpointVolume.SetMute aufrufen
 	return nil
 }
 
-func (c *windowsAudioController) UnmuteAudioSource(sourceID string) error {
+func (c *windowsAudioController) UnmuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
 	// TODO: Implementierung mit Windows Core Audio API
 	// - IAudioEndpointVolume.SetMute aufrufen
 	return nil
 }
 
-func (c *windowsAudioController) SetAudioSourceVolume(sourceID string, volume int) error {
+func (c *windowsAudioController) SetAudioSourceVolume(ctx context.Context, direction AudioDirection, sourceID string, volume int) error {
 	// TODO: Implementierung mit Windows Core Audio API
 	// - IAudioEndpointVolume.SetMasterVolumeLevelScalar aufrufen
 	return nil
 }
 
-// Linux-spezifische Audio-Controller-Implementierung
-type linuxAudioController struct{}
+// Linux-spezifische Audio-Controller-Implementierung über das native PulseAudio-Protokoll.
+// Audioquellen sind die Sinks des Servers; ID ist der Sink-Name, Name die Beschreibung.
+type linuxAudioController struct {
+	conn *pulseConnection
+}
 
-func newLinuxAudioController() (AudioController, error) {
-	return &linuxAudioController{}, nil
+// Close trennt die Verbindung zum Sound-Server
+func (c *linuxAudioController) Close() error {
+	return c.conn.close()
 }
 
-func (c *linuxAudioController) GetAudioSources() ([]AudioSource, error) {
-	// TODO: Implementierung mit PulseAudio oder ALSA
-	// - pactl list sinks verwenden (PulseAudio)
-	// - oder amixer -c 0 scontrols verwenden (ALSA)
-	return []AudioSource{
-		{ID: "analog-stereo", Name: "Analoger Stereo-Ausgang", Type: "speakers", IsDefault: true, IsMuted: false, Volume: 50, IsAvailable: true},
-		{ID: "hdmi-stereo", Name: "HDMI Stereo", Type: "hdmi", IsDefault: false, IsMuted: false, Volume: 50, IsAvailable: true},
-		{ID: "bluetooth", Name: "Bluetooth Audio", Type: "bluetooth", IsDefault: false, IsMuted: false, Volume: 50, IsAvailable: true},
-	}, nil
+// newLinuxAudioController wählt das konfigurierte bzw. ein verfügbares Linux-Backend
+func newLinuxAudioController(cfg config.AudioConfig, runner CommandRunner) (AudioController, error) {
+	conn := newPulseConnection()
+	switch backend := resolveAudioBackend(cfg.Backend, runner, conn.socketPath); backend {
+	case config.AudioBackendWpctl:
+		return newWpctlController(runner), nil
+	case config.AudioBackendPactl:
+		return newPactlController(runner), nil
+	case config.AudioBackendPulse, config.AudioBackendAmixer:
+		// amixer kennt keine Ausgabegeräte, Audioquellen laufen dann über das native Protokoll
+		return &linuxAudioController{conn: conn}, nil
+	default:
+		return nil, fmt.Errorf("unbekanntes Audio-Backend: %s", backend)
+	}
 }
 
-func (c *linuxAudioController) SetDefaultAudioSource(sourceID string) error {
-	// TODO: Implementierung mit PulseAudio oder ALSA
-	// - pactl set-default-sink verwenden (PulseAudio)
-	// - oder amixer -c 0 sset verwenden (ALSA)
-	return nil
+func (c *linuxAudioController) GetAudioSources(ctx context.Context, direction AudioDirection) ([]AudioSource, error) {
+	client, err := c.conn.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info, err := client.ServerInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := pulseDevices(ctx, client, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultName := info.DefaultSink
+	if direction == AudioCapture {
+		defaultName = info.DefaultSource
+	}
+	sources := make([]AudioSource, 0, len(devices))
+	for _, device := range devices {
+		sources = append(sources, pulseAudioSource(device, direction, defaultName))
+	}
+	return sources, nil
 }
 
-func (c *linuxAudioController) GetDefaultAudioSource() (AudioSource, error) {
-	// TODO: Implementierung mit PulseAudio oder ALSA
-	// - pactl info verwenden (PulseAudio)
-	// - oder amixer -c 0 sget verwenden (ALSA)
-	return AudioSource{ID: "analog-stereo", Name: "Analoger Stereo-Ausgang", Type: "speakers", IsDefault: true, IsMuted: false, Volume: 50, IsAvailable: true}, nil
+func (c *linuxAudioController) SetDefaultAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	client, device, err := c.device(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	if direction == AudioCapture {
+		return client.SetDefaultSource(ctx, device.Name)
+	}
+	return client.SetDefaultSink(ctx, device.Name)
 }
 
-func (c *linuxAudioController) MuteAudioSource(sourceID string) error {
-	// TODO: Implementierung mit PulseAudio oder ALSA
-	// - pactl set-sink-mute verwenden (PulseAudio)
-	// - oder amixer -c 0 sset verwenden (ALSA)
-	return nil
+func (c *linuxAudioController) GetDefaultAudioSource(ctx context.Context, direction AudioDirection) (AudioSource, error) {
+	client, err := c.conn.get(ctx)
+	if err != nil {
+		return AudioSource{}, err
+	}
+	var device *pulse.Device
+	if direction == AudioCapture {
+		device, err = client.Source(ctx, "")
+	} else {
+		device, err = client.Sink(ctx, "")
+	}
+	if err != nil {
+		return AudioSource{}, err
+	}
+	return pulseAudioSource(device, direction, device.Name), nil
 }
 
-func (c *linuxAudioController) UnmuteAudioSource(sourceID string) error {
-	// TODO: Implementierung mit PulseAudio oder ALSA
-	// - pactl set-sink-mute verwenden (PulseAudio)
-	// - oder amixer -c 0 sset verwenden (ALSA)
-	return nil
+func (c *linuxAudioController) MuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	return c.setMute(ctx, direction, sourceID, true)
 }
 
-func (c *linuxAudioController) SetAudioSourceVolume(sourceID string, volume int) error {
-	// TODO: Implementierung mit PulseAudio oder ALSA
-	// - pactl set-sink-volume verwenden (PulseAudio)
-	// - oder amixer -c 0 sset verwenden (ALSA)
-	return nil
+func (c *linuxAudioController) UnmuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	return c.setMute(ctx, direction, sourceID, false)
+}
+
+func (c *linuxAudioController) SetAudioSourceVolume(ctx context.Context, direction AudioDirection, sourceID string, volume int) error {
+	client, device, err := c.device(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	if direction == AudioCapture {
+		return client.SetSourceVolume(ctx, device.Name, pulse.ScaleVolume(device.Volume, volume))
+	}
+	return client.SetSinkVolume(ctx, device.Name, pulse.ScaleVolume(device.Volume, volume))
+}
+
+// setMute schaltet einen Sink bzw. eine Source stumm oder wieder laut
+func (c *linuxAudioController) setMute(ctx context.Context, direction AudioDirection, sourceID string, muted bool) error {
+	client, device, err := c.device(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	if direction == AudioCapture {
+		return client.SetSourceMute(ctx, device.Name, muted)
+	}
+	return client.SetSinkMute(ctx, device.Name, muted)
+}
+
+// GetAppStreams listet die Wiedergabe-Streams aller Anwendungen auf
+func (c *linuxAudioController) GetAppStreams(ctx context.Context) ([]AppStream, error) {
+	client, err := c.conn.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := client.SinkInputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	streams := make([]AppStream, 0, len(inputs))
+	for _, input := range inputs {
+		// Streams ohne Lautstärke (z.B. Passthrough) lassen sich nicht steuern
+		if !input.HasVolume {
+			continue
+		}
+		streams = append(streams, AppStream{
+			ID:          strconv.FormatUint(uint64(input.Index), 10),
+			Application: input.Application(),
+			Binary:      input.Binary(),
+			PID:         input.PID(),
+			Name:        input.Name,
+			Volume:      input.Percent(),
+			IsMuted:     input.Muted,
+		})
+	}
+	return streams, nil
+}
+
+// SetAppStreamVolume setzt die Lautstärke eines Streams, die Balance bleibt erhalten
+func (c *linuxAudioController) SetAppStreamVolume(ctx context.Context, streamID string, volume int) error {
+	client, input, err := c.sinkInput(ctx, streamID)
+	if err != nil {
+		return err
+	}
+	return pulseStreamError(client.SetSinkInputVolume(ctx, input.Index, pulse.ScaleVolume(input.Volume, volume)))
+}
+
+// SetAppStreamMute schaltet einen Stream stumm oder wieder laut
+func (c *linuxAudioController) SetAppStreamMute(ctx context.Context, streamID string, muted bool) error {
+	client, input, err := c.sinkInput(ctx, streamID)
+	if err != nil {
+		return err
+	}
+	return pulseStreamError(client.SetSinkInputMute(ctx, input.Index, muted))
+}
+
+// MoveAppStream verschiebt einen Stream auf einen anderen Sink
+func (c *linuxAudioController) MoveAppStream(ctx context.Context, streamID, sinkID string) error {
+	client, input, err := c.sinkInput(ctx, streamID)
+	if err != nil {
+		return err
+	}
+	return pulseStreamError(client.MoveSinkInput(ctx, input.Index, sinkID))
+}
+
+// sinkInput sucht einen Stream über seinen Index
+func (c *linuxAudioController) sinkInput(ctx context.Context, streamID string) (*pulse.Client, *pulse.SinkInput, error) {
+	index, err := strconv.ParseUint(streamID, 10, 32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ungültige Stream-ID: %s", streamID)
+	}
+	client, err := c.conn.get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs, err := client.SinkInputs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, input := range inputs {
+		if input.Index == uint32(index) {
+			return client, input, nil
+		}
+	}
+	return nil, nil, errStreamGone
+}
+
+// pulseStreamError erkennt Streams, die inzwischen beendet wurden
+func pulseStreamError(err error) error {
+	if pulse.IsNotFound(err) {
+		return fmt.Errorf("%w: %v", errStreamGone, err)
+	}
+	return err
+}
+
+// device sucht einen Sink bzw. eine Source über den Namen oder die Beschreibung
+func (c *linuxAudioController) device(ctx context.Context, direction AudioDirection, sourceID string) (*pulse.Client, *pulse.Device, error) {
+	client, err := c.conn.get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	devices, err := pulseDevices(ctx, client, direction)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, device := range devices {
+		if device.Name == sourceID {
+			return client, device, nil
+		}
+	}
+	for _, device := range devices {
+		if strings.EqualFold(device.Description, sourceID) {
+			return client, device, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("audioquelle '%s' nicht gefunden", sourceID)
+}
+
+// pulseDevices listet die Sinks (Wiedergabe) bzw. Sources ohne Monitor-Sources (Aufnahme) auf
+func pulseDevices(ctx context.Context, client *pulse.Client, direction AudioDirection) ([]*pulse.Device, error) {
+	if direction != AudioCapture {
+		return client.Sinks(ctx)
+	}
+	sources, err := client.Sources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inputs := sources[:0]
+	for _, source := range sources {
+		if !source.IsMonitor() {
+			inputs = append(inputs, source)
+		}
+	}
+	return inputs, nil
+}
+
+// pulseAudioSource wandelt einen Sink bzw. eine Source in eine Audioquelle um
+func pulseAudioSource(device *pulse.Device, direction AudioDirection, defaultName string) AudioSource {
+	available := true
+	for _, port := range device.Ports {
+		if port.Name == device.ActivePort && port.Available == pulse.PortAvailableNo {
+			available = false
+		}
+	}
+	return AudioSource{
+		ID:          device.Name,
+		Name:        device.Description,
+		Type:        guessDeviceType(direction, device.Name, device.ActivePort, device.Properties["device.bus"], device.Properties["device.form_factor"]),
+		Direction:   direction,
+		IsDefault:   device.Name == defaultName,
+		IsMuted:     device.Muted,
+		Volume:      device.Percent(),
+		IsAvailable: available,
+	}
 }
 
 // parseInt ist eine Hilfsfunktion zum Parsen von Strings zu Integers
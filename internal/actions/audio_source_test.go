@@ -0,0 +1,221 @@
+package actions
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// fakeAudioController hält Geräte beider Richtungen im Speicher
+type fakeAudioController struct {
+	mutex   sync.Mutex
+	devices map[AudioDirection][]AudioSource
+}
+
+func newFakeAudioController() *fakeAudioController {
+	return &fakeAudioController{devices: map[AudioDirection][]AudioSource{
+		AudioPlayback: {
+			{ID: "speakers", Name: "Lautsprecher", Type: "speakers", Direction: AudioPlayback, IsDefault: true, Volume: 60, IsAvailable: true},
+			{ID: "hdmi", Name: "HDMI", Type: "hdmi", Direction: AudioPlayback, Volume: 100, IsAvailable: false},
+			{ID: "headset", Name: "Headset", Type: "headphones", Direction: AudioPlayback, Volume: 40, IsAvailable: true},
+		},
+		AudioCapture: {
+			{ID: "mic", Name: "Mikrofon", Type: "microphone", Direction: AudioCapture, IsDefault: true, Volume: 70, IsAvailable: true},
+			{ID: "headset-mic", Name: "Headset-Mikrofon", Type: "headset", Direction: AudioCapture, Volume: 80, IsAvailable: true},
+		},
+	}}
+}
+
+func (c *fakeAudioController) GetAudioSources(ctx context.Context, direction AudioDirection) ([]AudioSource, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]AudioSource(nil), c.devices[direction]...), nil
+}
+
+func (c *fakeAudioController) SetDefaultAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	if _, err := c.find(direction, sourceID); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i := range c.devices[direction] {
+		c.devices[direction][i].IsDefault = c.devices[direction][i].ID == sourceID
+	}
+	return nil
+}
+
+func (c *fakeAudioController) GetDefaultAudioSource(ctx context.Context, direction AudioDirection) (AudioSource, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, source := range c.devices[direction] {
+		if source.IsDefault {
+			return source, nil
+		}
+	}
+	return AudioSource{}, errNoAudioDevices
+}
+
+func (c *fakeAudioController) MuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	return c.update(direction, sourceID, func(s *AudioSource) { s.IsMuted = true })
+}
+
+func (c *fakeAudioController) UnmuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	return c.update(direction, sourceID, func(s *AudioSource) { s.IsMuted = false })
+}
+
+func (c *fakeAudioController) SetAudioSourceVolume(ctx context.Context, direction AudioDirection, sourceID string, volume int) error {
+	return c.update(direction, sourceID, func(s *AudioSource) { s.Volume = volume })
+}
+
+func (c *fakeAudioController) find(direction AudioDirection, sourceID string) (AudioSource, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return findAudioSource(c.devices[direction], sourceID)
+}
+
+func (c *fakeAudioController) update(direction AudioDirection, sourceID string, apply func(s *AudioSource)) error {
+	source, err := c.find(direction, sourceID)
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i := range c.devices[direction] {
+		if c.devices[direction][i].ID == source.ID {
+			apply(&c.devices[direction][i])
+		}
+	}
+	return nil
+}
+
+func newTestAudioSourceExecutor(controller AudioController) *AudioSourceExecutor {
+	return &AudioSourceExecutor{
+		BaseExecutor:    NewBaseExecutor("audio_source", utils.NewNullLogger()),
+		audioController: controller,
+		fader:           newFader(),
+	}
+}
+
+func runAudioSource(t *testing.T, e *AudioSourceExecutor, params map[string]interface{}) Result {
+	t.Helper()
+	action := config.Action{Type: "audio_source", Parameters: params}
+	if err := e.Validate(action); err != nil {
+		t.Fatalf("Validate(%v): %v", params, err)
+	}
+	result, err := e.Execute(context.Background(), action)
+	if err != nil {
+		t.Fatalf("Execute(%v): %v", params, err)
+	}
+	return result
+}
+
+func TestAudioSourceCapture(t *testing.T) {
+	controller := newFakeAudioController()
+	e := newTestAudioSourceExecutor(controller)
+	ctx := context.Background()
+
+	// Mikrofon-Stummschaltung über das aktuelle Standard-Eingabegerät
+	result := runAudioSource(t, e, map[string]interface{}{"source": "default", "direction": "capture", "type": "toggle_mute"})
+	if mic, _ := controller.GetDefaultAudioSource(ctx, AudioCapture); result.State != "muted" || !mic.IsMuted {
+		t.Fatalf("toggle_mute: %+v, %+v", result, mic)
+	}
+	if speakers, _ := controller.GetDefaultAudioSource(ctx, AudioPlayback); speakers.IsMuted {
+		t.Fatalf("playback device muted by capture action")
+	}
+
+	// Eingangspegel setzen
+	runAudioSource(t, e, map[string]interface{}{"source": "Headset-Mikrofon", "direction": "capture", "type": "volume", "volume": 35})
+	if source, _ := controller.find(AudioCapture, "headset-mic"); source.Volume != 35 {
+		t.Fatalf("capture volume = %d", source.Volume)
+	}
+
+	// cycle bleibt innerhalb der Richtung
+	result = runAudioSource(t, e, map[string]interface{}{"source": "default", "direction": "capture", "type": "cycle"})
+	if result.State != "headset-mic" {
+		t.Fatalf("capture cycle = %+v", result)
+	}
+	result = runAudioSource(t, e, map[string]interface{}{"source": "default", "direction": "capture", "type": "cycle"})
+	if result.State != "mic" {
+		t.Fatalf("capture cycle wrap = %+v", result)
+	}
+	if speakers, _ := controller.GetDefaultAudioSource(ctx, AudioPlayback); speakers.ID != "speakers" {
+		t.Fatalf("capture cycle changed playback default: %+v", speakers)
+	}
+
+	// Ein Ausgabegerät ist kein gültiges Eingabegerät; das zeigt erst die Ausführung, die Validierung
+	// fragt das Backend nicht ab
+	action := config.Action{Type: "audio_source", Parameters: map[string]interface{}{"source": "hdmi", "direction": "capture", "type": "switch"}}
+	if err := e.Validate(action); err != nil {
+		t.Fatalf("Validate must not look up devices: %v", err)
+	}
+	if _, err := e.Execute(ctx, action); err == nil {
+		t.Fatalf("expected error for playback device in capture direction")
+	}
+	action.Parameters["direction"] = "input"
+	if err := e.Validate(action); err == nil {
+		t.Fatalf("expected error for invalid direction")
+	}
+}
+
+func TestAudioSourceCycleOptions(t *testing.T) {
+	controller := newFakeAudioController()
+	e := newTestAudioSourceExecutor(controller)
+	cycle := func(params map[string]interface{}) Result {
+		t.Helper()
+		params["source"] = "default"
+		params["type"] = "cycle"
+		return runAudioSource(t, e, params)
+	}
+
+	// Nicht verfügbare Geräte werden übersprungen
+	if result := cycle(map[string]interface{}{"skip_unavailable": true}); result.State != "headset" {
+		t.Fatalf("skip_unavailable: %+v", result)
+	}
+
+	// Explizite Reihenfolge; unbekannte Einträge (nicht eingesteckt) entfallen
+	order := []interface{}{"Headset", "bluetooth", "speakers"}
+	if result := cycle(map[string]interface{}{"order": order}); result.State != "speakers" {
+		t.Fatalf("order: %+v", result)
+	}
+	if result := cycle(map[string]interface{}{"order": order}); result.State != "headset" {
+		t.Fatalf("order wrap: %+v", result)
+	}
+
+	// Filter über ID oder Namen; ist die aktuelle Quelle ausgeschlossen, beginnt der Zyklus vorne
+	if result := cycle(map[string]interface{}{"exclude": "head*"}); result.State != "speakers" {
+		t.Fatalf("exclude: %+v", result)
+	}
+	if result := cycle(map[string]interface{}{"include": "hdmi,lautsprecher"}); result.State != "hdmi" {
+		t.Fatalf("include: %+v", result)
+	}
+
+	action := config.Action{Type: "audio_source", Parameters: map[string]interface{}{"source": "default", "type": "cycle", "include": "speakers", "exclude": "speakers"}}
+	if _, err := e.Execute(context.Background(), action); err == nil {
+		t.Fatalf("expected error without candidates")
+	}
+	action.Parameters = map[string]interface{}{"source": "default", "type": "cycle", "direction": "capture", "move_streams": true}
+	if err := e.Validate(action); err == nil {
+		t.Fatalf("expected error for move_streams on capture")
+	}
+}
+
+func TestAudioSourceCycleMoveStreams(t *testing.T) {
+	controller := &fakeAppController{
+		AudioController: newFakeAudioController(),
+		streams: []AppStream{
+			{ID: "7", Application: "Spotify"},
+			{ID: "9", Application: "Firefox"},
+		},
+		gone:  map[string]bool{"9": true},
+		sinks: map[string]string{},
+	}
+	e := newTestAudioSourceExecutor(controller)
+
+	result := runAudioSource(t, e, map[string]interface{}{"source": "default", "type": "cycle", "order": "speakers,headset", "move_streams": true})
+	if result.State != "headset" || result.Output != "1 Streams verschoben" || controller.sinks["7"] != "headset" {
+		t.Fatalf("move_streams: %+v, %v", result, controller.sinks)
+	}
+}
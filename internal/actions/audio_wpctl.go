@@ -0,0 +1,252 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält das Audio-Backend über die WirePlumber-Kommandozeile (wpctl).
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// wpctlDefaultSink bezeichnet bei wpctl den Standard-Sink
+const wpctlDefaultSink = "@DEFAULT_AUDIO_SINK@"
+
+var (
+	// wpctlVolumePattern liest "Volume: 0.40" bzw. "Volume: 0.40 [MUTED]"
+	wpctlVolumePattern = regexp.MustCompile(`Volume:\s*([0-9.]+)(\s*\[MUTED\])?`)
+
+	// wpctlNodePattern liest Einträge wie "*   50. Built-in Audio Analog Stereo   [vol: 0.40 MUTED]"
+	wpctlNodePattern = regexp.MustCompile(`^(\*)?\s*(\d+)\.\s+(.+?)\s*(?:\[vol:\s*([0-9.]+)(\s+MUTED)?\])?$`)
+)
+
+// wpctlController steuert Lautstärke und Ausgabegeräte über wpctl (PipeWire)
+type wpctlController struct {
+	runner CommandRunner
+}
+
+// wpctlNode ist ein Sink oder eine Source aus der Ausgabe von "wpctl status"
+type wpctlNode struct {
+	ID        string
+	Name      string
+	IsDefault bool
+	Volume    int
+	Muted     bool
+}
+
+func newWpctlController(runner CommandRunner) *wpctlController {
+	return &wpctlController{runner: runner}
+}
+
+func (c *wpctlController) GetVolume(ctx context.Context) (int, error) {
+	volume, _, err := c.getVolume(ctx, wpctlDefaultSink)
+	return volume, err
+}
+
+func (c *wpctlController) SetVolume(ctx context.Context, volume int) error {
+	return c.setVolume(ctx, wpctlDefaultSink, volume)
+}
+
+func (c *wpctlController) IncreaseVolume(ctx context.Context, percent int) error {
+	current, err := c.GetVolume(ctx)
+	if err != nil {
+		return err
+	}
+	return c.SetVolume(ctx, clampPercent(current+percent))
+}
+
+func (c *wpctlController) DecreaseVolume(ctx context.Context, percent int) error {
+	current, err := c.GetVolume(ctx)
+	if err != nil {
+		return err
+	}
+	return c.SetVolume(ctx, clampPercent(current-percent))
+}
+
+// GetMute gibt zurück, ob der Standard-Sink stummgeschaltet ist
+func (c *wpctlController) GetMute(ctx context.Context) (bool, error) {
+	_, muted, err := c.getVolume(ctx, wpctlDefaultSink)
+	return muted, err
+}
+
+// SetMute schaltet den Standard-Sink stumm oder wieder laut
+func (c *wpctlController) SetMute(ctx context.Context, muted bool) error {
+	return c.run(ctx, "set-mute", wpctlDefaultSink, boolArg(muted))
+}
+
+func (c *wpctlController) GetAudioSources(ctx context.Context, direction AudioDirection) ([]AudioSource, error) {
+	output, err := c.runner.Run(ctx, "wpctl", "status")
+	if err != nil {
+		return nil, err
+	}
+
+	section := "Sinks:"
+	if direction == AudioCapture {
+		section = "Sources:"
+	}
+	nodes := parseWpctlNodes(string(output), section)
+	sources := make([]AudioSource, 0, len(nodes))
+	for _, node := range nodes {
+		sources = append(sources, AudioSource{
+			ID:          node.ID,
+			Name:        node.Name,
+			Type:        guessDeviceType(direction, node.Name),
+			Direction:   direction,
+			IsDefault:   node.IsDefault,
+			IsMuted:     node.Muted,
+			Volume:      node.Volume,
+			IsAvailable: true,
+		})
+	}
+	return sources, nil
+}
+
+func (c *wpctlController) SetDefaultAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	id, err := c.resolve(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	return c.run(ctx, "set-default", id)
+}
+
+func (c *wpctlController) GetDefaultAudioSource(ctx context.Context, direction AudioDirection) (AudioSource, error) {
+	sources, err := c.GetAudioSources(ctx, direction)
+	if err != nil {
+		return AudioSource{}, err
+	}
+	for _, source := range sources {
+		if source.IsDefault {
+			return source, nil
+		}
+	}
+	return AudioSource{}, errNoAudioDevices
+}
+
+func (c *wpctlController) MuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	id, err := c.resolve(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	return c.run(ctx, "set-mute", id, "1")
+}
+
+func (c *wpctlController) UnmuteAudioSource(ctx context.Context, direction AudioDirection, sourceID string) error {
+	id, err := c.resolve(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	return c.run(ctx, "set-mute", id, "0")
+}
+
+func (c *wpctlController) SetAudioSourceVolume(ctx context.Context, direction AudioDirection, sourceID string, volume int) error {
+	id, err := c.resolve(ctx, direction, sourceID)
+	if err != nil {
+		return err
+	}
+	return c.setVolume(ctx, id, volume)
+}
+
+// run führt einen wpctl-Befehl ohne Ausgabe aus
+func (c *wpctlController) run(ctx context.Context, args ...string) error {
+	_, err := c.runner.Run(ctx, "wpctl", args...)
+	return err
+}
+
+// getVolume liest Lautstärke und Stummschaltung eines Knotens
+func (c *wpctlController) getVolume(ctx context.Context, id string) (int, bool, error) {
+	output, err := c.runner.Run(ctx, "wpctl", "get-volume", id)
+	if err != nil {
+		return 0, false, err
+	}
+	return parseWpctlVolume(string(output))
+}
+
+// setVolume setzt die Lautstärke eines Knotens (wpctl erwartet einen Faktor, 1.0 = 100%)
+func (c *wpctlController) setVolume(ctx context.Context, id string, volume int) error {
+	return c.run(ctx, "set-volume", id, strconv.FormatFloat(float64(clampPercent(volume))/100, 'f', 2, 64))
+}
+
+// resolve ermittelt die Knoten-ID zu einer ID oder einem Gerätenamen
+func (c *wpctlController) resolve(ctx context.Context, direction AudioDirection, sourceID string) (string, error) {
+	sources, err := c.GetAudioSources(ctx, direction)
+	if err != nil {
+		return "", err
+	}
+	source, err := findAudioSource(sources, sourceID)
+	if err != nil {
+		return "", err
+	}
+	return source.ID, nil
+}
+
+// parseWpctlVolume liest die Ausgabe von "wpctl get-volume"
+func parseWpctlVolume(output string) (int, bool, error) {
+	match := wpctlVolumePattern.FindStringSubmatch(output)
+	if match == nil {
+		return 0, false, fmt.Errorf("unerwartete Ausgabe von wpctl get-volume: %q", strings.TrimSpace(output))
+	}
+	factor, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("ungültige Lautstärke in der Ausgabe von wpctl: %q", match[1])
+	}
+	return int(math.Round(factor * 100)), match[2] != "", nil
+}
+
+// parseWpctlNodes liest die Knoten eines Unterabschnitts ("Sinks:" oder "Sources:") aus dem Abschnitt
+// "Audio" der Ausgabe von "wpctl status". Die Baumzeichen werden entfernt, damit unterschiedliche
+// Einrückungen der Versionen keine Rolle spielen.
+func parseWpctlNodes(output, section string) []wpctlNode {
+	var nodes []wpctlNode
+	inAudio, inSection := false, false
+
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+
+		// Abschnitte der obersten Ebene ("Audio", "Video", "Settings", ...) beginnen ohne Einrückung
+		if first, _ := utf8.DecodeRuneInString(line); !strings.ContainsRune(" \t│├└", first) {
+			inAudio = strings.TrimSpace(line) == "Audio"
+			inSection = false
+			continue
+		}
+		if !inAudio {
+			continue
+		}
+
+		text := strings.TrimSpace(strings.Trim(line, " │├└─"))
+		if text == "" {
+			continue
+		}
+		if strings.HasSuffix(text, ":") {
+			inSection = text == section
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		match := wpctlNodePattern.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		node := wpctlNode{
+			ID:        match[2],
+			Name:      match[3],
+			IsDefault: match[1] == "*",
+			Muted:     match[5] != "",
+		}
+		if match[4] != "" {
+			if factor, err := strconv.ParseFloat(match[4], 64); err == nil {
+				node.Volume = int(math.Round(factor * 100))
+			}
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
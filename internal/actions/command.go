@@ -0,0 +1,318 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält den Command-Executor für Befehle, deren Ergebnis abgewartet wird.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// Standardwerte für Befehle
+const (
+	defaultCommandTimeout = 30 * time.Second
+	defaultMaxOutput      = 64 * 1024
+
+	// commandWaitDelay begrenzt das Warten auf Kindprozesse, die nach dem Abbruch die Ausgabe offen halten
+	commandWaitDelay = 2 * time.Second
+)
+
+// CommandExecutor führt Befehle aus und übernimmt Exit-Code, stdout und stderr in das Ergebnis.
+// Anders als app_start wartet er auf das Ende des Prozesses.
+type CommandExecutor struct {
+	BaseExecutor
+}
+
+// CommandError meldet einen Befehl, der mit einem Exit-Code ungleich 0 beendet wurde
+type CommandError struct {
+	Command  string
+	ExitCode int
+	Stderr   string
+}
+
+// Error gibt den Exit-Code und die letzte Zeile der Fehlerausgabe zurück
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("befehl '%s' beendet mit Exit-Code %d", e.Command, e.ExitCode)
+	if lines := strings.Split(strings.TrimSpace(e.Stderr), "\n"); lines[len(lines)-1] != "" {
+		msg += ": " + lines[len(lines)-1]
+	}
+	return msg
+}
+
+// commandSpec enthält die ausgewerteten Parameter einer Command-Aktion
+type commandSpec struct {
+	command   string
+	args      []string
+	shell     string
+	env       []string
+	stdin     string
+	dir       string
+	timeout   time.Duration
+	maxOutput int
+}
+
+// NewCommandExecutor erstellt einen neuen Command-Executor
+func NewCommandExecutor(logger utils.Logger) (*CommandExecutor, error) {
+	executor := &CommandExecutor{
+		BaseExecutor: NewBaseExecutor("command", logger),
+	}
+
+	return executor, nil
+}
+
+// Execute führt eine Command-Aktion aus. Auch bei einem Fehler enthält das Ergebnis die
+// bis dahin erfassten Ausgaben und den Exit-Code.
+func (e *CommandExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
+	e.LogDebug("Führe Command-Aktion aus", "parameters", e.RedactParameters(action.Parameters))
+
+	spec, err := parseCommand(action)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if spec.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, spec.timeout)
+		defer cancel()
+	}
+
+	name, args := spec.command, spec.args
+	if spec.shell != "" {
+		name, args = shellCommand(spec.shell, spec.command, spec.args)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = spec.dir
+	cmd.WaitDelay = commandWaitDelay
+	if len(spec.env) > 0 {
+		cmd.Env = append(os.Environ(), spec.env...)
+	}
+	if spec.stdin != "" {
+		cmd.Stdin = strings.NewReader(spec.stdin)
+	}
+	stdout := &limitedBuffer{limit: spec.maxOutput}
+	stderr := &limitedBuffer{limit: spec.maxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	e.LogInfo("Führe Befehl aus", "command", spec.command, "args", spec.args, "shell", spec.shell, "working_dir", spec.dir)
+	err = cmd.Run()
+
+	result := Result{
+		Changed: true,
+		Output:  strings.TrimRight(stdout.String(), "\r\n"),
+		Stderr:  strings.TrimRight(stderr.String(), "\r\n"),
+	}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		e.LogInfo("Befehl beendet", "command", spec.command, "exit_code", result.ExitCode)
+		return result, nil
+	case ctx.Err() != nil:
+		// Abbruch durch Timeout, Mapping-Timeout oder Beenden des Daemons
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && spec.timeout > 0 {
+			return result, fmt.Errorf("befehl '%s' nach %s abgebrochen: %w", spec.command, spec.timeout, ctx.Err())
+		}
+		return result, fmt.Errorf("befehl '%s' abgebrochen: %w", spec.command, ctx.Err())
+	case errors.As(err, &exitErr):
+		return result, &CommandError{Command: spec.command, ExitCode: result.ExitCode, Stderr: result.Stderr}
+	default:
+		return result, fmt.Errorf("fehler beim Ausführen von '%s': %w", spec.command, err)
+	}
+}
+
+// RedactParameters entfernt die Werte von Umgebungsvariablen und die Eingabe aus den Parametern, bevor
+// sie in Verlauf und Logs gelangen; erhalten bleiben die Namen der Variablen und die Länge der Eingabe
+func (e *CommandExecutor) RedactParameters(parameters map[string]interface{}) map[string]interface{} {
+	_, hasEnv := parameters["env"]
+	stdin, hasStdin := parameters["stdin"]
+	if !hasEnv && !hasStdin {
+		return parameters
+	}
+
+	redacted := make(map[string]interface{}, len(parameters))
+	for name, value := range parameters {
+		redacted[name] = value
+	}
+	if hasEnv {
+		// Ungültige Angaben werden ganz verworfen, da sie sich nicht sicher in Name und Wert trennen lassen
+		var names []string
+		if env, err := envParameter(config.Action{Parameters: parameters}); err == nil {
+			for _, entry := range env {
+				name, _, _ := strings.Cut(entry, "=")
+				names = append(names, name)
+			}
+		}
+		redacted["env"] = names
+	}
+	if hasStdin {
+		redacted["stdin"] = fmt.Sprintf("[%d Bytes]", len(fmt.Sprint(stdin)))
+	}
+	return redacted
+}
+
+// Validate überprüft eine Command-Aktion auf Gültigkeit
+func (e *CommandExecutor) Validate(action config.Action) error {
+	_, err := parseCommand(action)
+	return err
+}
+
+// parseCommand liest und prüft die Parameter einer Command-Aktion
+func parseCommand(action config.Action) (commandSpec, error) {
+	var spec commandSpec
+	var ok bool
+	if spec.command, ok = action.Parameters["command"].(string); !ok || spec.command == "" {
+		return spec, fmt.Errorf("command-Aktion benötigt 'command' Parameter")
+	}
+
+	var err error
+	if spec.args, err = argsParameter(action); err != nil {
+		return spec, err
+	}
+
+	// shell: true für die Standard-Shell der Plattform oder der Name einer Shell
+	switch v := action.Parameters["shell"].(type) {
+	case nil:
+	case bool:
+		if v {
+			spec.shell = defaultShell()
+		}
+	case string:
+		spec.shell = v
+	default:
+		return spec, fmt.Errorf("'shell' Parameter muss true/false oder der Name einer Shell sein")
+	}
+
+	if spec.env, err = envParameter(action); err != nil {
+		return spec, err
+	}
+
+	if stdin, ok := action.Parameters["stdin"]; ok {
+		if spec.stdin, ok = stdin.(string); !ok {
+			return spec, fmt.Errorf("'stdin' Parameter muss ein String sein")
+		}
+	}
+	if dir, ok := action.Parameters["working_dir"]; ok {
+		if spec.dir, ok = dir.(string); !ok {
+			return spec, fmt.Errorf("'working_dir' Parameter muss ein String sein")
+		}
+	}
+
+	if spec.timeout, err = millisecondsParameter(action, "timeout", defaultCommandTimeout); err != nil {
+		return spec, err
+	}
+	if spec.maxOutput, err = intParameter(action, "max_output", defaultMaxOutput); err != nil {
+		return spec, err
+	}
+	if spec.maxOutput <= 0 {
+		return spec, fmt.Errorf("max_output muss größer als 0 sein")
+	}
+	return spec, nil
+}
+
+// argsParameter liest die Argumente als Liste oder Komma-getrennten String. Zahlen und
+// Wahrheitswerte (z.B. aus "{{.Value}}") werden in Text umgewandelt.
+func argsParameter(action config.Action) ([]string, error) {
+	list, ok := action.Parameters["args"].([]interface{})
+	if !ok {
+		return stringListParameter(action, "args")
+	}
+	args := make([]string, len(list))
+	for i, arg := range list {
+		switch arg.(type) {
+		case string, int, float64, bool:
+			args[i] = fmt.Sprint(arg)
+		default:
+			return nil, fmt.Errorf("arg %d muss ein String sein", i)
+		}
+	}
+	return args, nil
+}
+
+// envParameter liest zusätzliche Umgebungsvariablen als Objekt oder als Liste "NAME=wert"
+func envParameter(action config.Action) ([]string, error) {
+	var env []string
+	switch v := action.Parameters["env"].(type) {
+	case nil:
+	case map[string]interface{}:
+		for name, value := range v {
+			env = append(env, name+"="+fmt.Sprint(value))
+		}
+		// Feste Reihenfolge für Logs und Tests
+		sort.Strings(env)
+	case []interface{}, []string, string:
+		list, err := stringListParameter(action, "env")
+		if err != nil {
+			return nil, err
+		}
+		env = list
+	default:
+		return nil, fmt.Errorf("ungültiger 'env' Parameter: %v", v)
+	}
+	for _, entry := range env {
+		if name, _, ok := strings.Cut(entry, "="); !ok || name == "" {
+			return nil, fmt.Errorf("ungültige Umgebungsvariable: %s (erwartet: NAME=wert)", entry)
+		}
+	}
+	return env, nil
+}
+
+// defaultShell gibt die Standard-Shell der Plattform zurück
+func defaultShell() string {
+	if runtime.GOOS == "windows" {
+		return "cmd"
+	}
+	return "/bin/sh"
+}
+
+// shellCommand baut den Aufruf einer Shell. POSIX-Shells erhalten die Argumente als $1, $2, ...
+func shellCommand(shell, script string, args []string) (string, []string) {
+	switch strings.TrimSuffix(strings.ToLower(filepath.Base(shell)), ".exe") {
+	case "cmd":
+		return shell, append([]string{"/C", script}, args...)
+	case "powershell", "pwsh":
+		return shell, append([]string{"-NoProfile", "-Command", script}, args...)
+	default:
+		return shell, append([]string{"-c", script, "mididaemon"}, args...)
+	}
+}
+
+// limitedBuffer nimmt höchstens limit Bytes auf und verwirft den Rest, ohne den Prozess zu blockieren
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write schreibt bis zur Grenze in den Puffer
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if free := b.limit - b.buf.Len(); free < len(p) {
+		b.buf.Write(p[:max(free, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// String gibt den Inhalt zurück, gekürzte Ausgaben mit Hinweis
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n[gekürzt]"
+	}
+	return b.buf.String()
+}
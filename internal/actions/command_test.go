@@ -0,0 +1,129 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+func newTestCommandExecutor(t *testing.T) *CommandExecutor {
+	if runtime.GOOS == "windows" {
+		t.Skip("Tests benötigen /bin/sh")
+	}
+	executor, err := NewCommandExecutor(utils.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewCommandExecutor: %v", err)
+	}
+	return executor
+}
+
+func TestCommandExecutor(t *testing.T) {
+	executor := newTestCommandExecutor(t)
+	ctx := context.Background()
+
+	action := config.Action{Type: "command", Parameters: map[string]interface{}{
+		"command": `read line; echo "$line $GREETING $1"; echo warn >&2`,
+		"shell":   true,
+		"args":    []interface{}{"arg"},
+		"env":     map[string]interface{}{"GREETING": "hallo"},
+		"stdin":   "eingabe\n",
+	}}
+	if err := executor.Validate(action); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	result, err := executor.Execute(ctx, action)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if result.Output != "eingabe hallo arg" || result.Stderr != "warn" || result.ExitCode != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	// Exit-Code und Fehlerausgabe bleiben bei Fehlern erhalten
+	action = config.Action{Type: "command", Parameters: map[string]interface{}{
+		"command": "sh",
+		"args":    []interface{}{"-c", "echo teilweise; echo kaputt >&2; exit 3"},
+	}}
+	result, err = executor.Execute(ctx, action)
+	var commandErr *CommandError
+	if !errors.As(err, &commandErr) || commandErr.ExitCode != 3 || commandErr.Error() != "befehl 'sh' beendet mit Exit-Code 3: kaputt" {
+		t.Fatalf("expected exit code 3, got %v", err)
+	}
+	if result.Output != "teilweise" || result.ExitCode != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	// Timeout
+	action = config.Action{Type: "command", Parameters: map[string]interface{}{"command": "sleep", "args": "5", "timeout": 50}}
+	start := time.Now()
+	if _, err := executor.Execute(ctx, action); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("timeout not enforced, took %v", elapsed)
+	}
+
+	// Ausgaben werden begrenzt
+	action = config.Action{Type: "command", Parameters: map[string]interface{}{"command": "echo 1234567890", "shell": "sh", "max_output": 4}}
+	if result, err := executor.Execute(ctx, action); err != nil || result.Output != "1234\n[gekürzt]" {
+		t.Fatalf("unexpected truncated output: %q, %v", result.Output, err)
+	}
+
+	for _, parameters := range []map[string]interface{}{
+		{"args": "x"},
+		{"command": "true", "shell": 1},
+		{"command": "true", "env": []interface{}{"=x"}},
+		{"command": "true", "timeout": -1},
+		{"command": "true", "max_output": 0},
+	} {
+		if err := executor.Validate(config.Action{Type: "command", Parameters: parameters}); err == nil {
+			t.Fatalf("expected error for %v", parameters)
+		}
+	}
+}
+
+func TestMacroStepOutput(t *testing.T) {
+	newTestCommandExecutor(t)
+	manager, err := NewManager(&config.Config{}, utils.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	mapping := config.Mapping{Name: "pipeline", Macro: &config.Macro{Steps: []config.MacroStep{
+		{Name: "count", Action: config.Action{Type: "command", Parameters: map[string]interface{}{
+			"command": "echo 42",
+			"shell":   true,
+			"env":     map[string]interface{}{"TOKEN": "geheim"},
+			"stdin":   "passwort",
+		}}},
+		{Name: "fail", ContinueOnError: true, Action: config.Action{Type: "command", Parameters: map[string]interface{}{"command": "exit 2", "shell": true}}},
+		{Name: "report", Action: config.Action{Type: "command", Parameters: map[string]interface{}{
+			"command": "echo",
+			"args":    []interface{}{"{{.Steps.count.Output}}", "{{.Last.ExitCode}}"},
+		}}},
+	}}}
+	if err := manager.ExecuteMacro(context.Background(), mapping, config.TemplateData{}); err != nil {
+		t.Fatalf("ExecuteMacro: %v", err)
+	}
+
+	entries := manager.History().Entries(HistoryFilter{Mapping: "pipeline"})
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 history entries, got %d", len(entries))
+	}
+	// Werte von Umgebungsvariablen und Eingabe gelangen nicht in den Verlauf
+	if count := entries[0].Parameters; fmt.Sprint(count["env"]) != "[TOKEN]" || count["stdin"] != "[8 Bytes]" {
+		t.Fatalf("env and stdin not redacted: %v", count)
+	}
+	if failed := entries[1]; failed.Error == "" || failed.Result == nil || failed.Result.ExitCode != 2 {
+		t.Fatalf("failed step without exit code: %+v", failed)
+	}
+	if report := entries[2]; report.Result == nil || report.Result.Output != "42 2" {
+		t.Fatalf("unexpected report step: %+v", report)
+	}
+}
@@ -0,0 +1,410 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält das automatische Absenken anderer Anwendungen (Ducking).
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// defaultDuckingInterval gilt, wenn kein Abfrageintervall konfiguriert ist
+const defaultDuckingInterval = 250 * time.Millisecond
+
+// duckingRestoreTimeout begrenzt das Wiederherstellen der Lautstärken beim Beenden
+const duckingRestoreTimeout = 5 * time.Second
+
+// errDuckingUnsupported wird gemeldet, wenn das Audio-Backend keine Lautstärke pro Anwendung kennt
+var errDuckingUnsupported = errors.New("das Audio-Backend unterstützt keine Lautstärke pro Anwendung")
+
+// DuckingState ist der Zustand des Duckings (z.B. für LED-Feedback)
+type DuckingState struct {
+	Enabled bool `json:"enabled"`
+	Active  bool `json:"active"`
+}
+
+// duckedStream merkt sich die Lautstärken eines abgesenkten Streams
+type duckedStream struct {
+	original int // Lautstärke vor dem Absenken
+	ducked   int // beim Absenken gesetzte Lautstärke
+
+	// Der Benutzer hat die Lautstärke während des Absenkens geändert; sie wird nicht mehr angetastet
+	changed bool
+}
+
+// Ducker senkt andere Anwendungen ab, solange das Mikrofon aktiv ist, und stellt sie danach wieder her
+type Ducker struct {
+	controller AudioController
+	logger     utils.Logger
+
+	// busy serialisiert die Aufrufe des Backends und schützt ducked; mutex schützt nur die
+	// Zustandsfelder und wird nie während eines Backend-Aufrufs gehalten
+	busy   sync.Mutex
+	ducked map[string]duckedStream // Stream-ID -> Lautstärken
+
+	mutex       sync.Mutex
+	cfg         config.DuckingConfig
+	enabled     bool
+	active      bool
+	lastTrigger time.Time
+}
+
+// NewDucker erstellt einen neuen Ducker
+func NewDucker(controller AudioController, cfg config.DuckingConfig, logger utils.Logger) *Ducker {
+	return &Ducker{
+		controller: controller,
+		logger:     logger,
+		cfg:        cfg,
+		enabled:    cfg.Enabled,
+		ducked:     make(map[string]duckedStream),
+	}
+}
+
+// Reconfigure übernimmt neue Einstellungen. Der zur Laufzeit umgeschaltete Zustand bleibt erhalten,
+// solange "enabled" in der Konfiguration unverändert ist; wird es ausgeschaltet, werden abgesenkte
+// Streams wie bei SetEnabled sofort wiederhergestellt.
+func (d *Ducker) Reconfigure(ctx context.Context, cfg config.DuckingConfig) error {
+	d.mutex.Lock()
+	changed := cfg.Enabled != d.cfg.Enabled
+	d.cfg = cfg
+	d.mutex.Unlock()
+
+	if !changed {
+		return nil
+	}
+	return d.SetEnabled(ctx, cfg.Enabled)
+}
+
+// SetEnabled schaltet das Ducking ein oder aus; beim Ausschalten werden abgesenkte Streams sofort wiederhergestellt
+func (d *Ducker) SetEnabled(ctx context.Context, enabled bool) error {
+	d.busy.Lock()
+	defer d.busy.Unlock()
+
+	d.mutex.Lock()
+	d.enabled = enabled
+	if !enabled {
+		d.active = false
+	}
+	d.mutex.Unlock()
+
+	if enabled {
+		return nil
+	}
+	return d.restore(ctx)
+}
+
+// SetController wechselt das Audio-Backend, z.B. nach dem Neuladen der Konfiguration. Abgesenkte
+// Streams werden vorher über das bisherige Backend wiederhergestellt, danach wird es freigegeben.
+func (d *Ducker) SetController(ctx context.Context, controller AudioController) error {
+	d.busy.Lock()
+	defer d.busy.Unlock()
+
+	err := d.restore(ctx)
+	if closeErr := closeController(d.controller); err == nil {
+		err = closeErr
+	}
+	d.controller = controller
+	d.ducked = make(map[string]duckedStream)
+
+	d.mutex.Lock()
+	d.active = false
+	d.mutex.Unlock()
+	return err
+}
+
+// State gibt zurück, ob das Ducking eingeschaltet ist und gerade absenkt
+func (d *Ducker) State() DuckingState {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return DuckingState{Enabled: d.enabled, Active: d.active}
+}
+
+// Run prüft das Mikrofon im konfigurierten Intervall, bis ctx endet. Abgesenkte Streams werden beim Beenden wiederhergestellt.
+func (d *Ducker) Run(ctx context.Context) error {
+	var lastErr string
+	for {
+		select {
+		case <-ctx.Done():
+			return d.shutdown()
+		case <-time.After(d.interval()):
+		}
+
+		// Fehler (z.B. ein abgestecktes Mikrofon) nur bei Änderung melden, nicht bei jedem Durchlauf
+		switch err := d.tick(ctx, time.Now()); {
+		case err == nil:
+			lastErr = ""
+		case err.Error() != lastErr:
+			lastErr = err.Error()
+			d.logger.Warn("Fehler beim Ducking", "error", err)
+		}
+	}
+}
+
+// shutdown stellt beim Beenden alle abgesenkten Streams wieder her, höchstens für duckingRestoreTimeout
+func (d *Ducker) shutdown() error {
+	d.busy.Lock()
+	defer d.busy.Unlock()
+
+	d.mutex.Lock()
+	d.active = false
+	d.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), duckingRestoreTimeout)
+	defer cancel()
+	return d.restore(ctx)
+}
+
+// config gibt die aktuellen Einstellungen zurück
+func (d *Ducker) config() config.DuckingConfig {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.cfg
+}
+
+// interval gibt das Abfrageintervall zurück
+func (d *Ducker) interval() time.Duration {
+	if interval := d.config().Interval; interval > 0 {
+		return time.Duration(interval) * time.Millisecond
+	}
+	return defaultDuckingInterval
+}
+
+// tick wertet das Mikrofon einmal aus und senkt ab bzw. stellt wieder her
+func (d *Ducker) tick(ctx context.Context, now time.Time) error {
+	d.busy.Lock()
+	defer d.busy.Unlock()
+
+	d.mutex.Lock()
+	enabled, cfg := d.enabled, d.cfg
+	d.mutex.Unlock()
+	if !enabled {
+		return nil
+	}
+	// Das Backend kann beim Neuladen wechseln und wird deshalb bei jedem Durchlauf geprüft
+	if _, ok := d.controller.(AppAudioController); !ok {
+		return errDuckingUnsupported
+	}
+
+	triggered, err := d.triggered(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	d.mutex.Lock()
+	wasActive := d.active
+	if triggered {
+		d.active = true
+		d.lastTrigger = now
+	} else if d.active && now.Sub(d.lastTrigger) >= time.Duration(cfg.Release)*time.Millisecond {
+		d.active = false
+	}
+	active := d.active
+	d.mutex.Unlock()
+
+	if triggered {
+		if !wasActive {
+			d.logger.Debug("Mikrofon aktiv, senke andere Anwendungen ab", "amount", cfg.Amount)
+		}
+		// Auch Streams, die während der Aktivität starten, werden abgesenkt
+		return d.duck(ctx, cfg)
+	}
+	// Auch ein zuvor fehlgeschlagenes Wiederherstellen wird wiederholt
+	if !active && len(d.ducked) > 0 {
+		d.logger.Debug("Mikrofon inaktiv, stelle Lautstärken wieder her", "streams", len(d.ducked))
+		return d.restore(ctx)
+	}
+	return nil
+}
+
+// triggered prüft, ob das Mikrofon eingeschaltet ist
+func (d *Ducker) triggered(ctx context.Context, cfg config.DuckingConfig) (bool, error) {
+	var source AudioSource
+	var err error
+	if cfg.Source == "" || cfg.Source == defaultSourceAlias {
+		source, err = d.controller.GetDefaultAudioSource(ctx, AudioCapture)
+	} else {
+		var sources []AudioSource
+		sources, err = d.controller.GetAudioSources(ctx, AudioCapture)
+		if err == nil {
+			source, err = findAudioSource(sources, cfg.Source)
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("fehler beim Abrufen des Mikrofons: %w", err)
+	}
+	return !source.IsMuted, nil
+}
+
+// duck senkt alle noch nicht abgesenkten Streams ab, die nicht ausgenommen sind. Hat der Benutzer
+// die Lautstärke eines abgesenkten Streams inzwischen geändert, bleibt sie unangetastet.
+func (d *Ducker) duck(ctx context.Context, cfg config.DuckingConfig) error {
+	controller := d.controller.(AppAudioController)
+	streams, err := controller.GetAppStreams(ctx)
+	if err != nil {
+		return fmt.Errorf("fehler beim Abrufen der Streams: %w", err)
+	}
+
+	running := make(map[string]bool, len(streams))
+	var errs []string
+	for _, stream := range streams {
+		running[stream.ID] = true
+		if entry, ok := d.ducked[stream.ID]; ok {
+			if !entry.changed && volumeChanged(stream.Volume, entry.ducked) {
+				d.logger.Debug("Lautstärke während des Absenkens geändert, wird nicht wiederhergestellt",
+					"stream", stream.ID, "application", stream.Application, "volume", stream.Volume)
+				entry.changed = true
+				d.ducked[stream.ID] = entry
+			}
+			continue
+		}
+		if excludedStream(cfg, stream) {
+			continue
+		}
+		volume := stream.Volume * (100 - cfg.Amount) / 100
+		if err := controller.SetAppStreamVolume(ctx, stream.ID, volume); err != nil {
+			if !errors.Is(err, errStreamGone) {
+				errs = append(errs, err.Error())
+			}
+			continue
+		}
+		d.ducked[stream.ID] = duckedStream{original: stream.Volume, ducked: volume}
+	}
+
+	// Beendete Streams vergessen
+	for id := range d.ducked {
+		if !running[id] {
+			delete(d.ducked, id)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("fehler beim Absenken: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// restore setzt alle abgesenkten Streams auf ihre vorherige Lautstärke zurück. Streams, deren
+// Lautstärke der Benutzer während des Absenkens geändert hat, behalten sie. Streams, die nicht
+// wiederhergestellt werden konnten, bleiben für den nächsten Versuch vorgemerkt.
+func (d *Ducker) restore(ctx context.Context) error {
+	controller, ok := d.controller.(AppAudioController)
+	if !ok || len(d.ducked) == 0 {
+		return nil
+	}
+	streams, err := controller.GetAppStreams(ctx)
+	if err != nil {
+		return fmt.Errorf("fehler beim Abrufen der Streams: %w", err)
+	}
+	current := make(map[string]int, len(streams))
+	for _, stream := range streams {
+		current[stream.ID] = stream.Volume
+	}
+
+	var errs []string
+	for id, entry := range d.ducked {
+		volume, running := current[id]
+		if running && !entry.changed && !volumeChanged(volume, entry.ducked) {
+			if err := controller.SetAppStreamVolume(ctx, id, entry.original); err != nil && !errors.Is(err, errStreamGone) {
+				errs = append(errs, err.Error())
+				continue
+			}
+		}
+		delete(d.ducked, id)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("fehler beim Wiederherstellen: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// volumeChanged vergleicht eine gemeldete mit einer gesetzten Lautstärke; ein Prozentpunkt
+// Abweichung entsteht durch Rundung im Backend und gilt nicht als Änderung
+func volumeChanged(reported, set int) bool {
+	return reported-set > 1 || set-reported > 1
+}
+
+// excludedStream prüft, ob ein Stream vom Absenken ausgenommen ist (z.B. die Sprach-App selbst)
+func excludedStream(cfg config.DuckingConfig, stream AppStream) bool {
+	for _, pattern := range cfg.Exclude {
+		if matchPattern(pattern, stream.Application) || matchPattern(pattern, stream.Binary) {
+			return true
+		}
+	}
+	return false
+}
+
+// DuckingExecutor schaltet das Ducking zur Laufzeit ein und aus
+type DuckingExecutor struct {
+	BaseExecutor
+	ducker *Ducker
+}
+
+// NewDuckingExecutor erstellt einen neuen Ducking-Executor
+func NewDuckingExecutor(audio config.AudioConfig, logger utils.Logger) (*DuckingExecutor, error) {
+	controller, err := newAudioController(audio)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Erstellen des Audio-Controllers: %w", err)
+	}
+
+	executor := &DuckingExecutor{
+		BaseExecutor: NewBaseExecutor("ducking", logger),
+		ducker:       NewDucker(controller, audio.Ducking, logger),
+	}
+
+	return executor, nil
+}
+
+// Execute führt eine Ducking-Aktion aus
+func (e *DuckingExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
+	e.LogDebug("Führe Ducking-Aktion aus", "parameters", action.Parameters)
+
+	operation, _ := action.Parameters["operation"].(string)
+	previous := e.ducker.State().Enabled
+
+	var enabled bool
+	switch operation {
+	case "enable":
+		enabled = true
+	case "disable":
+		enabled = false
+	case "toggle":
+		enabled = !previous
+	default:
+		return Result{}, fmt.Errorf("ungültige Operation: %s (erwartet: enable, disable, toggle)", operation)
+	}
+
+	e.LogInfo("Ducking umgeschaltet", "enabled", enabled)
+	if err := e.ducker.SetEnabled(ctx, enabled); err != nil {
+		return Result{}, err
+	}
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	return Result{Changed: enabled != previous, State: state}, nil
+}
+
+// Validate überprüft eine Ducking-Aktion auf Gültigkeit
+func (e *DuckingExecutor) Validate(action config.Action) error {
+	operation, ok := action.Parameters["operation"].(string)
+	if !ok {
+		return fmt.Errorf("ducking-Aktion benötigt 'operation' Parameter")
+	}
+	switch operation {
+	case "enable", "disable", "toggle":
+		return nil
+	default:
+		return fmt.Errorf("ungültige Operation: %s (erwartet: enable, disable, toggle)", operation)
+	}
+}
+
+// Ducker gibt die Ducking-Engine zurück
+func (e *DuckingExecutor) Ducker() *Ducker {
+	return e.ducker
+}
@@ -0,0 +1,145 @@
+package actions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+func newDuckingTestController() *fakeAppController {
+	return &fakeAppController{
+		AudioController: newFakeAudioController(),
+		streams: []AppStream{
+			{ID: "7", Application: "Spotify", Binary: "spotify", Volume: 80},
+			{ID: "9", Application: "Discord", Binary: "Discord", Volume: 100},
+		},
+		gone:  map[string]bool{},
+		sinks: map[string]string{},
+	}
+}
+
+func TestDucker(t *testing.T) {
+	controller := newDuckingTestController()
+	cfg := config.DuckingConfig{Enabled: true, Amount: 75, Release: 500, Exclude: []string{"discord"}}
+	d := NewDucker(controller, cfg, utils.NewNullLogger())
+	ctx := context.Background()
+	now := time.Now()
+
+	// Mikrofon eingeschaltet: alle außer den ausgenommenen Anwendungen werden abgesenkt
+	if err := d.tick(ctx, now); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if !d.State().Active || controller.streams[0].Volume != 20 || controller.streams[1].Volume != 100 {
+		t.Fatalf("not ducked: %+v, %+v", d.State(), controller.streams)
+	}
+
+	// Neue Streams während der Aktivität werden ebenfalls abgesenkt, bestehende nicht erneut
+	controller.streams = append(controller.streams, AppStream{ID: "12", Application: "Firefox", Binary: "firefox", Volume: 40})
+	d.tick(ctx, now.Add(250*time.Millisecond))
+	if controller.streams[0].Volume != 20 || controller.streams[2].Volume != 10 {
+		t.Fatalf("unexpected volumes: %+v", controller.streams)
+	}
+
+	// Nach dem Stummschalten erst nach der Wartezeit wiederherstellen
+	controller.MuteAudioSource(ctx, AudioCapture, "mic")
+	d.tick(ctx, now.Add(500*time.Millisecond))
+	if !d.State().Active || controller.streams[0].Volume != 20 {
+		t.Fatalf("restored before release: %+v", controller.streams)
+	}
+	d.tick(ctx, now.Add(800*time.Millisecond))
+	if d.State().Active || controller.streams[0].Volume != 80 || controller.streams[2].Volume != 40 {
+		t.Fatalf("not restored: %+v, %+v", d.State(), controller.streams)
+	}
+
+	// Ausschalten stellt sofort wieder her
+	controller.UnmuteAudioSource(ctx, AudioCapture, "mic")
+	d.tick(ctx, now.Add(time.Second))
+	if err := d.SetEnabled(ctx, false); err != nil {
+		t.Fatalf("SetEnabled: %v", err)
+	}
+	d.tick(ctx, now.Add(2*time.Second))
+	if d.State() != (DuckingState{}) || controller.streams[0].Volume != 80 {
+		t.Fatalf("disable did not restore: %+v, %+v", d.State(), controller.streams)
+	}
+}
+
+func TestDuckerKeepsUserVolume(t *testing.T) {
+	controller := newDuckingTestController()
+	d := NewDucker(controller, config.DuckingConfig{Enabled: true, Amount: 50}, utils.NewNullLogger())
+	ctx := context.Background()
+	now := time.Now()
+
+	d.tick(ctx, now)
+	if controller.streams[0].Volume != 40 || controller.streams[1].Volume != 50 {
+		t.Fatalf("not ducked: %+v", controller.streams)
+	}
+
+	// Der Benutzer ändert eine Lautstärke während des Absenkens; sie wird weder erneut abgesenkt
+	// noch beim Wiederherstellen überschrieben
+	controller.SetAppStreamVolume(ctx, "7", 65)
+	d.tick(ctx, now.Add(250*time.Millisecond))
+	controller.MuteAudioSource(ctx, AudioCapture, "mic")
+	d.tick(ctx, now.Add(500*time.Millisecond))
+	if d.State().Active || controller.streams[0].Volume != 65 || controller.streams[1].Volume != 100 {
+		t.Fatalf("unexpected volumes after restore: %+v", controller.streams)
+	}
+
+	// Auch beim Beenden werden geänderte Lautstärken nicht überschrieben
+	controller.UnmuteAudioSource(ctx, AudioCapture, "mic")
+	d.tick(ctx, now.Add(time.Second))
+	controller.SetAppStreamVolume(ctx, "9", 70)
+	if err := d.shutdown(); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if controller.streams[0].Volume != 65 || controller.streams[1].Volume != 70 {
+		t.Fatalf("unexpected volumes after shutdown: %+v", controller.streams)
+	}
+}
+
+func TestDuckerReconfigureRestores(t *testing.T) {
+	controller := newDuckingTestController()
+	d := NewDucker(controller, config.DuckingConfig{Enabled: true, Amount: 50}, utils.NewNullLogger())
+	ctx := context.Background()
+
+	d.tick(ctx, time.Now())
+	if controller.streams[0].Volume != 40 {
+		t.Fatalf("not ducked: %+v", controller.streams)
+	}
+
+	// Ausschalten über die Konfiguration stellt sofort wieder her, wie die Aktion "ducking"
+	if err := d.Reconfigure(ctx, config.DuckingConfig{Amount: 50}); err != nil {
+		t.Fatalf("Reconfigure: %v", err)
+	}
+	if d.State() != (DuckingState{}) || controller.streams[0].Volume != 80 || controller.streams[1].Volume != 100 {
+		t.Fatalf("disable by reload did not restore: %+v, %+v", d.State(), controller.streams)
+	}
+}
+
+func TestDuckingExecutor(t *testing.T) {
+	e := &DuckingExecutor{
+		BaseExecutor: NewBaseExecutor("ducking", utils.NewNullLogger()),
+		ducker:       NewDucker(newDuckingTestController(), config.DuckingConfig{}, utils.NewNullLogger()),
+	}
+	action := config.Action{Type: "ducking", Parameters: map[string]interface{}{"operation": "toggle"}}
+	if err := e.Validate(action); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	result, err := e.Execute(context.Background(), action)
+	if err != nil || !result.Changed || result.State != "enabled" || !e.ducker.State().Enabled {
+		t.Fatalf("toggle: %+v, %v", result, err)
+	}
+
+	// Ein Neuladen mit unveränderter Konfiguration behält den umgeschalteten Zustand
+	e.ducker.Reconfigure(context.Background(), config.DuckingConfig{Amount: 30})
+	if !e.ducker.State().Enabled {
+		t.Fatalf("reload reset runtime state")
+	}
+
+	action.Parameters["operation"] = "on"
+	if err := e.Validate(action); err == nil {
+		t.Fatalf("expected error for invalid operation")
+	}
+}
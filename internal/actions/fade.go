@@ -0,0 +1,190 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält die Überblendungen (Fades) für Lautstärken.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+// Grenzen und Schrittweite für Überblendungen
+const (
+	fadeStepInterval = 20 * time.Millisecond
+	maxFadeDuration  = 10 * time.Minute
+)
+
+// errFadeCanceled meldet eine Überblendung, die durch eine neuere Änderung desselben Ziels abgebrochen wurde
+var errFadeCanceled = errors.New("überblendung durch eine neuere Änderung abgebrochen")
+
+// Easing bildet den Fortschritt einer Überblendung (0 bis 1) auf den Anteil der Lautstärkeänderung ab
+type Easing func(t float64) float64
+
+// easings enthält die verfügbaren Verläufe
+var easings = map[string]Easing{
+	"linear":      func(t float64) float64 { return t },
+	"ease_in":     func(t float64) float64 { return t * t },
+	"ease_out":    func(t float64) float64 { return t * (2 - t) },
+	"ease_in_out": func(t float64) float64 { return (1 - math.Cos(math.Pi*t)) / 2 },
+	// Exponentiell wirkt für das Ohr gleichmäßiger, weil Lautstärke logarithmisch wahrgenommen wird
+	"exponential": func(t float64) float64 {
+		if t <= 0 {
+			return 0
+		}
+		return math.Pow(2, 10*(t-1))
+	},
+}
+
+// easingByName gibt den Verlauf zu einem Namen zurück (leer = linear)
+func easingByName(name string) (Easing, error) {
+	if name == "" {
+		name = "linear"
+	}
+	easing, ok := easings[name]
+	if !ok {
+		return nil, fmt.Errorf("ungültiges easing: %s (erwartet: linear, ease_in, ease_out, ease_in_out, exponential)", name)
+	}
+	return easing, nil
+}
+
+// fader führt Überblendungen aus. Je Ziel läuft höchstens eine; eine neue bricht die laufende ab.
+type fader struct {
+	mutex   sync.Mutex
+	running map[string]*fadeRun
+}
+
+// fadeRun ist eine laufende Überblendung
+type fadeRun struct {
+	cancel context.CancelFunc
+}
+
+// newFader erstellt einen neuen Fader
+func newFader() *fader {
+	return &fader{
+		running: make(map[string]*fadeRun),
+	}
+}
+
+// fade blendet von from nach to über duration und ruft set für jeden Zwischenwert auf.
+// Blockiert bis zum Ende; wird die Überblendung ersetzt, kommt errFadeCanceled zurück.
+func (f *fader) fade(ctx context.Context, target string, from, to int, duration time.Duration, easing Easing, set func(ctx context.Context, volume int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	run := &fadeRun{cancel: cancel}
+	f.mutex.Lock()
+	if previous, ok := f.running[target]; ok {
+		previous.cancel()
+	}
+	f.running[target] = run
+	f.mutex.Unlock()
+
+	defer func() {
+		f.mutex.Lock()
+		if f.running[target] == run {
+			delete(f.running, target)
+		}
+		f.mutex.Unlock()
+	}()
+
+	ticker := time.NewTicker(fadeStepInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	last := from
+	for {
+		progress := 1.0
+		if duration > 0 {
+			progress = math.Min(float64(time.Since(start))/float64(duration), 1)
+		}
+		volume := from + int(math.Round(float64(to-from)*easing(progress)))
+		if volume != last || progress >= 1 {
+			if err := set(ctx, volume); err != nil {
+				if ctx.Err() != nil {
+					return f.stopped(target, run, ctx.Err())
+				}
+				return err
+			}
+			last = volume
+		}
+		if progress >= 1 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return f.stopped(target, run, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// stopped unterscheidet den Abbruch durch eine neuere Überblendung vom Abbruch von außerhalb
+func (f *fader) stopped(target string, run *fadeRun, err error) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.running[target] != run {
+		return errFadeCanceled
+	}
+	return err
+}
+
+// fadeParameters liest Ziel-Lautstärke, Dauer ("duration" in Millisekunden) und Verlauf ("easing") einer Überblendung
+func fadeParameters(params map[string]interface{}) (int, time.Duration, Easing, error) {
+	action := config.Action{Parameters: params}
+	volume, err := intParameter(action, "volume", -1)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if volume < 0 || volume > 100 {
+		return 0, 0, nil, fmt.Errorf("volume muss zwischen 0 und 100 liegen, got: %d", volume)
+	}
+	duration, easing, err := fadeTiming(params, time.Second)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return volume, duration, easing, nil
+}
+
+// fadeTiming liest Dauer ("duration" in Millisekunden) und Verlauf ("easing") einer Überblendung
+func fadeTiming(params map[string]interface{}, fallback time.Duration) (time.Duration, Easing, error) {
+	duration, err := intParameter(config.Action{Parameters: params}, "duration", int(fallback.Milliseconds()))
+	if err != nil {
+		return 0, nil, err
+	}
+	if duration < 0 || time.Duration(duration)*time.Millisecond > maxFadeDuration {
+		return 0, nil, fmt.Errorf("duration muss zwischen 0 und %d ms liegen, got: %d", maxFadeDuration.Milliseconds(), duration)
+	}
+	name, _ := params["easing"].(string)
+	easing, err := easingByName(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	return time.Duration(duration) * time.Millisecond, easing, nil
+}
+
+// stopAll bricht alle laufenden Überblendungen ab
+func (f *fader) stopAll() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for target, run := range f.running {
+		run.cancel()
+		delete(f.running, target)
+	}
+}
+
+// cancel bricht eine laufende Überblendung auf dem Ziel ab, z.B. wenn die Lautstärke direkt gesetzt wird
+func (f *fader) cancel(target string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if run, ok := f.running[target]; ok {
+		run.cancel()
+		delete(f.running, target)
+	}
+}
@@ -0,0 +1,87 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEasings(t *testing.T) {
+	for name, easing := range easings {
+		if easing(0) != 0 || easing(1) != 1 {
+			t.Fatalf("%s: endpoints %v, %v", name, easing(0), easing(1))
+		}
+		for step := 1; step <= 10; step++ {
+			if easing(float64(step)/10) < easing(float64(step-1)/10) {
+				t.Fatalf("%s is not monotonic", name)
+			}
+		}
+	}
+	if _, err := easingByName("bounce"); err == nil {
+		t.Fatalf("expected error for unknown easing")
+	}
+}
+
+func TestFade(t *testing.T) {
+	f := newFader()
+	var mutex sync.Mutex
+	var values []int
+	set := func(ctx context.Context, volume int) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		values = append(values, volume)
+		return nil
+	}
+
+	if err := f.fade(context.Background(), "volume", 80, 20, 100*time.Millisecond, easings["ease_in_out"], set); err != nil {
+		t.Fatalf("fade: %v", err)
+	}
+	if len(values) < 3 || values[len(values)-1] != 20 {
+		t.Fatalf("unexpected steps: %v", values)
+	}
+	for i := 1; i < len(values); i++ {
+		if values[i] > values[i-1] {
+			t.Fatalf("fade down went up: %v", values)
+		}
+	}
+
+	// Eine neue Überblendung auf demselben Ziel bricht die laufende ab
+	done := make(chan error, 1)
+	go func() {
+		done <- f.fade(context.Background(), "volume", 0, 100, time.Minute, easings["linear"], set)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if err := f.fade(context.Background(), "volume", 50, 10, 0, easings["linear"], set); err != nil {
+		t.Fatalf("second fade: %v", err)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, errFadeCanceled) {
+			t.Fatalf("expected canceled fade, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("first fade was not canceled")
+	}
+
+	// Andere Ziele laufen unabhängig weiter
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		f.cancel("volume")
+		cancel()
+	}()
+	if err := f.fade(ctx, "source:hdmi", 0, 100, time.Minute, easings["linear"], set); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context cancel, got %v", err)
+	}
+
+	// Beim Schließen eines Executors werden alle Überblendungen abgebrochen
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		f.stopAll()
+	}()
+	if err := f.fade(context.Background(), "source:hdmi", 0, 100, time.Minute, easings["linear"], set); !errors.Is(err, errFadeCanceled) {
+		t.Fatalf("expected canceled fade after stopAll, got %v", err)
+	}
+}
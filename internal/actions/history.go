@@ -0,0 +1,275 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält den Verlauf der ausgeführten Aktionen (Ringpuffer und optionale JSONL-Datei).
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+// EventRecord beschreibt das auslösende Event eines Verlaufseintrags
+type EventRecord struct {
+	Type       string `json:"type,omitempty"`
+	Port       string `json:"port,omitempty"`
+	Channel    int    `json:"channel"`
+	Note       int    `json:"note,omitempty"`
+	Velocity   int    `json:"velocity,omitempty"`
+	Controller int    `json:"controller,omitempty"`
+	Program    int    `json:"program,omitempty"`
+	Value      int    `json:"value"`
+	Layer      string `json:"layer,omitempty"`
+}
+
+// HistoryEntry ist ein Eintrag im Aktionsverlauf
+type HistoryEntry struct {
+	Time       time.Time              `json:"time"`
+	Mapping    string                 `json:"mapping"`
+	Step       string                 `json:"step,omitempty"` // Makro-Schritt
+	Event      EventRecord            `json:"event"`
+	Action     string                 `json:"action"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"` // nach dem Ausfüllen der Templates
+	Duration   time.Duration          `json:"duration"`
+	Result     *Result                `json:"result,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// HistoryFilter schränkt eine Abfrage des Verlaufs ein
+type HistoryFilter struct {
+	Mapping    string // nur Einträge dieses Mappings
+	ErrorsOnly bool   // nur fehlgeschlagene Aktionen
+	Limit      int    // höchstens so viele (neueste) Einträge, 0 = alle
+}
+
+// History speichert die zuletzt ausgeführten Aktionen in einem Ringpuffer
+type History struct {
+	entries []HistoryEntry
+	next    int
+	full    bool
+	cfg     config.HistoryConfig
+	file    *os.File
+	written int64
+	mutex   sync.Mutex
+}
+
+// NewHistory erstellt einen neuen Aktionsverlauf
+func NewHistory(cfg config.HistoryConfig) (*History, error) {
+	h := &History{}
+	if err := h.Reconfigure(cfg); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Reconfigure übernimmt neue Einstellungen; vorhandene Einträge bleiben soweit möglich erhalten
+func (h *History) Reconfigure(cfg config.HistoryConfig) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if cfg.Size <= 0 {
+		cfg.Size = 200
+	}
+
+	if len(h.entries) != cfg.Size {
+		current := h.snapshot()
+		if len(current) > cfg.Size {
+			current = current[len(current)-cfg.Size:]
+		}
+		h.entries = make([]HistoryEntry, cfg.Size)
+		copy(h.entries, current)
+		h.next = len(current) % cfg.Size
+		h.full = len(current) == cfg.Size
+	}
+
+	if cfg.File != h.cfg.File {
+		h.closeFile()
+		h.cfg = cfg
+		if cfg.File != "" {
+			if err := h.openFile(); err != nil {
+				return err
+			}
+		}
+	}
+	h.cfg = cfg
+
+	return nil
+}
+
+// Add fügt einen Eintrag hinzu und schreibt ihn ggf. in die Datei
+func (h *History) Add(entry HistoryEntry) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.entries[h.next] = entry
+	h.next = (h.next + 1) % len(h.entries)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	if h.file == nil {
+		return nil
+	}
+	return h.writeFile(entry)
+}
+
+// Entries gibt die Einträge vom ältesten zum neuesten zurück
+func (h *History) Entries(filter HistoryFilter) []HistoryEntry {
+	h.mutex.Lock()
+	all := h.snapshot()
+	h.mutex.Unlock()
+
+	entries := make([]HistoryEntry, 0, len(all))
+	for _, entry := range all {
+		if filter.Mapping != "" && entry.Mapping != filter.Mapping {
+			continue
+		}
+		if filter.ErrorsOnly && entry.Error == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries
+}
+
+// Close schließt die Verlaufsdatei
+func (h *History) Close() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.closeFile()
+}
+
+// snapshot kopiert den Ringpuffer in zeitlicher Reihenfolge (Mutex muss gehalten werden)
+func (h *History) snapshot() []HistoryEntry {
+	if !h.full {
+		return append([]HistoryEntry(nil), h.entries[:h.next]...)
+	}
+	entries := make([]HistoryEntry, 0, len(h.entries))
+	entries = append(entries, h.entries[h.next:]...)
+	return append(entries, h.entries[:h.next]...)
+}
+
+// openFile öffnet die Verlaufsdatei zum Anhängen (Mutex muss gehalten werden)
+func (h *History) openFile() error {
+	file, err := os.OpenFile(h.cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("fehler beim Öffnen der Verlaufsdatei: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("fehler beim Lesen der Verlaufsdatei: %w", err)
+	}
+	h.file = file
+	h.written = info.Size()
+	return nil
+}
+
+// closeFile schließt die Verlaufsdatei (Mutex muss gehalten werden)
+func (h *History) closeFile() error {
+	if h.file == nil {
+		return nil
+	}
+	err := h.file.Close()
+	h.file = nil
+	return err
+}
+
+// writeFile hängt einen Eintrag als JSON-Zeile an und rotiert die Datei bei Bedarf (Mutex muss gehalten werden)
+func (h *History) writeFile(entry HistoryEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("fehler beim Serialisieren des Verlaufseintrags: %w", err)
+	}
+	line = append(line, '\n')
+
+	// Schlägt das Rotieren fehl, wird in die bisherige Datei weitergeschrieben und der Fehler gemeldet
+	var rotateErr error
+	if limit := int64(h.cfg.MaxFileSize) * 1024; limit > 0 && h.written > 0 && h.written+int64(len(line)) > limit {
+		if rotateErr = h.rotate(); h.file == nil {
+			return rotateErr
+		}
+	}
+
+	n, err := h.file.Write(line)
+	h.written += int64(n)
+	if err != nil {
+		return fmt.Errorf("fehler beim Schreiben der Verlaufsdatei: %w", err)
+	}
+	return rotateErr
+}
+
+// rotate benennt die Verlaufsdatei in <datei>.1 um und verschiebt ältere Dateien. Schlägt ein Schritt
+// fehl, wird die bisherige Datei wieder geöffnet, damit der Verlauf weiter geschrieben wird (Mutex muss gehalten werden).
+func (h *History) rotate() error {
+	if err := h.closeFile(); err != nil {
+		return h.reopenFile(fmt.Errorf("fehler beim Schließen der Verlaufsdatei: %w", err))
+	}
+
+	path := h.cfg.File
+	keep := h.cfg.MaxFiles
+	var err error
+	if keep <= 0 {
+		err = removeIfExists(path)
+	} else {
+		err = removeIfExists(fmt.Sprintf("%s.%d", path, keep))
+		for i := keep - 1; i >= 1 && err == nil; i-- {
+			err = renameIfExists(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+		}
+		if err == nil {
+			err = os.Rename(path, path+".1")
+		}
+	}
+	if err != nil {
+		return h.reopenFile(fmt.Errorf("fehler beim Rotieren der Verlaufsdatei: %w", err))
+	}
+
+	return h.openFile()
+}
+
+// reopenFile öffnet die Verlaufsdatei nach einem fehlgeschlagenen Rotieren erneut und gibt den Fehler zurück
+func (h *History) reopenFile(err error) error {
+	if openErr := h.openFile(); openErr != nil {
+		return fmt.Errorf("%w (erneutes Öffnen fehlgeschlagen: %v)", err, openErr)
+	}
+	return err
+}
+
+// removeIfExists löscht eine Datei; eine fehlende Datei ist kein Fehler
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// renameIfExists benennt eine Datei um; eine fehlende Datei ist kein Fehler
+func renameIfExists(from, to string) error {
+	if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// newEventRecord übernimmt die Event-Daten eines Template-Kontexts
+func newEventRecord(data config.TemplateData) EventRecord {
+	return EventRecord{
+		Type:       data.Type,
+		Port:       data.Port,
+		Channel:    data.Channel,
+		Note:       data.Note,
+		Velocity:   data.Velocity,
+		Controller: data.Controller,
+		Program:    data.Program,
+		Value:      data.Value,
+		Layer:      data.Layer,
+	}
+}
@@ -0,0 +1,102 @@
+package actions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+func TestHistoryRingBufferAndFilter(t *testing.T) {
+	history, err := NewHistory(config.HistoryConfig{Size: 3})
+	if err != nil {
+		t.Fatalf("NewHistory: %v", err)
+	}
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		entry := HistoryEntry{Mapping: name, Action: "volume"}
+		if name == "c" {
+			entry.Error = "fehlgeschlagen"
+		}
+		history.Add(entry)
+	}
+
+	entries := history.Entries(HistoryFilter{})
+	if len(entries) != 3 || entries[0].Mapping != "b" || entries[2].Mapping != "d" {
+		t.Fatalf("expected b, c, d in order, got %+v", entries)
+	}
+	if errs := history.Entries(HistoryFilter{ErrorsOnly: true}); len(errs) != 1 || errs[0].Mapping != "c" {
+		t.Fatalf("expected only c as error, got %+v", errs)
+	}
+	if last := history.Entries(HistoryFilter{Limit: 1}); len(last) != 1 || last[0].Mapping != "d" {
+		t.Fatalf("expected newest entry d, got %+v", last)
+	}
+
+	// Verkleinern behält die neuesten Einträge
+	history.Reconfigure(config.HistoryConfig{Size: 2})
+	if entries := history.Entries(HistoryFilter{}); len(entries) != 2 || entries[0].Mapping != "c" {
+		t.Fatalf("expected c, d after resize, got %+v", entries)
+	}
+}
+
+func TestHistoryFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	history, err := NewHistory(config.HistoryConfig{Size: 10, File: path, MaxFileSize: 1, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("NewHistory: %v", err)
+	}
+	defer history.Close()
+
+	params := map[string]interface{}{"text": strings.Repeat("x", 300)}
+	for i := 0; i < 10; i++ {
+		if err := history.Add(HistoryEntry{Mapping: "m", Action: "key_combination", Parameters: params}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+		if info.Size() > 1024 {
+			t.Fatalf("%s exceeds max size: %d", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected at most 2 rotated files")
+	}
+}
+
+func TestHistoryRotationFailureKeepsWriting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	history, err := NewHistory(config.HistoryConfig{Size: 10, File: path, MaxFileSize: 1, MaxFiles: 1})
+	if err != nil {
+		t.Fatalf("NewHistory: %v", err)
+	}
+	defer history.Close()
+
+	// Ein nicht leeres Verzeichnis an Stelle von <datei>.1 lässt das Rotieren fehlschlagen
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocked"), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	params := map[string]interface{}{"text": strings.Repeat("x", 600)}
+	if err := history.Add(HistoryEntry{Mapping: "m", Action: "key_combination", Parameters: params}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := history.Add(HistoryEntry{Mapping: "m", Action: "key_combination", Parameters: params}); err == nil {
+		t.Fatalf("expected rotation error")
+	}
+	history.Add(HistoryEntry{Mapping: "m", Action: "key_combination", Parameters: params})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Fatalf("expected 3 entries after failed rotation, got %d", lines)
+	}
+}
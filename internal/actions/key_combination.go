@@ -4,6 +4,7 @@
 package actions
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 	"strings"
@@ -21,10 +22,10 @@ type KeyCombinationExecutor struct {
 
 // KeyboardController definiert die Schnittstelle für plattformspezifische Tastatureingaben
 type KeyboardController interface {
-	SendKey(key string) error
-	SendKeyCombination(keys []string) error
-	SendText(text string) error
-	HoldKey(key string, duration time.Duration) error
+	SendKey(ctx context.Context, key string) error
+	SendKeyCombination(ctx context.Context, keys []string) error
+	SendText(ctx context.Context, text string) error
+	HoldKey(ctx context.Context, key string, duration time.Duration) error
 }
 
 // NewKeyCombinationExecutor erstellt einen neuen Key-Combination-Executor
@@ -44,13 +45,13 @@ func NewKeyCombinationExecutor(logger utils.Logger) (*KeyCombinationExecutor, er
 }
 
 // Execute führt eine Key-Combination-Aktion aus
-func (e *KeyCombinationExecutor) Execute(action config.Action) error {
+func (e *KeyCombinationExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
 	e.LogDebug("Führe Key-Combination-Aktion aus", "parameters", action.Parameters)
 
 	// Keys-Parameter extrahieren
 	keys, ok := action.Parameters["keys"]
 	if !ok {
-		return fmt.Errorf("key_combination-Aktion benötigt 'keys' Parameter")
+		return Result{}, fmt.Errorf("key_combination-Aktion benötigt 'keys' Parameter")
 	}
 
 	var keyList []string
@@ -70,11 +71,11 @@ func (e *KeyCombinationExecutor) Execute(action config.Action) error {
 			keyList[i] = strings.TrimSpace(key)
 		}
 	default:
-		return fmt.Errorf("ungültiger 'keys' Parameter: %v", keys)
+		return Result{}, fmt.Errorf("ungültiger 'keys' Parameter: %v", keys)
 	}
 
 	if len(keyList) == 0 {
-		return fmt.Errorf("'keys' Parameter darf nicht leer sein")
+		return Result{}, fmt.Errorf("'keys' Parameter darf nicht leer sein")
 	}
 
 	// Verzögerung zwischen Tasten extrahieren (optional)
@@ -104,24 +105,27 @@ func (e *KeyCombinationExecutor) Execute(action config.Action) error {
 	switch actionType {
 	case "combination":
 		e.LogInfo("Sende Tastenkombination", "keys", keyList, "delay", delay)
-		return e.keyboard.SendKeyCombination(keyList)
+		if err := e.keyboard.SendKeyCombination(ctx, keyList); err != nil {
+			return Result{}, err
+		}
 
 	case "sequence":
 		e.LogInfo("Sende Tastensequenz", "keys", keyList, "delay", delay)
 		for i, key := range keyList {
-			if err := e.keyboard.SendKey(key); err != nil {
-				return fmt.Errorf("fehler beim Senden der Taste '%s': %w", key, err)
+			if err := e.keyboard.SendKey(ctx, key); err != nil {
+				return Result{}, fmt.Errorf("fehler beim Senden der Taste '%s': %w", key, err)
 			}
 			if i < len(keyList)-1 && delay > 0 {
-				time.Sleep(delay)
+				if err := sleepContext(ctx, delay); err != nil {
+					return Result{}, fmt.Errorf("tastensequenz abgebrochen nach '%s': %w", key, err)
+				}
 			}
 		}
-		return nil
 
 	case "hold":
 		// Taste gedrückt halten
 		if len(keyList) != 1 {
-			return fmt.Errorf("'hold' Typ benötigt genau eine Taste")
+			return Result{}, fmt.Errorf("'hold' Typ benötigt genau eine Taste")
 		}
 		holdDuration := 1 * time.Second // Standard: 1 Sekunde
 		if durationParam, ok := action.Parameters["duration"]; ok {
@@ -137,17 +141,31 @@ func (e *KeyCombinationExecutor) Execute(action config.Action) error {
 			}
 		}
 		e.LogInfo("Halte Taste gedrückt", "key", keyList[0], "duration", holdDuration)
-		return e.keyboard.HoldKey(keyList[0], holdDuration)
+		if err := e.keyboard.HoldKey(ctx, keyList[0], holdDuration); err != nil {
+			return Result{}, err
+		}
 
 	case "text":
-		// Text eingeben
-		text := strings.Join(keyList, "")
-		e.LogInfo("Gebe Text ein", "text", text)
-		return e.keyboard.SendText(text)
+		// Text mit Sondertasten ("{ENTER}") eingeben
+		text := textParameter(action, keyList)
+		segments, err := parseTextInput(text)
+		if err != nil {
+			return Result{}, fmt.Errorf("ungültiger Text: %w", err)
+		}
+		charDelay, err := millisecondsParameter(action, "char_delay", 0)
+		if err != nil {
+			return Result{}, err
+		}
+		e.LogInfo("Gebe Text ein", "text", text, "char_delay", charDelay)
+		if err := typeText(ctx, e.keyboard, segments, charDelay); err != nil {
+			return Result{}, err
+		}
 
 	default:
-		return fmt.Errorf("ungültiger Aktionstyp: %s (erwartet: combination, sequence, hold, text)", actionType)
+		return Result{}, fmt.Errorf("ungültiger Aktionstyp: %s (erwartet: combination, sequence, hold, text)", actionType)
 	}
+
+	return Result{Changed: true}, nil
 }
 
 // Validate überprüft eine Key-Combination-Aktion auf Gültigkeit
@@ -171,7 +189,9 @@ func (e *KeyCombinationExecutor) Validate(action config.Action) error {
 	case []string:
 		keyList = v
 	case string:
-		// OK
+		for _, key := range strings.Split(v, ",") {
+			keyList = append(keyList, strings.TrimSpace(key))
+		}
 	default:
 		return fmt.Errorf("ungültiger 'keys' Parameter: %v", keys)
 	}
@@ -180,10 +200,19 @@ func (e *KeyCombinationExecutor) Validate(action config.Action) error {
 		return fmt.Errorf("'keys' Parameter darf nicht leer sein")
 	}
 
-	// Tasten validieren
-	for i, key := range keyList {
-		if err := e.validateKey(key); err != nil {
-			return fmt.Errorf("ungültige Taste %d: %w", i, err)
+	// Tasten validieren; Texte dürfen beliebige Zeichen und Sondertasten in Klammern enthalten
+	if action.Parameters["type"] == "text" {
+		if err := e.validateText(textParameter(action, keyList)); err != nil {
+			return fmt.Errorf("ungültiger Text: %w", err)
+		}
+		if _, err := millisecondsParameter(action, "char_delay", 0); err != nil {
+			return err
+		}
+	} else {
+		for i, key := range keyList {
+			if err := e.validateKey(key); err != nil {
+				return fmt.Errorf("ungültige Taste %d: %w", i, err)
+			}
 		}
 	}
 
@@ -271,63 +300,23 @@ func (e *KeyCombinationExecutor) validateKey(key string) error {
 
 // validateWindowsKey überprüft eine Windows-Taste
 func (e *KeyCombinationExecutor) validateWindowsKey(key string) error {
-	// Gültige Windows-Tasten
-	validKeys := map[string]bool{
-		// Funktionstasten
-		"F1": true, "F2": true, "F3": true, "F4": true, "F5": true, "F6": true,
-		"F7": true, "F8": true, "F9": true, "F10": true, "F11": true, "F12": true,
-		// Modifier-Tasten
-		"CTRL": true, "ALT": true, "SHIFT": true, "WIN": true,
-		// Navigation
-		"UP": true, "DOWN": true, "LEFT": true, "RIGHT": true,
-		"HOME": true, "END": true, "PAGEUP": true, "PAGEDOWN": true,
-		// Andere
-		"ENTER": true, "ESC": true, "TAB": true, "SPACE": true,
-		"BACKSPACE": true, "DELETE": true, "INSERT": true,
-		// Buchstaben und Zahlen sind auch gültig
-	}
-
-	// Einzelne Buchstaben und Zahlen sind gültig
-	if len(key) == 1 {
-		return nil
-	}
-
-	// Spezielle Tasten überprüfen
-	if !validKeys[strings.ToUpper(key)] {
-		return fmt.Errorf("ungültige Taste: %s", key)
-	}
-
-	return nil
+	return validatePlatformKey(key, "windows")
 }
 
 // validateLinuxKey überprüft eine Linux-Taste
 func (e *KeyCombinationExecutor) validateLinuxKey(key string) error {
-	// Gültige Linux-Tasten (X11)
-	validKeys := map[string]bool{
-		// Funktionstasten
-		"F1": true, "F2": true, "F3": true, "F4": true, "F5": true, "F6": true,
-		"F7": true, "F8": true, "F9": true, "F10": true, "F11": true, "F12": true,
-		// Modifier-Tasten
-		"CTRL": true, "ALT": true, "SHIFT": true, "SUPER": true,
-		// Navigation
-		"UP": true, "DOWN": true, "LEFT": true, "RIGHT": true,
-		"HOME": true, "END": true, "PAGEUP": true, "PAGEDOWN": true,
-		// Andere
-		"ENTER": true, "ESC": true, "TAB": true, "SPACE": true,
-		"BACKSPACE": true, "DELETE": true, "INSERT": true,
-		// Buchstaben und Zahlen sind auch gültig
-	}
+	return validatePlatformKey(key, "linux")
+}
 
-	// Einzelne Buchstaben und Zahlen sind gültig
-	if len(key) == 1 {
-		return nil
+// validatePlatformKey prüft eine Taste gegen das Tastenvokabular und ihre Verfügbarkeit auf der Plattform
+func validatePlatformKey(key, goos string) error {
+	def, err := lookupKey(key)
+	if err != nil {
+		return err
 	}
-
-	// Spezielle Tasten überprüfen
-	if !validKeys[strings.ToUpper(key)] {
-		return fmt.Errorf("ungültige Taste: %s", key)
+	if !def.availableOn(goos) {
+		return fmt.Errorf("taste %s ist unter %s nicht verfügbar", key, goos)
 	}
-
 	return nil
 }
 
@@ -350,14 +339,20 @@ func newWindowsKeyboardController() (KeyboardController, error) {
 	return &windowsKeyboardController{}, nil
 }
 
-func (c *windowsKeyboardController) SendKey(key string) error {
+func (c *windowsKeyboardController) SendKey(ctx context.Context, key string) error {
+	if _, err := windowsVirtualKeys([]string{key}); err != nil {
+		return err
+	}
 	// TODO: Implementierung mit Windows API
 	// - keybd_event oder SendInput verwenden
 	// - Taste drücken und loslassen
 	return nil
 }
 
-func (c *windowsKeyboardController) SendKeyCombination(keys []string) error {
+func (c *windowsKeyboardController) SendKeyCombination(ctx context.Context, keys []string) error {
+	if _, err := windowsVirtualKeys(keys); err != nil {
+		return err
+	}
 	// TODO: Implementierung mit Windows API
 	// - Alle Modifier-Tasten drücken
 	// - Haupttaste drücken und loslassen
@@ -365,47 +360,33 @@ func (c *windowsKeyboardController) SendKeyCombination(keys []string) error {
 	return nil
 }
 
-func (c *windowsKeyboardController) SendText(text string) error {
+func (c *windowsKeyboardController) SendText(ctx context.Context, text string) error {
 	// TODO: Implementierung mit Windows API
 	// - Jedes Zeichen einzeln senden
 	return nil
 }
 
-func (c *windowsKeyboardController) HoldKey(key string, duration time.Duration) error {
+func (c *windowsKeyboardController) HoldKey(ctx context.Context, key string, duration time.Duration) error {
+	if _, err := windowsVirtualKeys([]string{key}); err != nil {
+		return err
+	}
 	// TODO: Implementierung mit Windows API
 	// - Taste drücken
-	// - Warten
+	// - Warten (bis duration abgelaufen oder ctx abgebrochen)
 	// - Taste loslassen
 	return nil
 }
 
-// Linux-spezifische Keyboard-Controller-Implementierung
-type linuxKeyboardController struct{}
-
-func newLinuxKeyboardController() (KeyboardController, error) {
-	return &linuxKeyboardController{}, nil
-}
-
-func (c *linuxKeyboardController) SendKey(key string) error {
-	// TODO: Implementierung mit X11 oder uinput
-	// - XTestFakeKeyEvent verwenden
-	return nil
-}
-
-func (c *linuxKeyboardController) SendKeyCombination(keys []string) error {
-	// TODO: Implementierung mit X11 oder uinput
-	// - Alle Tasten in der richtigen Reihenfolge senden
-	return nil
-}
-
-func (c *linuxKeyboardController) SendText(text string) error {
-	// TODO: Implementierung mit X11 oder uinput
-	// - Jedes Zeichen einzeln senden
-	return nil
+// windowsVirtualKeys löst Tasten über das Tastenvokabular in virtuelle Tastencodes auf.
+// Einzelne Zeichen haben den Code 0 und müssen über VkKeyScan aufgelöst werden.
+func windowsVirtualKeys(keys []string) ([]uint16, error) {
+	codes := make([]uint16, 0, len(keys))
+	for _, key := range keys {
+		if err := validatePlatformKey(key, "windows"); err != nil {
+			return nil, err
+		}
+		def, _ := lookupKey(key)
+		codes = append(codes, def.vk)
+	}
+	return codes, nil
 }
-
-func (c *linuxKeyboardController) HoldKey(key string, duration time.Duration) error {
-	// TODO: Implementierung mit X11 oder uinput
-	// - Taste drücken, warten, loslassen
-	return nil
-} 
\ No newline at end of file
@@ -0,0 +1,158 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält die Tastaturbelegungen für Backends ohne Zugriff auf die Belegung des Systems (uinput).
+
+package actions
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// evdevStroke beschreibt, wie ein Zeichen auf einer Belegung getippt wird
+type evdevStroke struct {
+	code  uint16
+	shift bool
+	altGr bool
+}
+
+// evdevLayout ordnet Zeichen den Tasten einer Belegung zu
+type evdevLayout map[rune]evdevStroke
+
+// Modifier für Zeichen höherer Ebenen
+const (
+	evdevLeftCtrl  = 29  // KEY_LEFTCTRL
+	evdevLeftShift = 42  // KEY_LEFTSHIFT
+	evdevRightAlt  = 100 // KEY_RIGHTALT (AltGr)
+)
+
+// evdevCommonKeys sind die Zeichen, die auf allen Belegungen auf denselben Tasten liegen
+var evdevCommonKeys = map[rune]uint16{
+	'1': 2, '2': 3, '3': 4, '4': 5, '5': 6, '6': 7, '7': 8, '8': 9, '9': 10, '0': 11,
+	'q': 16, 'w': 17, 'e': 18, 'r': 19, 't': 20, 'u': 22, 'i': 23, 'o': 24, 'p': 25,
+	'a': 30, 's': 31, 'd': 32, 'f': 33, 'g': 34, 'h': 35, 'j': 36, 'k': 37, 'l': 38,
+	'x': 45, 'c': 46, 'v': 47, 'b': 48, 'n': 49, 'm': 50,
+	' ': 57, '\n': 28, '\r': 28, '\t': 15, '\b': 14,
+}
+
+// evdevLayouts enthält die unterstützten Belegungen nach XKB-Namen
+var evdevLayouts = map[string]evdevLayout{
+	"us": newEvdevLayout(
+		map[rune]uint16{
+			'y': 21, 'z': 44,
+			'-': 12, '=': 13, '[': 26, ']': 27, ';': 39, '\'': 40, '`': 41, '\\': 43, ',': 51, '.': 52, '/': 53,
+		},
+		map[rune]uint16{
+			'!': 2, '@': 3, '#': 4, '$': 5, '%': 6, '^': 7, '&': 8, '*': 9, '(': 10, ')': 11,
+			'_': 12, '+': 13, '{': 26, '}': 27, ':': 39, '"': 40, '~': 41, '|': 43, '<': 51, '>': 52, '?': 53,
+		},
+		nil,
+	),
+	// Deutsche Belegung ohne Tottasten (^, ´ und ` werden über die Unicode-Eingabe getippt)
+	"de": newEvdevLayout(
+		map[rune]uint16{
+			'z': 21, 'y': 44,
+			'ß': 12, 'ü': 26, '+': 27, 'ö': 39, 'ä': 40, '#': 43, '<': 86, ',': 51, '.': 52, '-': 53,
+		},
+		map[rune]uint16{
+			'!': 2, '"': 3, '§': 4, '$': 5, '%': 6, '&': 7, '/': 8, '(': 9, ')': 10, '=': 11,
+			'?': 12, '*': 27, '\'': 43, '>': 86, ';': 51, ':': 52, '_': 53, '°': 41,
+		},
+		map[rune]uint16{
+			'²': 3, '³': 4, '{': 8, '[': 9, ']': 10, '}': 11, '\\': 12,
+			'@': 16, '€': 18, '~': 27, '|': 86, 'µ': 50,
+		},
+	),
+}
+
+// newEvdevLayout baut eine Belegung aus den gemeinsamen Tasten und den Zeichen der Ebenen.
+// Großbuchstaben werden aus den Kleinbuchstaben der Grundebene abgeleitet.
+func newEvdevLayout(base, shifted, altGr map[rune]uint16) evdevLayout {
+	layout := evdevLayout{}
+	for _, keys := range []map[rune]uint16{evdevCommonKeys, base} {
+		for r, code := range keys {
+			layout[r] = evdevStroke{code: code}
+			if upper := unicode.ToUpper(r); upper != r {
+				layout[upper] = evdevStroke{code: code, shift: true}
+			}
+		}
+	}
+	for r, code := range shifted {
+		layout[r] = evdevStroke{code: code, shift: true}
+	}
+	for r, code := range altGr {
+		layout[r] = evdevStroke{code: code, altGr: true}
+	}
+	return layout
+}
+
+// codes gibt die Tasten zurück, die für ein Zeichen gedrückt werden
+func (s evdevStroke) codes() []uint16 {
+	var codes []uint16
+	if s.altGr {
+		codes = append(codes, evdevRightAlt)
+	}
+	if s.shift {
+		codes = append(codes, evdevLeftShift)
+	}
+	return append(codes, s.code)
+}
+
+// keyboardLayoutFiles sind die Dateien, in denen Distributionen die Tastaturbelegung ablegen
+var keyboardLayoutFiles = []string{
+	"/etc/default/keyboard",                 // Debian, Ubuntu
+	"/etc/vconsole.conf",                    // systemd (Fedora, Arch)
+	"/etc/X11/xorg.conf.d/00-keyboard.conf", // localectl
+}
+
+// resolveKeyboardLayout wählt die Belegung anhand von $XKB_DEFAULT_LAYOUT bzw. der Systemkonfiguration.
+// Unbekannte Belegungen fallen auf "us" zurück.
+func resolveKeyboardLayout(getenv func(string) string, readFile func(string) ([]byte, error)) (string, evdevLayout) {
+	name := firstLayout(getenv("XKB_DEFAULT_LAYOUT"))
+	for _, path := range keyboardLayoutFiles {
+		if name != "" {
+			break
+		}
+		if data, err := readFile(path); err == nil {
+			name = parseLayoutFile(data)
+		}
+	}
+	if layout, ok := evdevLayouts[name]; ok {
+		return name, layout
+	}
+	return "us", evdevLayouts["us"]
+}
+
+// parseLayoutFile liest die Belegung aus XKBLAYOUT=, KEYMAP= oder Option "XkbLayout"
+func parseLayoutFile(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	keymap := ""
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "XKBLAYOUT="):
+			return firstLayout(strings.TrimPrefix(line, "XKBLAYOUT="))
+		case strings.HasPrefix(line, "KEYMAP="):
+			// Konsolen-Keymaps wie "de-latin1-nodeadkeys"
+			keymap, _, _ = strings.Cut(strings.Trim(strings.TrimPrefix(line, "KEYMAP="), `"'`), "-")
+		case strings.HasPrefix(line, "Option") && strings.Contains(line, `"XkbLayout"`):
+			fields := strings.Fields(line)
+			return firstLayout(fields[len(fields)-1])
+		}
+	}
+	return firstLayout(keymap)
+}
+
+// firstLayout gibt die erste Belegung einer Liste wie "de,us" zurück
+func firstLayout(value string) string {
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	first, _, _ := strings.Cut(value, ",")
+	return strings.ToLower(strings.TrimSpace(first))
+}
+
+// defaultKeyboardLayout ermittelt die Belegung des Systems
+func defaultKeyboardLayout() (string, evdevLayout) {
+	return resolveKeyboardLayout(os.Getenv, os.ReadFile)
+}
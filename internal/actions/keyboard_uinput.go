@@ -0,0 +1,238 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält den Keyboard-Controller für uinput (Wayland und Konsole) und die Auswahl des Linux-Backends.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Xcruser/MidiDaemon/internal/uinput"
+)
+
+// Keyboard-Backends unter Linux
+const (
+	keyboardBackendX11    = "x11"
+	keyboardBackendUinput = "uinput"
+)
+
+// keyboardSessionBackend wählt das Backend anhand der Sitzung: X11-Sitzungen nutzen XTEST,
+// Wayland und die Konsole uinput. Unter Wayland erreicht XTEST nur XWayland-Programme.
+func keyboardSessionBackend(getenv func(string) string) string {
+	switch strings.ToLower(getenv("XDG_SESSION_TYPE")) {
+	case "x11":
+		return keyboardBackendX11
+	case "wayland", "tty":
+		return keyboardBackendUinput
+	}
+	if getenv("WAYLAND_DISPLAY") == "" && getenv("DISPLAY") != "" {
+		return keyboardBackendX11
+	}
+	return keyboardBackendUinput
+}
+
+// newLinuxKeyboardController erstellt den Keyboard-Controller für die aktuelle Sitzung
+func newLinuxKeyboardController() (KeyboardController, error) {
+	if keyboardSessionBackend(os.Getenv) == keyboardBackendX11 {
+		return newX11KeyboardController(), nil
+	}
+	return newUinputKeyboardController(), nil
+}
+
+// keyDevice ist ein Gerät, über das evdev-Tastenereignisse gesendet werden
+type keyDevice interface {
+	Key(code uint16, press bool) error
+	Close() error
+}
+
+// uinputKeyboardController simuliert Tastatureingaben über ein virtuelles uinput-Gerät.
+// Da uinput unterhalb der Tastaturbelegung arbeitet, werden Zeichen über die Belegung des Systems übersetzt.
+type uinputKeyboardController struct {
+	open   func() (keyDevice, error)
+	layout evdevLayout
+	device keyDevice
+	mutex  sync.Mutex
+}
+
+// newUinputKeyboardController erstellt einen Controller, der das virtuelle Gerät erst bei der
+// ersten Eingabe anlegt, damit fehlende Berechtigungen den Start des Daemons nicht verhindern
+func newUinputKeyboardController() *uinputKeyboardController {
+	_, layout := defaultKeyboardLayout()
+	return &uinputKeyboardController{
+		open: func() (keyDevice, error) {
+			return uinput.Open("MidiDaemon Keyboard")
+		},
+		layout: layout,
+	}
+}
+
+// SendKey drückt eine Taste und lässt sie wieder los
+func (c *uinputKeyboardController) SendKey(ctx context.Context, key string) error {
+	return c.SendKeyCombination(ctx, []string{key})
+}
+
+// SendKeyCombination drückt alle Tasten in der angegebenen Reihenfolge und lässt sie
+// in umgekehrter Reihenfolge wieder los
+func (c *uinputKeyboardController) SendKeyCombination(ctx context.Context, keys []string) error {
+	codes, err := c.keyCodes(keys)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	device, err := c.get()
+	if err != nil {
+		return err
+	}
+	return pressEvdevKeys(ctx, device, codes, 0)
+}
+
+// SendText tippt einen Text Zeichen für Zeichen. Zeichen, die die Belegung nicht enthält,
+// werden über die Unicode-Eingabe von GTK/IBus (Strg+Umschalt+U, Hex-Code, Leertaste) getippt.
+func (c *uinputKeyboardController) SendText(ctx context.Context, text string) error {
+	var strokes [][][]uint16
+	for _, r := range text {
+		keys, err := c.charStrokes(r)
+		if err != nil {
+			return err
+		}
+		strokes = append(strokes, keys)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	device, err := c.get()
+	if err != nil {
+		return err
+	}
+	for _, keys := range strokes {
+		for _, codes := range keys {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := pressEvdevKeys(ctx, device, codes, 0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// charStrokes gibt die Tastenanschläge für ein Zeichen zurück
+func (c *uinputKeyboardController) charStrokes(r rune) ([][]uint16, error) {
+	if stroke, ok := c.layout[r]; ok {
+		return [][]uint16{stroke.codes()}, nil
+	}
+	if r < 0x20 || r == 0x7f {
+		return nil, fmt.Errorf("zeichen %q kann nicht getippt werden", r)
+	}
+	strokes := [][]uint16{{evdevLeftCtrl, evdevLeftShift, c.layout['u'].code}}
+	for _, digit := range strconv.FormatInt(int64(r), 16) {
+		stroke, ok := c.layout[digit]
+		if !ok {
+			return nil, fmt.Errorf("zeichen %q kann über uinput nicht getippt werden", r)
+		}
+		strokes = append(strokes, stroke.codes())
+	}
+	return append(strokes, []uint16{c.layout[' '].code}), nil
+}
+
+// HoldKey hält eine Taste für die angegebene Dauer gedrückt. Die Taste wird auch bei
+// Abbruch des Context wieder losgelassen.
+func (c *uinputKeyboardController) HoldKey(ctx context.Context, key string, duration time.Duration) error {
+	codes, err := c.keyCodes([]string{key})
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	device, err := c.get()
+	if err != nil {
+		return err
+	}
+	return pressEvdevKeys(ctx, device, codes, duration)
+}
+
+// get gibt das virtuelle Gerät zurück und legt es bei Bedarf an
+func (c *uinputKeyboardController) get() (keyDevice, error) {
+	if c.device != nil {
+		return c.device, nil
+	}
+	device, err := c.open()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Anlegen der virtuellen Tastatur: %w", err)
+	}
+	c.device = device
+	return device, nil
+}
+
+// pressEvdevKeys drückt alle Tasten, wartet hold ab und lässt sie in umgekehrter Reihenfolge los.
+// Das Loslassen geschieht auch nach Fehlern, damit keine Taste hängen bleibt.
+func pressEvdevKeys(ctx context.Context, device keyDevice, codes []uint16, hold time.Duration) error {
+	var errs []error
+	pressed := 0
+	for _, code := range codes {
+		if err := device.Key(code, true); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		pressed++
+	}
+	if len(errs) == 0 && hold > 0 {
+		if err := sleepContext(ctx, hold); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i := pressed - 1; i >= 0; i-- {
+		if err := device.Key(codes[i], false); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("fehler beim Senden der Tasten: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// keyCodes ermittelt die evdev-Codes einer Tastenliste
+func (c *uinputKeyboardController) keyCodes(keys []string) ([]uint16, error) {
+	codes := make([]uint16, 0, len(keys))
+	for _, key := range keys {
+		code, err := evdevKeyCode(c.layout, key)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// evdevKeyCode gibt den evdev-Code einer Taste zurück. Einzelne Zeichen stehen für die
+// Taste, auf der sie in der Belegung liegen (Modifier werden nicht mitgedrückt).
+func evdevKeyCode(layout evdevLayout, key string) (uint16, error) {
+	def, err := lookupKey(key)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case def.char != 0:
+		if stroke, ok := layout[unicode.ToLower(def.char)]; ok {
+			return stroke.code, nil
+		}
+		return 0, fmt.Errorf("taste %s ist in der Tastaturbelegung nicht vorhanden", key)
+	case def.evdev != 0:
+		return def.evdev, nil
+	case def.keycode != 0:
+		return uint16(def.keycode) - 8, nil
+	}
+	return 0, fmt.Errorf("taste %s ist unter Linux nicht verfügbar", key)
+}
@@ -0,0 +1,159 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// fakeKeyDevice zeichnet evdev-Tastenereignisse auf
+type fakeKeyDevice struct {
+	events []string
+}
+
+func (d *fakeKeyDevice) Key(code uint16, press bool) error {
+	if press {
+		d.events = append(d.events, fmt.Sprintf("+%d", code))
+	} else {
+		d.events = append(d.events, fmt.Sprintf("-%d", code))
+	}
+	return nil
+}
+
+func (d *fakeKeyDevice) Close() error {
+	return nil
+}
+
+func newFakeUinputKeyboard() (*uinputKeyboardController, *fakeKeyDevice) {
+	device := &fakeKeyDevice{}
+	controller := &uinputKeyboardController{
+		open:   func() (keyDevice, error) { return device, nil },
+		layout: evdevLayouts["us"],
+	}
+	return controller, device
+}
+
+func TestKeyboardSessionBackend(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"XDG_SESSION_TYPE": "x11", "DISPLAY": ":0"}, keyboardBackendX11},
+		{map[string]string{"XDG_SESSION_TYPE": "wayland", "DISPLAY": ":0", "WAYLAND_DISPLAY": "wayland-0"}, keyboardBackendUinput},
+		{map[string]string{"XDG_SESSION_TYPE": "tty"}, keyboardBackendUinput},
+		{map[string]string{"DISPLAY": ":1"}, keyboardBackendX11},
+		{map[string]string{"DISPLAY": ":1", "WAYLAND_DISPLAY": "wayland-1"}, keyboardBackendUinput},
+		{map[string]string{}, keyboardBackendUinput},
+	}
+	for _, test := range tests {
+		getenv := func(name string) string { return test.env[name] }
+		if got := keyboardSessionBackend(getenv); got != test.want {
+			t.Fatalf("keyboardSessionBackend(%v) = %s, want %s", test.env, got, test.want)
+		}
+	}
+}
+
+func TestUinputGermanLayout(t *testing.T) {
+	controller, device := newFakeUinputKeyboard()
+	controller.layout = evdevLayouts["de"]
+
+	if err := controller.SendText(context.Background(), "yZ@ß"); err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+	if got := fmt.Sprint(device.events); got != "[+44 -44 +42 +21 -21 -42 +100 +16 -16 -100 +12 -12]" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+	device.events = nil
+	if err := controller.SendKeyCombination(context.Background(), []string{"CTRL", "Z"}); err != nil {
+		t.Fatalf("SendKeyCombination: %v", err)
+	}
+	if got := fmt.Sprint(device.events); got != "[+29 +21 -21 -29]" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+
+	// Medientasten, rechte Modifier, Maustasten und rohe Codes
+	device.events = nil
+	if err := controller.SendKeyCombination(context.Background(), []string{"RCtrl", "PlayPause", "mouse_left", "CODE:0x2a0", "X11:150"}); err != nil {
+		t.Fatalf("SendKeyCombination: %v", err)
+	}
+	if got := fmt.Sprint(device.events); got != "[+97 +164 +272 +672 +142 -142 -672 -272 -164 -97]" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+	if err := controller.SendKey(context.Background(), "VK:0x41"); err == nil {
+		t.Fatalf("expected error for windows-only key")
+	}
+}
+
+func TestResolveKeyboardLayout(t *testing.T) {
+	files := map[string]string{
+		"/etc/vconsole.conf": "KEYMAP=de-latin1-nodeadkeys\nFONT=eurlatgr\n",
+	}
+	readFile := func(path string) ([]byte, error) {
+		if data, ok := files[path]; ok {
+			return []byte(data), nil
+		}
+		return nil, os.ErrNotExist
+	}
+	env := map[string]string{}
+	getenv := func(name string) string { return env[name] }
+
+	if name, _ := resolveKeyboardLayout(getenv, readFile); name != "de" {
+		t.Fatalf("layout from vconsole.conf = %s", name)
+	}
+	files["/etc/default/keyboard"] = `XKBMODEL="pc105"` + "\n" + `XKBLAYOUT="us,de"`
+	if name, _ := resolveKeyboardLayout(getenv, readFile); name != "us" {
+		t.Fatalf("layout from /etc/default/keyboard = %s", name)
+	}
+	env["XKB_DEFAULT_LAYOUT"] = "DE"
+	if name, _ := resolveKeyboardLayout(getenv, readFile); name != "de" {
+		t.Fatalf("layout from XKB_DEFAULT_LAYOUT = %s", name)
+	}
+	env["XKB_DEFAULT_LAYOUT"] = "fr"
+	if name, _ := resolveKeyboardLayout(getenv, readFile); name != "us" {
+		t.Fatalf("unknown layout = %s", name)
+	}
+	if got := parseLayoutFile([]byte(`    Option "XkbLayout" "de"`)); got != "de" {
+		t.Fatalf("xorg.conf layout = %s", got)
+	}
+}
+
+func TestUinputKeyboard(t *testing.T) {
+	controller, device := newFakeUinputKeyboard()
+	ctx := context.Background()
+
+	if err := controller.SendKeyCombination(ctx, []string{"CTRL", "shift", "T"}); err != nil {
+		t.Fatalf("SendKeyCombination: %v", err)
+	}
+	if got := fmt.Sprint(device.events); got != "[+29 +42 +20 -20 -42 -29]" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+
+	device.events = nil
+	if err := controller.SendText(ctx, "Hi!"); err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+	if got := fmt.Sprint(device.events); got != "[+42 +35 -35 -42 +23 -23 +42 +2 -2 -42]" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+
+	// Zeichen außerhalb der Belegung über die Unicode-Eingabe: Strg+Umschalt+U, "f6", Leertaste
+	device.events = nil
+	if err := controller.SendText(ctx, "ö"); err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+	if got := fmt.Sprint(device.events); got != "[+29 +42 +22 -22 -42 -29 +33 -33 +7 -7 +57 -57]" {
+		t.Fatalf("unexpected unicode input: %s", got)
+	}
+
+	device.events = nil
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := controller.HoldKey(cancelled, "F1", time.Minute); err == nil {
+		t.Fatalf("expected cancellation error")
+	}
+	if got := fmt.Sprint(device.events); got != "[+59 -59]" {
+		t.Fatalf("key not released: %s", got)
+	}
+}
@@ -0,0 +1,320 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält den Keyboard-Controller für X11 (XTEST-Erweiterung).
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Xcruser/MidiDaemon/internal/x11"
+)
+
+// x11Display ist der Teil einer X11-Verbindung, den Keyboard- und Mouse-Controller benötigen
+type x11Display interface {
+	KeyboardMapping(ctx context.Context) (*x11.KeyboardMapping, error)
+	FakeKey(ctx context.Context, keycode uint8, press bool) error
+	FakeButton(ctx context.Context, button uint8, press bool) error
+	FakeMotion(ctx context.Context, x, y int16, relative bool) error
+	ChangeKeyboardMapping(ctx context.Context, keycode uint8, perKeycode int, syms ...x11.Keysym) error
+	Sync(ctx context.Context) error
+}
+
+// x11Connection hält eine Verbindung zum X-Server und baut sie bei Bedarf neu auf
+type x11Connection struct {
+	display string
+	conn    *x11.Conn
+	mutex   sync.Mutex
+}
+
+// get gibt die bestehende Verbindung zurück oder baut sie (erneut) auf
+func (c *x11Connection) get(ctx context.Context) (x11Display, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.conn != nil && c.conn.Err() == nil {
+		return c.conn, nil
+	}
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+
+	conn, err := x11.Dial(ctx, c.display)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Verbinden mit dem X-Server: %w", err)
+	}
+	c.conn = conn
+	return conn, nil
+}
+
+// x11KeyboardController simuliert Tastatureingaben über die XTEST-Erweiterung des X-Servers
+type x11KeyboardController struct {
+	display func(ctx context.Context) (x11Display, error)
+	// mutex verhindert, dass sich gleichzeitige Aktionen die gedrückten Tasten gegenseitig verfälschen
+	mutex sync.Mutex
+}
+
+// newX11KeyboardController erstellt einen Controller für $DISPLAY. Die Verbindung wird erst
+// bei der ersten Eingabe aufgebaut, damit der Daemon auch ohne laufenden X-Server startet.
+func newX11KeyboardController() *x11KeyboardController {
+	conn := &x11Connection{}
+	return &x11KeyboardController{display: conn.get}
+}
+
+// SendKey drückt eine Taste und lässt sie wieder los
+func (c *x11KeyboardController) SendKey(ctx context.Context, key string) error {
+	return c.SendKeyCombination(ctx, []string{key})
+}
+
+// SendKeyCombination drückt alle Tasten in der angegebenen Reihenfolge und lässt sie
+// in umgekehrter Reihenfolge wieder los
+func (c *x11KeyboardController) SendKeyCombination(ctx context.Context, keys []string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	display, mapping, err := c.prepare(ctx)
+	if err != nil {
+		return err
+	}
+	inputs, err := x11Inputs(mapping, keys)
+	if err != nil {
+		return err
+	}
+	return pressKeys(ctx, display, inputs, 0)
+}
+
+// SendText tippt einen Text Zeichen für Zeichen über die aktive Tastaturbelegung. Zeichen auf
+// der Shift- oder AltGr-Ebene werden mit den entsprechenden Modifiern getippt; Zeichen, die
+// die Belegung nicht enthält (z.B. Emoji), werden vorübergehend auf eine freie Taste gelegt.
+func (c *x11KeyboardController) SendText(ctx context.Context, text string) (err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	display, mapping, err := c.prepare(ctx)
+	if err != nil {
+		return err
+	}
+	typist := &x11Typist{display: display, mapping: mapping}
+	typist.shift, _, _ = mapping.Find(x11.KeysymShift, x11.LevelBase)
+	typist.altGr, _, _ = mapping.Find(x11.KeysymAltGr, x11.LevelBase, x11.LevelShift)
+	defer func() {
+		if restoreErr := typist.restore(ctx); err == nil {
+			err = restoreErr
+		}
+	}()
+
+	for _, r := range text {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := typist.typeRune(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// x11Typist tippt einzelne Zeichen und verwaltet die vorübergehend belegte Ersatztaste
+type x11Typist struct {
+	display x11Display
+	mapping *x11.KeyboardMapping
+	shift   uint8
+	altGr   uint8
+	spare   uint8
+}
+
+// typeRune tippt ein Zeichen
+func (t *x11Typist) typeRune(ctx context.Context, r rune) error {
+	sym := x11.RuneKeysym(r)
+	if sym == x11.NoSymbol {
+		return fmt.Errorf("zeichen %q kann nicht getippt werden", r)
+	}
+
+	keycode, level, ok := t.mapping.Find(sym, x11.LevelBase, x11.LevelShift, x11.LevelAltGr, x11.LevelAltGrShift)
+	var keycodes []uint8
+	switch {
+	case ok && level == x11.LevelBase:
+		keycodes = []uint8{keycode}
+	case ok && level == x11.LevelShift && t.shift != 0:
+		keycodes = []uint8{t.shift, keycode}
+	case ok && level == x11.LevelAltGr && t.altGr != 0:
+		keycodes = []uint8{t.altGr, keycode}
+	case ok && level == x11.LevelAltGrShift && t.altGr != 0 && t.shift != 0:
+		keycodes = []uint8{t.altGr, t.shift, keycode}
+	default:
+		spare, err := t.remap(ctx, sym)
+		if err != nil {
+			return fmt.Errorf("zeichen %q ist in der Tastaturbelegung nicht vorhanden: %w", r, err)
+		}
+		keycodes = []uint8{spare}
+	}
+	return pressKeys(ctx, t.display, x11Keys(keycodes...), 0)
+}
+
+// remap legt ein Keysym auf eine freie Taste, die nach dem Tippen wieder geleert wird
+func (t *x11Typist) remap(ctx context.Context, sym x11.Keysym) (uint8, error) {
+	if t.spare == 0 {
+		spare, ok := t.mapping.Unused()
+		if !ok {
+			return 0, fmt.Errorf("keine freie Taste zum Umbelegen")
+		}
+		t.spare = spare
+	}
+	if err := t.display.ChangeKeyboardMapping(ctx, t.spare, t.mapping.PerKeycode, sym, sym); err != nil {
+		return 0, err
+	}
+	t.mapping.Set(t.spare, sym, sym)
+	return t.spare, nil
+}
+
+// restore gibt die Ersatztaste wieder frei
+func (t *x11Typist) restore(ctx context.Context) error {
+	if t.spare == 0 {
+		return nil
+	}
+	release := context.WithoutCancel(ctx)
+	if err := t.display.ChangeKeyboardMapping(release, t.spare, t.mapping.PerKeycode); err != nil {
+		return err
+	}
+	t.mapping.Set(t.spare)
+	return t.display.Sync(release)
+}
+
+// HoldKey hält eine Taste für die angegebene Dauer gedrückt. Die Taste wird auch bei
+// Abbruch des Context wieder losgelassen.
+func (c *x11KeyboardController) HoldKey(ctx context.Context, key string, duration time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	display, mapping, err := c.prepare(ctx)
+	if err != nil {
+		return err
+	}
+	inputs, err := x11Inputs(mapping, []string{key})
+	if err != nil {
+		return err
+	}
+	return pressKeys(ctx, display, inputs, duration)
+}
+
+// prepare stellt die Verbindung her und lädt die aktuelle Tastaturbelegung,
+// damit Änderungen des Layouts (z.B. setxkbmap) sofort berücksichtigt werden
+func (c *x11KeyboardController) prepare(ctx context.Context) (x11Display, *x11.KeyboardMapping, error) {
+	display, err := c.display(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	mapping, err := display.KeyboardMapping(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return display, mapping, nil
+}
+
+// x11Input ist eine Taste oder Maustaste, die über XTEST gedrückt wird
+type x11Input struct {
+	button bool
+	detail uint8
+}
+
+// x11Keys wandelt Keycodes in Eingaben um
+func x11Keys(keycodes ...uint8) []x11Input {
+	inputs := make([]x11Input, len(keycodes))
+	for i, keycode := range keycodes {
+		inputs[i] = x11Input{detail: keycode}
+	}
+	return inputs
+}
+
+// fake drückt bzw. löst die Eingabe
+func (in x11Input) fake(ctx context.Context, display x11Display, press bool) error {
+	if in.button {
+		return display.FakeButton(ctx, in.detail, press)
+	}
+	return display.FakeKey(ctx, in.detail, press)
+}
+
+// pressKeys drückt alle Tasten, wartet hold ab und lässt sie in umgekehrter Reihenfolge los.
+// Das Loslassen geschieht auch nach Fehlern, damit keine Taste hängen bleibt.
+func pressKeys(ctx context.Context, display x11Display, inputs []x11Input, hold time.Duration) error {
+	var errs []error
+	pressed := 0
+	for _, input := range inputs {
+		if err := input.fake(ctx, display, true); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		pressed++
+	}
+	if len(errs) == 0 && hold > 0 {
+		if err := display.Sync(ctx); err != nil {
+			errs = append(errs, err)
+		} else if err := sleepContext(ctx, hold); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Loslassen darf nicht am abgebrochenen Context scheitern
+	release := context.WithoutCancel(ctx)
+	for i := pressed - 1; i >= 0; i-- {
+		if err := inputs[i].fake(release, display, false); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := display.Sync(release); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("fehler beim Senden der Tasten: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// x11Inputs löst eine Tastenliste über das Tastenvokabular und die Tastaturbelegung auf
+func x11Inputs(mapping *x11.KeyboardMapping, keys []string) ([]x11Input, error) {
+	inputs := make([]x11Input, 0, len(keys))
+	for _, key := range keys {
+		input, err := x11ResolveKey(mapping, key)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+// x11ResolveKey löst eine Taste auf. Einzelne Zeichen stehen für die Taste, auf der sie liegen
+// (Buchstaben werden klein gesucht); rohe evdev-Codes entsprechen unter X11 dem Keycode minus 8.
+func x11ResolveKey(mapping *x11.KeyboardMapping, key string) (x11Input, error) {
+	def, err := lookupKey(key)
+	if err != nil {
+		return x11Input{}, err
+	}
+
+	var syms []x11.Keysym
+	switch {
+	case def.button != 0:
+		return x11Input{button: true, detail: def.button}, nil
+	case def.keycode != 0:
+		return x11Input{detail: def.keycode}, nil
+	case def.char != 0:
+		syms = []x11.Keysym{x11.RuneKeysym(unicode.ToLower(def.char))}
+	case len(def.keysyms) > 0:
+		syms = def.keysyms
+	case def.evdev != 0 && def.evdev <= 255-8:
+		return x11Input{detail: uint8(def.evdev + 8)}, nil
+	default:
+		return x11Input{}, fmt.Errorf("taste %s ist unter X11 nicht verfügbar", key)
+	}
+	for _, sym := range syms {
+		if keycode, _, ok := mapping.Lookup(sym); ok {
+			return x11Input{detail: keycode}, nil
+		}
+	}
+	return x11Input{}, fmt.Errorf("taste %s ist in der Tastaturbelegung nicht vorhanden", key)
+}
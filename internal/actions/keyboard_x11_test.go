@@ -0,0 +1,147 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/x11"
+)
+
+// fakeX11Display zeichnet simulierte Tastendrücke auf
+type fakeX11Display struct {
+	mapping *x11.KeyboardMapping
+	events  []string
+	syncs   int
+}
+
+func (d *fakeX11Display) KeyboardMapping(ctx context.Context) (*x11.KeyboardMapping, error) {
+	return d.mapping, nil
+}
+
+func (d *fakeX11Display) FakeKey(ctx context.Context, keycode uint8, press bool) error {
+	if press {
+		d.events = append(d.events, fmt.Sprintf("+%d", keycode))
+	} else {
+		d.events = append(d.events, fmt.Sprintf("-%d", keycode))
+	}
+	return nil
+}
+
+func (d *fakeX11Display) FakeButton(ctx context.Context, button uint8, press bool) error {
+	if press {
+		d.events = append(d.events, fmt.Sprintf("+b%d", button))
+	} else {
+		d.events = append(d.events, fmt.Sprintf("-b%d", button))
+	}
+	return nil
+}
+
+func (d *fakeX11Display) FakeMotion(ctx context.Context, x, y int16, relative bool) error {
+	if relative {
+		d.events = append(d.events, fmt.Sprintf("~%d,%d", x, y))
+	} else {
+		d.events = append(d.events, fmt.Sprintf("@%d,%d", x, y))
+	}
+	return nil
+}
+
+func (d *fakeX11Display) ChangeKeyboardMapping(ctx context.Context, keycode uint8, perKeycode int, syms ...x11.Keysym) error {
+	d.events = append(d.events, fmt.Sprintf("map%d=%x", keycode, syms))
+	return nil
+}
+
+func (d *fakeX11Display) Sync(ctx context.Context) error {
+	d.syncs++
+	return nil
+}
+
+func newFakeX11Keyboard() (*x11KeyboardController, *fakeX11Display) {
+	// Keycodes ab 8 mit XKB-Ebenen (Gruppe 1, Gruppe 2, AltGr): a/A, 1/!, Control_L, Shift_L,
+	// Return, F5, q/Q/@ (AltGr), ISO_Level3_Shift und eine freie Taste
+	display := &fakeX11Display{mapping: &x11.KeyboardMapping{
+		MinKeycode: 8,
+		PerKeycode: 6,
+		Keysyms: []x11.Keysym{
+			'a', 'A', 0, 0, 0, 0,
+			'1', '!', 0, 0, 0, 0,
+			0xffe3, 0, 0, 0, 0, 0,
+			x11.KeysymShift, 0, 0, 0, 0, 0,
+			x11.KeysymReturn, 0, 0, 0, 0, 0,
+			0xffc2, 0, 0, 0, 0, 0,
+			'q', 'Q', 0, 0, '@', 0,
+			x11.KeysymAltGr, 0, 0, 0, 0, 0,
+			0, 0, 0, 0, 0, 0,
+		},
+	}}
+	controller := &x11KeyboardController{display: func(ctx context.Context) (x11Display, error) {
+		return display, nil
+	}}
+	return controller, display
+}
+
+func TestX11KeyboardCombination(t *testing.T) {
+	controller, display := newFakeX11Keyboard()
+	if err := controller.SendKeyCombination(context.Background(), []string{"ctrl", "A"}); err != nil {
+		t.Fatalf("SendKeyCombination: %v", err)
+	}
+	if got := fmt.Sprint(display.events); got != "[+10 +8 -8 -10]" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+
+	display.events = nil
+	if err := controller.SendKey(context.Background(), "F5"); err != nil {
+		t.Fatalf("SendKey: %v", err)
+	}
+	if got := fmt.Sprint(display.events); got != "[+13 -13]" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+	if err := controller.SendKey(context.Background(), "F9"); err == nil {
+		t.Fatalf("expected error for unmapped key")
+	}
+
+	// Alias, Maustaste und roher Keycode
+	display.events = nil
+	if err := controller.SendKeyCombination(context.Background(), []string{"Control_L", "MOUSE3", "X11:200", "CODE:60"}); err != nil {
+		t.Fatalf("SendKeyCombination: %v", err)
+	}
+	if got := fmt.Sprint(display.events); got != "[+10 +b2 +200 +68 -68 -200 -b2 -10]" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+}
+
+func TestX11KeyboardText(t *testing.T) {
+	controller, display := newFakeX11Keyboard()
+	if err := controller.SendText(context.Background(), "a!\n"); err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+	if got := fmt.Sprint(display.events); got != "[+8 -8 +11 +9 -9 -11 +12 -12]" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+
+	// AltGr-Ebene und vorübergehend belegte freie Taste für Zeichen außerhalb der Belegung
+	display.events = nil
+	if err := controller.SendText(context.Background(), "@ö"); err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+	if got := fmt.Sprint(display.events); got != "[+15 +14 -14 -15 map16=[f6 f6] +16 -16 map16=[]]" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+	if sym := display.mapping.Keysym(16, 0); sym != x11.NoSymbol {
+		t.Fatalf("spare key not restored: %#x", sym)
+	}
+}
+
+func TestX11KeyboardHoldReleasesOnCancel(t *testing.T) {
+	controller, display := newFakeX11Keyboard()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := controller.HoldKey(ctx, "a", time.Minute); err == nil {
+		t.Fatalf("expected cancellation error")
+	}
+	if got := fmt.Sprint(display.events); got != "[+8 -8]" {
+		t.Fatalf("key not released: %s", got)
+	}
+}
@@ -0,0 +1,221 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält das plattformunabhängige Tastenvokabular, das Validierung und alle Keyboard-Backends teilen.
+
+package actions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Xcruser/MidiDaemon/internal/x11"
+)
+
+// keyDef beschreibt eine Taste mit ihren Codes auf den einzelnen Plattformen. Ein Code von 0
+// bedeutet, dass die Taste auf dieser Plattform nicht gesendet werden kann.
+type keyDef struct {
+	// names enthält den kanonischen Namen und die Aliase der Taste
+	names []string
+
+	// evdev ist der Linux-Code aus linux/input-event-codes.h (uinput)
+	evdev uint16
+
+	// keysyms sind die X11-Keysyms in der Reihenfolge, in der sie in der Belegung gesucht werden
+	keysyms []x11.Keysym
+
+	// vk ist der virtuelle Tastencode unter Windows
+	vk uint16
+
+	// button ist die X11-Nummer einer Maustaste (0 für Tastaturtasten)
+	button uint8
+
+	// char ist ein einzelnes Zeichen, das über die Tastaturbelegung aufgelöst wird
+	char rune
+
+	// keycode ist ein roher X11-Keycode (aus einem "X11:"-Namen)
+	keycode uint8
+}
+
+// keyTable ist das Tastenvokabular. Namen werden ohne Groß-/Kleinschreibung, Leerzeichen,
+// Binde- und Unterstriche verglichen ("Page_Up" = "PAGEUP").
+var keyTable = []keyDef{
+	// Modifier-Tasten (ohne Seitenangabe links)
+	{names: []string{"CTRL", "CONTROL", "LCTRL", "LEFTCTRL", "CTRL_L", "CONTROL_L", "LCONTROL"}, evdev: 29, keysyms: ks(0xffe3), vk: 0xa2},
+	{names: []string{"RCTRL", "RIGHTCTRL", "CTRL_R", "CONTROL_R", "RCONTROL"}, evdev: 97, keysyms: ks(0xffe4), vk: 0xa3},
+	{names: []string{"SHIFT", "LSHIFT", "LEFTSHIFT", "SHIFT_L"}, evdev: 42, keysyms: ks(0xffe1), vk: 0xa0},
+	{names: []string{"RSHIFT", "RIGHTSHIFT", "SHIFT_R"}, evdev: 54, keysyms: ks(0xffe2), vk: 0xa1},
+	{names: []string{"ALT", "LALT", "LEFTALT", "ALT_L", "OPTION"}, evdev: 56, keysyms: ks(0xffe9), vk: 0xa4},
+	{names: []string{"RALT", "RIGHTALT", "ALT_R", "ALTGR", "ALTGRAPH"}, evdev: 100, keysyms: ks(0xffea, 0xfe03), vk: 0xa5},
+	{names: []string{"SUPER", "LSUPER", "SUPER_L", "WIN", "LWIN", "WINDOWS", "META", "LMETA", "CMD", "COMMAND"}, evdev: 125, keysyms: ks(0xffeb), vk: 0x5b},
+	{names: []string{"RSUPER", "SUPER_R", "RWIN", "RMETA"}, evdev: 126, keysyms: ks(0xffec), vk: 0x5c},
+	{names: []string{"MENU", "APPS", "CONTEXTMENU", "COMPOSE"}, evdev: 127, keysyms: ks(0xff67), vk: 0x5d},
+	// Navigation
+	{names: []string{"UP", "ARROWUP"}, evdev: 103, keysyms: ks(0xff52), vk: 0x26},
+	{names: []string{"DOWN", "ARROWDOWN"}, evdev: 108, keysyms: ks(0xff54), vk: 0x28},
+	{names: []string{"LEFT", "ARROWLEFT"}, evdev: 105, keysyms: ks(0xff51), vk: 0x25},
+	{names: []string{"RIGHT", "ARROWRIGHT"}, evdev: 106, keysyms: ks(0xff53), vk: 0x27},
+	{names: []string{"HOME"}, evdev: 102, keysyms: ks(0xff50), vk: 0x24},
+	{names: []string{"END"}, evdev: 107, keysyms: ks(0xff57), vk: 0x23},
+	{names: []string{"PAGEUP", "PGUP", "PRIOR"}, evdev: 104, keysyms: ks(0xff55), vk: 0x21},
+	{names: []string{"PAGEDOWN", "PGDN", "PGDOWN"}, evdev: 109, keysyms: ks(0xff56), vk: 0x22},
+	// Bearbeitung
+	{names: []string{"ENTER", "RETURN"}, evdev: 28, keysyms: ks(0xff0d), vk: 0x0d},
+	{names: []string{"ESC", "ESCAPE"}, evdev: 1, keysyms: ks(0xff1b), vk: 0x1b},
+	{names: []string{"TAB"}, evdev: 15, keysyms: ks(0xff09), vk: 0x09},
+	{names: []string{"SPACE", "SPACEBAR"}, evdev: 57, keysyms: ks(0x20), vk: 0x20},
+	{names: []string{"BACKSPACE", "BKSP", "BS"}, evdev: 14, keysyms: ks(0xff08), vk: 0x08},
+	{names: []string{"DELETE", "DEL"}, evdev: 111, keysyms: ks(0xffff), vk: 0x2e},
+	{names: []string{"INSERT", "INS"}, evdev: 110, keysyms: ks(0xff63), vk: 0x2d},
+	// Feststell- und Systemtasten
+	{names: []string{"CAPSLOCK", "CAPS"}, evdev: 58, keysyms: ks(0xffe5), vk: 0x14},
+	{names: []string{"NUMLOCK"}, evdev: 69, keysyms: ks(0xff7f), vk: 0x90},
+	{names: []string{"SCROLLLOCK"}, evdev: 70, keysyms: ks(0xff14), vk: 0x91},
+	{names: []string{"PRINTSCREEN", "PRINT", "PRTSC", "SNAPSHOT"}, evdev: 99, keysyms: ks(0xff61), vk: 0x2c},
+	{names: []string{"PAUSE", "BREAK"}, evdev: 119, keysyms: ks(0xff13), vk: 0x13},
+	{names: []string{"POWER"}, evdev: 116, keysyms: ks(0x1008ff2a)},
+	{names: []string{"SLEEP"}, evdev: 142, keysyms: ks(0x1008ff2f), vk: 0x5f},
+	{names: []string{"BRIGHTNESSUP"}, evdev: 225, keysyms: ks(0x1008ff02)},
+	{names: []string{"BRIGHTNESSDOWN"}, evdev: 224, keysyms: ks(0x1008ff03)},
+	// Ziffernblock
+	{names: []string{"KP0", "NUMPAD0"}, evdev: 82, keysyms: ks(0xffb0), vk: 0x60},
+	{names: []string{"KP1", "NUMPAD1"}, evdev: 79, keysyms: ks(0xffb1), vk: 0x61},
+	{names: []string{"KP2", "NUMPAD2"}, evdev: 80, keysyms: ks(0xffb2), vk: 0x62},
+	{names: []string{"KP3", "NUMPAD3"}, evdev: 81, keysyms: ks(0xffb3), vk: 0x63},
+	{names: []string{"KP4", "NUMPAD4"}, evdev: 75, keysyms: ks(0xffb4), vk: 0x64},
+	{names: []string{"KP5", "NUMPAD5"}, evdev: 76, keysyms: ks(0xffb5), vk: 0x65},
+	{names: []string{"KP6", "NUMPAD6"}, evdev: 77, keysyms: ks(0xffb6), vk: 0x66},
+	{names: []string{"KP7", "NUMPAD7"}, evdev: 71, keysyms: ks(0xffb7), vk: 0x67},
+	{names: []string{"KP8", "NUMPAD8"}, evdev: 72, keysyms: ks(0xffb8), vk: 0x68},
+	{names: []string{"KP9", "NUMPAD9"}, evdev: 73, keysyms: ks(0xffb9), vk: 0x69},
+	{names: []string{"KPMULTIPLY", "KPASTERISK", "MULTIPLY"}, evdev: 55, keysyms: ks(0xffaa), vk: 0x6a},
+	{names: []string{"KPPLUS", "KPADD", "ADD"}, evdev: 78, keysyms: ks(0xffab), vk: 0x6b},
+	{names: []string{"KPMINUS", "KPSUBTRACT", "SUBTRACT"}, evdev: 74, keysyms: ks(0xffad), vk: 0x6d},
+	{names: []string{"KPDECIMAL", "KPDOT", "DECIMAL"}, evdev: 83, keysyms: ks(0xffae), vk: 0x6e},
+	{names: []string{"KPDIVIDE", "KPSLASH", "DIVIDE"}, evdev: 98, keysyms: ks(0xffaf), vk: 0x6f},
+	{names: []string{"KPENTER"}, evdev: 96, keysyms: ks(0xff8d)},
+	// Medientasten
+	{names: []string{"PLAYPAUSE", "MEDIAPLAYPAUSE", "PLAY", "MEDIAPLAY"}, evdev: 164, keysyms: ks(0x1008ff14), vk: 0xb3},
+	{names: []string{"MEDIASTOP", "STOPCD"}, evdev: 166, keysyms: ks(0x1008ff15), vk: 0xb2},
+	{names: []string{"NEXTTRACK", "MEDIANEXT", "NEXTSONG"}, evdev: 163, keysyms: ks(0x1008ff17), vk: 0xb0},
+	{names: []string{"PREVTRACK", "MEDIAPREV", "MEDIAPREVIOUS", "PREVIOUSSONG"}, evdev: 165, keysyms: ks(0x1008ff16), vk: 0xb1},
+	{names: []string{"VOLUMEUP", "VOLUP"}, evdev: 115, keysyms: ks(0x1008ff13), vk: 0xaf},
+	{names: []string{"VOLUMEDOWN", "VOLDOWN"}, evdev: 114, keysyms: ks(0x1008ff11), vk: 0xae},
+	{names: []string{"MUTE", "VOLUMEMUTE"}, evdev: 113, keysyms: ks(0x1008ff12), vk: 0xad},
+	{names: []string{"MICMUTE"}, evdev: 248, keysyms: ks(0x1008ffb2)},
+	// Browser- und Programmtasten
+	{names: []string{"BROWSERBACK", "BACK"}, evdev: 158, keysyms: ks(0x1008ff26), vk: 0xa6},
+	{names: []string{"BROWSERFORWARD", "FORWARD"}, evdev: 159, keysyms: ks(0x1008ff27), vk: 0xa7},
+	{names: []string{"BROWSERREFRESH", "REFRESH", "RELOAD"}, evdev: 173, keysyms: ks(0x1008ff73), vk: 0xa8},
+	{names: []string{"BROWSERSTOP"}, evdev: 128, keysyms: ks(0x1008ff28), vk: 0xa9},
+	{names: []string{"BROWSERSEARCH", "SEARCH"}, evdev: 217, keysyms: ks(0x1008ff1b), vk: 0xaa},
+	{names: []string{"BROWSERFAVORITES", "FAVORITES", "BOOKMARKS"}, evdev: 156, keysyms: ks(0x1008ff30), vk: 0xab},
+	{names: []string{"BROWSERHOME", "HOMEPAGE"}, evdev: 172, keysyms: ks(0x1008ff18), vk: 0xac},
+	{names: []string{"MAIL", "LAUNCHMAIL"}, evdev: 155, keysyms: ks(0x1008ff19), vk: 0xb4},
+	{names: []string{"CALCULATOR", "CALC"}, evdev: 140, keysyms: ks(0x1008ff1d), vk: 0xb7},
+	// Maustasten
+	{names: []string{"MOUSELEFT", "LBUTTON", "MOUSE1", "LEFTCLICK"}, evdev: 0x110, vk: 0x01, button: 1},
+	{names: []string{"MOUSERIGHT", "RBUTTON", "MOUSE2", "RIGHTCLICK"}, evdev: 0x111, vk: 0x02, button: 3},
+	{names: []string{"MOUSEMIDDLE", "MBUTTON", "MOUSE3", "MIDDLECLICK"}, evdev: 0x112, vk: 0x04, button: 2},
+	{names: []string{"MOUSEBACK", "XBUTTON1", "MOUSE4"}, evdev: 0x113, vk: 0x05, button: 8},
+	{names: []string{"MOUSEFORWARD", "XBUTTON2", "MOUSE5"}, evdev: 0x114, vk: 0x06, button: 9},
+}
+
+// evdevFunctionKeys sind die Linux-Codes von F1 bis F24
+var evdevFunctionKeys = [24]uint16{
+	59, 60, 61, 62, 63, 64, 65, 66, 67, 68, 87, 88,
+	183, 184, 185, 186, 187, 188, 189, 190, 191, 192, 193, 194,
+}
+
+// keysByName ist der Index des Vokabulars nach normalisiertem Namen
+var keysByName = func() map[string]keyDef {
+	index := map[string]keyDef{}
+	for _, def := range keyTable {
+		for _, name := range def.names {
+			index[normalizeKeyName(name)] = def
+		}
+	}
+	for n := 1; n <= len(evdevFunctionKeys); n++ {
+		name := "F" + strconv.Itoa(n)
+		index[name] = keyDef{
+			names:   []string{name},
+			evdev:   evdevFunctionKeys[n-1],
+			keysyms: ks(0xffbe + x11.Keysym(n-1)),
+			vk:      uint16(0x70 + n - 1),
+		}
+	}
+	return index
+}()
+
+// ks ist eine Kurzschreibweise für Keysym-Listen
+func ks(syms ...x11.Keysym) []x11.Keysym {
+	return syms
+}
+
+// normalizeKeyName vereinheitlicht einen Tastennamen für den Vergleich
+func normalizeKeyName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '_', '-':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(name))
+}
+
+// lookupKey löst einen Tastennamen auf. Gültig sind Namen aus dem Vokabular, einzelne Zeichen und
+// rohe Codes: "CODE:<n>" (Linux-evdev-Code), "X11:<n>" (X11-Keycode) und "VK:<n>" (Windows),
+// jeweils dezimal oder mit 0x hexadezimal.
+func lookupKey(key string) (keyDef, error) {
+	trimmed := strings.TrimSpace(key)
+	if trimmed == "" {
+		return keyDef{}, fmt.Errorf("taste darf nicht leer sein")
+	}
+	if utf8.RuneCountInString(key) == 1 {
+		r, _ := utf8.DecodeRuneInString(key)
+		return keyDef{names: []string{key}, char: r}, nil
+	}
+
+	if prefix, value, ok := strings.Cut(trimmed, ":"); ok {
+		code, err := strconv.ParseUint(strings.TrimSpace(value), 0, 16)
+		if err != nil || code == 0 {
+			return keyDef{}, fmt.Errorf("ungültiger Tastencode: %s", key)
+		}
+		switch strings.ToUpper(strings.TrimSpace(prefix)) {
+		case "CODE", "EVDEV":
+			return keyDef{names: []string{trimmed}, evdev: uint16(code)}, nil
+		case "X11":
+			if code < 8 || code > 255 {
+				return keyDef{}, fmt.Errorf("x11-keycode muss zwischen 8 und 255 liegen: %s", key)
+			}
+			return keyDef{names: []string{trimmed}, keycode: uint8(code)}, nil
+		case "VK":
+			return keyDef{names: []string{trimmed}, vk: uint16(code)}, nil
+		}
+		return keyDef{}, fmt.Errorf("ungültiger Tastencode: %s (erwartet: CODE:, X11: oder VK:)", key)
+	}
+
+	if def, ok := keysByName[normalizeKeyName(trimmed)]; ok {
+		return def, nil
+	}
+	return keyDef{}, fmt.Errorf("ungültige Taste: %s", key)
+}
+
+// name gibt den kanonischen Namen der Taste zurück
+func (k keyDef) name() string {
+	return k.names[0]
+}
+
+// availableOn prüft, ob eine Taste auf einer Plattform gesendet werden kann
+func (k keyDef) availableOn(goos string) bool {
+	if k.char != 0 {
+		return true
+	}
+	switch goos {
+	case "windows":
+		return k.vk != 0
+	case "linux":
+		return k.evdev != 0 || k.keycode != 0 || len(k.keysyms) > 0
+	}
+	return false
+}
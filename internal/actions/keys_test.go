@@ -0,0 +1,76 @@
+package actions
+
+import (
+	"testing"
+)
+
+func TestLookupKey(t *testing.T) {
+	aliases := map[string]string{
+		"ctrl":         "CTRL",
+		"Control":      "CTRL",
+		"LCtrl":        "CTRL",
+		"Control_L":    "CTRL",
+		"Right Ctrl":   "RCTRL",
+		"AltGr":        "RALT",
+		"Page_Up":      "PAGEUP",
+		"win":          "SUPER",
+		"media-play":   "PLAYPAUSE",
+		"XButton1":     "MOUSEBACK",
+		"f24":          "F24",
+		"VolumeMute":   "MUTE",
+		"browser_back": "BROWSERBACK",
+	}
+	for name, want := range aliases {
+		def, err := lookupKey(name)
+		if err != nil || def.name() != want {
+			t.Fatalf("lookupKey(%q) = %v, %v; want %s", name, def.names, err, want)
+		}
+	}
+
+	if def, err := lookupKey("ß"); err != nil || def.char != 'ß' {
+		t.Fatalf("lookupKey(ß) = %+v, %v", def, err)
+	}
+	if def, err := lookupKey("code:0x2a0"); err != nil || def.evdev != 0x2a0 {
+		t.Fatalf("lookupKey(code:0x2a0) = %+v, %v", def, err)
+	}
+	if def, err := lookupKey("VK:65"); err != nil || def.vk != 65 || def.availableOn("linux") {
+		t.Fatalf("lookupKey(VK:65) = %+v, %v", def, err)
+	}
+	for _, name := range []string{"", "F25", "NOPE", "X11:3", "CODE:abc", "FOO:1"} {
+		if _, err := lookupKey(name); err == nil {
+			t.Fatalf("expected error for %q", name)
+		}
+	}
+}
+
+func TestKeyTable(t *testing.T) {
+	seen := map[string]string{}
+	for _, def := range keyTable {
+		if def.evdev == 0 {
+			t.Fatalf("%s has no evdev code", def.name())
+		}
+		if len(def.keysyms) == 0 && def.button == 0 {
+			t.Fatalf("%s has no X11 keysym", def.name())
+		}
+		for _, name := range def.names {
+			key := normalizeKeyName(name)
+			if other, ok := seen[key]; ok {
+				t.Fatalf("alias %s used by %s and %s", name, other, def.name())
+			}
+			seen[key] = def.name()
+		}
+	}
+
+	// Alle bisher gültigen Namen bleiben auf beiden Plattformen gültig
+	for _, name := range []string{"F1", "F12", "CTRL", "ALT", "SHIFT", "SUPER", "WIN", "UP", "DOWN", "LEFT", "RIGHT",
+		"HOME", "END", "PAGEUP", "PAGEDOWN", "ENTER", "ESC", "TAB", "SPACE", "BACKSPACE", "DELETE", "INSERT", "a", "1"} {
+		for _, goos := range []string{"linux", "windows"} {
+			if err := validatePlatformKey(name, goos); err != nil {
+				t.Fatalf("%s on %s: %v", name, goos, err)
+			}
+		}
+	}
+	if err := validatePlatformKey("MICMUTE", "windows"); err == nil {
+		t.Fatalf("expected MICMUTE to be unavailable on windows")
+	}
+}
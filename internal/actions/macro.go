@@ -0,0 +1,170 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält die Ausführung von Makros.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+// maxMacroSteps begrenzt die Anzahl ausgeführter Schritte pro Makro-Lauf (Schutz vor Sprung-Schleifen)
+const maxMacroSteps = 100
+
+// ExecuteMacro führt das Makro eines Mappings Schritt für Schritt aus. Der Lauf kann über den Context,
+// das Mapping-Timeout oder CancelMacro abgebrochen werden; ein Makro läuft pro Mapping höchstens einmal gleichzeitig.
+func (m *Manager) ExecuteMacro(ctx context.Context, mapping config.Mapping, data config.TemplateData) error {
+	if mapping.Macro == nil {
+		return fmt.Errorf("mapping '%s' hat kein Makro", mapping.Name)
+	}
+	name, macro := mapping.Name, *mapping.Macro
+
+	ctx, cancel := withMappingTimeout(ctx, mapping)
+	defer cancel()
+
+	m.mutex.Lock()
+	if _, running := m.macros[name]; running {
+		m.mutex.Unlock()
+		return fmt.Errorf("makro '%s' läuft bereits", name)
+	}
+	m.macros[name] = cancel
+	m.mutex.Unlock()
+
+	defer func() {
+		m.mutex.Lock()
+		delete(m.macros, name)
+		m.mutex.Unlock()
+	}()
+
+	m.logger.Info("Starte Makro", "macro", name, "steps", len(macro.Steps))
+	start := time.Now()
+
+	// Ergebnisse der Schritte stehen späteren Schritten als .Steps bzw. .Last zur Verfügung
+	names := make([]string, len(macro.Steps))
+	for i, step := range macro.Steps {
+		names[i] = step.Name
+	}
+	data.Steps = config.NewStepResults(names...)
+
+	failures := 0
+	for i, executed := 0, 0; i < len(macro.Steps); executed++ {
+		if executed >= maxMacroSteps {
+			return fmt.Errorf("makro '%s' hat mehr als %d Schritte ausgeführt (Sprung-Schleife?)", name, maxMacroSteps)
+		}
+
+		step := macro.Steps[i]
+		if err := sleepContext(ctx, time.Duration(step.Delay)*time.Millisecond); err != nil {
+			m.logger.Info("Makro abgebrochen", "macro", name, "step", step.Name)
+			return fmt.Errorf("makro '%s' abgebrochen vor Schritt '%s': %w", name, step.Name, err)
+		}
+
+		result, err := m.executeStep(ctx, mapping, step, data)
+		data.Last = newStepResult(result, err)
+		data.Steps[step.Name] = data.Last
+		next := i + 1
+		target := step.OnSuccess
+		if err != nil {
+			m.logger.Warn("Makro-Schritt fehlgeschlagen",
+				"macro", name,
+				"step", step.Name,
+				"action", step.Action.Type,
+				"error", err,
+			)
+			target = step.OnFailure
+			if target == "" && !step.ContinueOnError {
+				return fmt.Errorf("makro '%s' abgebrochen in Schritt '%s': %w", name, step.Name, err)
+			}
+			failures++
+		} else {
+			m.logger.Info("Makro-Schritt ausgeführt",
+				"macro", name,
+				"step", step.Name,
+				"action", step.Action.Type,
+				"duration", result.Duration,
+			)
+		}
+
+		// Verzweigung
+		switch target {
+		case "":
+		case config.MacroStop:
+			next = len(macro.Steps)
+		default:
+			next, _ = macro.Index(target)
+		}
+		i = next
+	}
+
+	m.logger.Info("Makro beendet", "macro", name, "duration", time.Since(start), "failures", failures)
+	return nil
+}
+
+// executeStep füllt die Parameter eines Makro-Schritts aus und führt ihn aus
+func (m *Manager) executeStep(ctx context.Context, mapping config.Mapping, step config.MacroStep, data config.TemplateData) (Result, error) {
+	start := time.Now()
+
+	// Variablen können sich durch vorherige Schritte geändert haben
+	data.Vars = m.variables.Snapshot()
+
+	action, err := step.Action.WithValue(data.Value).Render(data)
+	if err != nil {
+		err = fmt.Errorf("fehler beim Ausfüllen der Parameter: %w", err)
+		m.record(mapping, step.Name, data, step.Action, start, Result{}, err)
+		return Result{}, err
+	}
+
+	if err := m.validateRendered(step.Action, action); err != nil {
+		m.record(mapping, step.Name, data, action, start, Result{}, err)
+		return Result{}, err
+	}
+
+	result, err := m.executeWithRetry(ctx, mapping, step.Name, action)
+	m.record(mapping, step.Name, data, action, start, result, err)
+	return result, err
+}
+
+// newStepResult übernimmt das Ergebnis eines Schritts für die Templates der folgenden Schritte
+func newStepResult(result Result, err error) config.StepResult {
+	step := config.StepResult{
+		Output:   result.Output,
+		Stderr:   result.Stderr,
+		ExitCode: result.ExitCode,
+		State:    result.State,
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	return step
+}
+
+// CancelMacro bricht ein laufendes Makro ab und gibt zurück, ob es lief
+func (m *Manager) CancelMacro(name string) bool {
+	m.mutex.RLock()
+	cancel, running := m.macros[name]
+	m.mutex.RUnlock()
+
+	if running {
+		cancel()
+	}
+	return running
+}
+
+// sleepContext wartet die angegebene Dauer oder bis der Context beendet ist
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -3,8 +3,12 @@
 package actions
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"sync"
+	"time"
 
 	"github.com/Xcruser/MidiDaemon/internal/config"
 	"github.com/Xcruser/MidiDaemon/pkg/utils"
@@ -15,21 +19,71 @@ type Manager struct {
 	config    *config.Config
 	logger    utils.Logger
 	executors map[string]Executor
+	variables *Variables
+	macros    map[string]context.CancelFunc
+	breakers  map[string]*breaker
+	history   *History
 	mutex     sync.RWMutex
+
+	// Wird geschlossen, sobald die Audio-Executors nach einem Backend-Wechsel ersetzt wurden
+	audioChanged chan struct{}
 }
 
-// Executor definiert die Schnittstelle für Aktion-Ausführer
+// ErrAudioBackendChanged wird von WatchVolume gemeldet, wenn das Audio-Backend beim Neuladen gewechselt hat
+var ErrAudioBackendChanged = errors.New("audio-backend gewechselt")
+
+// Executor definiert die Schnittstelle für Aktion-Ausführer.
+// Execute muss den Context beachten und bei dessen Abbruch schnellstmöglich zurückkehren.
 type Executor interface {
-	Execute(action config.Action) error
+	Execute(ctx context.Context, action config.Action) (Result, error)
 	GetName() string
 }
 
+// Result beschreibt das Ergebnis einer ausgeführten Aktion
+type Result struct {
+	// Die Aktion hat den Systemzustand verändert
+	Changed bool `json:"changed"`
+
+	// Neuer Zustand nach der Aktion (z.B. Lautstärke oder aktive Audioquelle)
+	State string `json:"state,omitempty"`
+
+	// Ausgabe der Aktion (z.B. Prozess-ID einer gestarteten Anwendung oder stdout eines Befehls)
+	Output string `json:"output,omitempty"`
+
+	// Fehlerausgabe eines Befehls (stderr)
+	Stderr string `json:"stderr,omitempty"`
+
+	// Exit-Code eines Befehls
+	ExitCode int `json:"exit_code,omitempty"`
+
+	// Dauer der Ausführung
+	Duration time.Duration `json:"duration"`
+}
+
+// historyConfig gibt die Verlaufs-Konfiguration mit dem aufgelösten Pfad der Verlaufsdatei zurück
+func historyConfig(cfg *config.Config) config.HistoryConfig {
+	history := cfg.General.History
+	history.File = cfg.HistoryPath()
+	return history
+}
+
 // NewManager erstellt einen neuen Action-Manager
 func NewManager(cfg *config.Config, logger utils.Logger) (*Manager, error) {
+	history, err := NewHistory(historyConfig(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Erstellen des Verlaufs: %w", err)
+	}
+
 	manager := &Manager{
 		config:    cfg,
 		logger:    logger,
 		executors: make(map[string]Executor),
+		variables: NewVariables(cfg.Variables),
+		macros:    make(map[string]context.CancelFunc),
+		breakers:  make(map[string]*breaker),
+		history:   history,
+
+		audioChanged: make(chan struct{}),
 	}
 
 	// Plattformspezifische Executors registrieren
@@ -43,12 +97,19 @@ func NewManager(cfg *config.Config, logger utils.Logger) (*Manager, error) {
 // registerExecutors registriert alle verfügbaren Aktion-Executors
 func (m *Manager) registerExecutors() error {
 	// Volume-Executor registrieren
-	volumeExecutor, err := NewVolumeExecutor(m.logger)
+	volumeExecutor, err := NewVolumeExecutor(m.config.Audio, m.logger)
 	if err != nil {
 		return fmt.Errorf("fehler beim Erstellen des Volume-Executors: %w", err)
 	}
 	m.registerExecutor(volumeExecutor)
 
+	// App-Volume-Executor registrieren
+	appVolumeExecutor, err := NewAppVolumeExecutor(m.config.Audio, m.logger)
+	if err != nil {
+		return fmt.Errorf("fehler beim Erstellen des App-Volume-Executors: %w", err)
+	}
+	m.registerExecutor(appVolumeExecutor)
+
 	// App-Start-Executor registrieren
 	appStartExecutor, err := NewAppStartExecutor(m.logger)
 	if err != nil {
@@ -56,6 +117,13 @@ func (m *Manager) registerExecutors() error {
 	}
 	m.registerExecutor(appStartExecutor)
 
+	// Command-Executor registrieren
+	commandExecutor, err := NewCommandExecutor(m.logger)
+	if err != nil {
+		return fmt.Errorf("fehler beim Erstellen des Command-Executors: %w", err)
+	}
+	m.registerExecutor(commandExecutor)
+
 	// Tastenkombination-Executor registrieren
 	keyCombinationExecutor, err := NewKeyCombinationExecutor(m.logger)
 	if err != nil {
@@ -63,13 +131,41 @@ func (m *Manager) registerExecutors() error {
 	}
 	m.registerExecutor(keyCombinationExecutor)
 
+	// Mouse-Executor registrieren
+	mouseExecutor, err := NewMouseExecutor(m.config.Input, m.logger)
+	if err != nil {
+		return fmt.Errorf("fehler beim Erstellen des Mouse-Executors: %w", err)
+	}
+	m.registerExecutor(mouseExecutor)
+
 	// Audio-Quelle-Executor registrieren
-	audioSourceExecutor, err := NewAudioSourceExecutor(m.logger)
+	audioSourceExecutor, err := NewAudioSourceExecutor(m.config.Audio, m.logger)
 	if err != nil {
 		return fmt.Errorf("fehler beim Erstellen des Audio-Source-Executors: %w", err)
 	}
 	m.registerExecutor(audioSourceExecutor)
 
+	// Audio-Scene-Executor registrieren
+	audioSceneExecutor, err := NewAudioSceneExecutor(m.config.Audio, m.config.ScenesPath(), m.logger)
+	if err != nil {
+		return fmt.Errorf("fehler beim Erstellen des Audio-Scene-Executors: %w", err)
+	}
+	m.registerExecutor(audioSceneExecutor)
+
+	// Ducking-Executor registrieren
+	duckingExecutor, err := NewDuckingExecutor(m.config.Audio, m.logger)
+	if err != nil {
+		return fmt.Errorf("fehler beim Erstellen des Ducking-Executors: %w", err)
+	}
+	m.registerExecutor(duckingExecutor)
+
+	// Variable-Executor registrieren
+	variableExecutor, err := NewVariableExecutor(m.variables, m.logger)
+	if err != nil {
+		return fmt.Errorf("fehler beim Erstellen des Variable-Executors: %w", err)
+	}
+	m.registerExecutor(variableExecutor)
+
 	m.logger.Info("Action-Executors registriert", "count", len(m.executors))
 	return nil
 }
@@ -81,26 +177,236 @@ func (m *Manager) registerExecutor(executor Executor) {
 	m.executors[executor.GetName()] = executor
 }
 
-// Execute führt eine Aktion aus
-func (m *Manager) Execute(action config.Action) error {
+// Execute führt eine Aktion aus. Wird der Context abgebrochen, kehrt Execute sofort zurück,
+// auch wenn der Executor den Abbruch nicht rechtzeitig beachtet.
+func (m *Manager) Execute(ctx context.Context, action config.Action) (Result, error) {
 	m.mutex.RLock()
 	executor, exists := m.executors[action.Type]
 	m.mutex.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("kein Executor für Aktion-Typ '%s' gefunden", action.Type)
+		return Result{}, fmt.Errorf("kein Executor für Aktion-Typ '%s' gefunden", action.Type)
+	}
+
+	m.logger.Debug("Führe Aktion aus", "type", action.Type, "parameters", m.redactParameters(action))
+
+	type outcome struct {
+		result Result
+		err    error
+	}
+
+	start := time.Now()
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := executor.Execute(ctx, action)
+		done <- outcome{result, err}
+	}()
+
+	var result Result
+	select {
+	case <-ctx.Done():
+		return Result{Duration: time.Since(start)}, fmt.Errorf("aktion '%s' abgebrochen: %w", action.Type, ctx.Err())
+	case o := <-done:
+		result = o.result
+		result.Duration = time.Since(start)
+		if o.err != nil {
+			return result, fmt.Errorf("fehler beim Ausführen der Aktion '%s': %w", action.Type, o.err)
+		}
+	}
+
+	m.logger.Info("Aktion erfolgreich ausgeführt",
+		"type", action.Type,
+		"changed", result.Changed,
+		"state", result.State,
+		"duration", result.Duration,
+	)
+	return result, nil
+}
+
+// ExecuteMapping füllt die Parameter einer Aktion mit den Event-Daten aus und führt sie
+// unter Beachtung des Mapping-Timeouts, der Wiederholungs-Einstellungen und des Circuit-Breakers aus
+func (m *Manager) ExecuteMapping(ctx context.Context, mapping config.Mapping, action config.Action, data config.TemplateData) (Result, error) {
+	start := time.Now()
+
+	// Live-Wert des Events in die Parameter übernehmen und Templates ausfüllen
+	rendered, err := action.WithValue(data.Value).Render(data)
+	if err != nil {
+		err = fmt.Errorf("fehler beim Ausfüllen der Parameter: %w", err)
+		m.record(mapping, "", data, action, start, Result{}, err)
+		return Result{}, err
+	}
+
+	if err := m.validateRendered(action, rendered); err != nil {
+		m.record(mapping, "", data, rendered, start, Result{}, err)
+		return Result{}, err
+	}
+
+	ctx, cancel := withMappingTimeout(ctx, mapping)
+	defer cancel()
+
+	result, err := m.executeWithRetry(ctx, mapping, "", rendered)
+	m.record(mapping, "", data, rendered, start, result, err)
+	return result, err
+}
+
+// record nimmt eine ausgeführte Aktion in den Verlauf auf
+func (m *Manager) record(mapping config.Mapping, step string, data config.TemplateData, action config.Action, start time.Time, result Result, err error) {
+	entry := HistoryEntry{
+		Time:       start,
+		Mapping:    mapping.Name,
+		Step:       step,
+		Event:      newEventRecord(data),
+		Action:     action.Type,
+		Parameters: m.redactParameters(action),
+		Duration:   time.Since(start),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		// Ausgaben fehlgeschlagener Befehle bleiben für die Fehlersuche erhalten
+		if result.Output != "" || result.Stderr != "" || result.ExitCode != 0 {
+			entry.Result = &result
+		}
+	} else {
+		entry.Result = &result
+	}
+
+	if err := m.history.Add(entry); err != nil {
+		m.logger.Warn("Fehler beim Schreiben des Verlaufs", "error", err)
+	}
+}
+
+// History gibt den Verlauf der ausgeführten Aktionen zurück
+func (m *Manager) History() *History {
+	return m.history
+}
+
+// Close gibt die Ressourcen des Managers frei
+func (m *Manager) Close() error {
+	return m.history.Close()
+}
+
+// withMappingTimeout begrenzt einen Context auf das Timeout eines Mappings (falls gesetzt)
+func withMappingTimeout(ctx context.Context, mapping config.Mapping) (context.Context, context.CancelFunc) {
+	if mapping.Timeout > 0 {
+		return context.WithTimeout(ctx, time.Duration(mapping.Timeout)*time.Millisecond)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Variables gibt den Speicher der Benutzervariablen zurück
+func (m *Manager) Variables() *Variables {
+	return m.variables
+}
+
+// Reload übernimmt eine neu geladene Konfiguration. Ändern sich Backend, ALSA-Karte, Regler
+// oder Szenen-Datei, werden die Audio-Executors neu erstellt.
+func (m *Manager) Reload(cfg *config.Config) {
+	m.mutex.Lock()
+	previous := m.config
+	m.config = cfg
+	m.mutex.Unlock()
+
+	m.variables.Reconfigure(cfg.Variables)
+	if err := m.history.Reconfigure(historyConfig(cfg)); err != nil {
+		m.logger.Error("Fehler beim Übernehmen der Verlaufs-Konfiguration", "error", err)
+	}
+	if audioBackendChanged(previous, cfg) {
+		if err := m.reloadAudio(cfg); err != nil {
+			m.logger.Error("Fehler beim Wechsel des Audio-Backends", "error", err)
+		}
 	}
+	if ducker, err := m.ducker(); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), duckingRestoreTimeout)
+		if err := ducker.Reconfigure(ctx, cfg.Audio.Ducking); err != nil {
+			m.logger.Warn("Fehler beim Wiederherstellen abgesenkter Streams", "error", err)
+		}
+		cancel()
+	}
+}
 
-	m.logger.Debug("Führe Aktion aus", "type", action.Type, "parameters", action.Parameters)
+// audioBackendChanged prüft ob sich Einstellungen geändert haben, die beim Erstellen der Audio-Executors gelesen werden
+func audioBackendChanged(previous, cfg *config.Config) bool {
+	return previous.Audio.Backend != cfg.Audio.Backend ||
+		previous.Audio.Card != cfg.Audio.Card ||
+		previous.Audio.Control != cfg.Audio.Control ||
+		previous.ScenesPath() != cfg.ScenesPath()
+}
 
-	if err := executor.Execute(action); err != nil {
-		return fmt.Errorf("fehler beim Ausführen der Aktion '%s': %w", action.Type, err)
+// reloadAudio ersetzt die Audio-Executors durch neu erstellte. Schlägt eines fehl, bleiben die bisherigen aktiv
+// und die bereits erstellten werden wieder freigegeben. Die Ducking-Engine läuft weiter und wechselt nur ihr Backend.
+func (m *Manager) reloadAudio(cfg *config.Config) error {
+	var created []Executor
+	build := func(name string, create func() (Executor, error)) error {
+		executor, err := create()
+		if err != nil {
+			return fmt.Errorf("fehler beim Erstellen des %s-Executors: %w", name, err)
+		}
+		created = append(created, executor)
+		return nil
+	}
+	err := build("Volume", func() (Executor, error) { return NewVolumeExecutor(cfg.Audio, m.logger) })
+	if err == nil {
+		err = build("App-Volume", func() (Executor, error) { return NewAppVolumeExecutor(cfg.Audio, m.logger) })
+	}
+	if err == nil {
+		err = build("Audio-Source", func() (Executor, error) { return NewAudioSourceExecutor(cfg.Audio, m.logger) })
+	}
+	if err == nil {
+		err = build("Audio-Scene", func() (Executor, error) { return NewAudioSceneExecutor(cfg.Audio, cfg.ScenesPath(), m.logger) })
 	}
+	var controller AudioController
+	if err == nil {
+		if controller, err = newAudioController(cfg.Audio); err != nil {
+			err = fmt.Errorf("fehler beim Erstellen des Audio-Controllers: %w", err)
+		}
+	}
+	if err != nil {
+		m.closeExecutors(created)
+		return err
+	}
+
+	if ducker, err := m.ducker(); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), duckingRestoreTimeout)
+		defer cancel()
+		if err := ducker.SetController(ctx, controller); err != nil {
+			m.logger.Warn("Fehler beim Wechsel des Ducking-Backends", "error", err)
+		}
+	} else {
+		closeController(controller)
+	}
+
+	var replaced []Executor
+	for _, executor := range created {
+		if previous, exists := m.GetExecutor(executor.GetName()); exists {
+			replaced = append(replaced, previous)
+		}
+		m.registerExecutor(executor)
+	}
+
+	// Laufende Lautstärke-Überwachungen des bisherigen Backends beenden
+	m.mutex.Lock()
+	close(m.audioChanged)
+	m.audioChanged = make(chan struct{})
+	m.mutex.Unlock()
+
+	// Laufende Überblendungen abbrechen und die Verbindungen des bisherigen Backends freigeben
+	m.closeExecutors(replaced)
 
-	m.logger.Info("Aktion erfolgreich ausgeführt", "type", action.Type)
+	m.logger.Info("Audio-Backend gewechselt", "backend", cfg.Audio.Backend)
 	return nil
 }
 
+// closeExecutors gibt die Ressourcen von Executors frei, die nicht mehr verwendet werden
+func (m *Manager) closeExecutors(executors []Executor) {
+	for _, executor := range executors {
+		if closer, ok := executor.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				m.logger.Warn("Fehler beim Schließen des Executors", "type", executor.GetName(), "error", err)
+			}
+		}
+	}
+}
+
 // GetExecutor gibt einen Executor für einen bestimmten Typ zurück
 func (m *Manager) GetExecutor(actionType string) (Executor, bool) {
 	m.mutex.RLock()
@@ -109,6 +415,98 @@ func (m *Manager) GetExecutor(actionType string) (Executor, bool) {
 	return executor, exists
 }
 
+// CurrentAudioSource gibt die aktuelle Standard-Audioquelle einer Richtung zurück
+func (m *Manager) CurrentAudioSource(direction AudioDirection) (AudioSource, error) {
+	executor, exists := m.GetExecutor("audio_source")
+	if !exists {
+		return AudioSource{}, fmt.Errorf("kein Audio-Source-Executor registriert")
+	}
+	audioExecutor, ok := executor.(*AudioSourceExecutor)
+	if !ok {
+		return AudioSource{}, fmt.Errorf("unerwarteter Audio-Source-Executor: %T", executor)
+	}
+	return audioExecutor.GetCurrentSource(direction)
+}
+
+// CurrentVolume gibt Lautstärke und Stummschaltung des Systems zurück
+func (m *Manager) CurrentVolume() (VolumeState, error) {
+	executor, exists := m.GetExecutor("volume")
+	if !exists {
+		return VolumeState{}, fmt.Errorf("kein Volume-Executor registriert")
+	}
+	volumeExecutor, ok := executor.(*VolumeExecutor)
+	if !ok {
+		return VolumeState{}, fmt.Errorf("unerwarteter Volume-Executor: %T", executor)
+	}
+	return volumeExecutor.GetState(context.Background())
+}
+
+// WatchVolume meldet Änderungen der Systemlautstärke, bis ctx endet. Wechselt das Audio-Backend,
+// kehrt WatchVolume mit ErrAudioBackendChanged zurück und muss erneut aufgerufen werden.
+func (m *Manager) WatchVolume(ctx context.Context, onChange func(volume int, muted bool)) error {
+	m.mutex.RLock()
+	changed := m.audioChanged
+	m.mutex.RUnlock()
+
+	executor, exists := m.GetExecutor("volume")
+	if !exists {
+		return fmt.Errorf("kein Volume-Executor registriert")
+	}
+	volumeExecutor, ok := executor.(*VolumeExecutor)
+	if !ok {
+		return fmt.Errorf("unerwarteter Volume-Executor: %T", executor)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-changed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := volumeExecutor.WatchVolume(ctx, onChange)
+	select {
+	case <-changed:
+		return ErrAudioBackendChanged
+	default:
+		return err
+	}
+}
+
+// RunDucking senkt andere Anwendungen bei aktivem Mikrofon ab, bis ctx endet
+func (m *Manager) RunDucking(ctx context.Context) error {
+	ducker, err := m.ducker()
+	if err != nil {
+		return err
+	}
+	return ducker.Run(ctx)
+}
+
+// CurrentDucking gibt den Zustand des Duckings zurück
+func (m *Manager) CurrentDucking() (DuckingState, error) {
+	ducker, err := m.ducker()
+	if err != nil {
+		return DuckingState{}, err
+	}
+	return ducker.State(), nil
+}
+
+// ducker gibt die Ducking-Engine des registrierten Executors zurück
+func (m *Manager) ducker() (*Ducker, error) {
+	executor, exists := m.GetExecutor("ducking")
+	if !exists {
+		return nil, fmt.Errorf("kein Ducking-Executor registriert")
+	}
+	duckingExecutor, ok := executor.(*DuckingExecutor)
+	if !ok {
+		return nil, fmt.Errorf("unerwarteter Ducking-Executor: %T", executor)
+	}
+	return duckingExecutor.Ducker(), nil
+}
+
 // GetAvailableTypes gibt alle verfügbaren Aktion-Typen zurück
 func (m *Manager) GetAvailableTypes() []string {
 	m.mutex.RLock()
@@ -136,11 +534,62 @@ func (m *Manager) ValidateAction(action config.Action) error {
 	return nil
 }
 
-// ActionValidator definiert die Schnittstelle für Aktion-Validierung
+// ValidateMappings überprüft die Aktionen aller Mappings mit den Executors, damit ungültige
+// Parameter (z.B. unbekannte Tasten) schon beim Laden und nicht erst beim Auslösen auffallen.
+// Aktionen mit Templates werden übersprungen, da ihre Parameter erst bei der Ausführung feststehen.
+func (m *Manager) ValidateMappings(mappings []config.Mapping) error {
+	for i, mapping := range mappings {
+		var actions []config.Action
+		var names []string
+		if mapping.Macro != nil {
+			for j, step := range mapping.Macro.Steps {
+				actions = append(actions, step.Action)
+				names = append(names, fmt.Sprintf("schritt %d", j+1))
+			}
+		} else {
+			actions = append(actions, mapping.Action)
+			names = append(names, "aktion")
+		}
+		if mapping.OffAction != nil {
+			actions = append(actions, *mapping.OffAction)
+			names = append(names, "off_action")
+		}
+
+		for j, action := range actions {
+			action = action.WithValue(0)
+			if action.HasTemplates() {
+				continue
+			}
+			if err := m.ValidateAction(action); err != nil {
+				return fmt.Errorf("ungültiges Mapping %d (%s): %s: %w", i, mapping.Name, names[j], err)
+			}
+		}
+	}
+	return nil
+}
+
+// ActionValidator definiert die Schnittstelle für Aktion-Validierung. Validate prüft nur die
+// Parameter und fragt keine Backends ab, da sie beim Laden der Konfiguration aufgerufen wird.
 type ActionValidator interface {
 	Validate(action config.Action) error
 }
 
+// ParameterRedactor wird von Executors implementiert, deren Parameter vertrauliche Werte enthalten
+// können; Verlauf und Logs erhalten nur die bereinigten Parameter
+type ParameterRedactor interface {
+	RedactParameters(parameters map[string]interface{}) map[string]interface{}
+}
+
+// redactParameters gibt die Parameter einer Aktion für Verlauf und Logs zurück
+func (m *Manager) redactParameters(action config.Action) map[string]interface{} {
+	if executor, exists := m.GetExecutor(action.Type); exists {
+		if redactor, ok := executor.(ParameterRedactor); ok {
+			return redactor.RedactParameters(action.Parameters)
+		}
+	}
+	return action.Parameters
+}
+
 // BaseExecutor bietet grundlegende Funktionalität für Executors
 type BaseExecutor struct {
 	name   string
@@ -170,6 +619,11 @@ func (e *BaseExecutor) LogInfo(msg string, fields ...interface{}) {
 	e.logger.Info(msg, fields...)
 }
 
+// LogWarn loggt eine Warnung
+func (e *BaseExecutor) LogWarn(msg string, fields ...interface{}) {
+	e.logger.Warn(msg, fields...)
+}
+
 // LogError loggt eine Fehler-Nachricht
 func (e *BaseExecutor) LogError(msg string, fields ...interface{}) {
 	e.logger.Error(msg, fields...)
@@ -0,0 +1,227 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/internal/pulse"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// blockingExecutor ignoriert den Context und blockiert bis zum Testende
+type blockingExecutor struct {
+	release chan struct{}
+}
+
+func (e *blockingExecutor) GetName() string { return "blocking" }
+
+func (e *blockingExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
+	<-e.release
+	return Result{Changed: true}, nil
+}
+
+func TestExecuteMappingTimeout(t *testing.T) {
+	manager, err := NewManager(&config.Config{}, utils.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	executor := &blockingExecutor{release: make(chan struct{})}
+	defer close(executor.release)
+	manager.registerExecutor(executor)
+
+	mapping := config.Mapping{Name: "slow", Timeout: 20}
+	start := time.Now()
+	_, err = manager.ExecuteMapping(context.Background(), mapping, config.Action{Type: "blocking"}, config.TemplateData{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timeout not enforced, took %v", elapsed)
+	}
+}
+
+// flakyExecutor schlägt fehl, solange failing gesetzt ist
+type flakyExecutor struct {
+	calls   int
+	failing bool
+}
+
+func (e *flakyExecutor) GetName() string { return "flaky" }
+
+func (e *flakyExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
+	e.calls++
+	if e.failing {
+		return Result{}, errors.New("backend nicht erreichbar")
+	}
+	return Result{Changed: true}, nil
+}
+
+func TestRetryAndCircuitBreaker(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.General.Retry = config.RetryConfig{Attempts: 3, Backoff: 1, Multiplier: 1}
+	cfg.General.Breaker = config.BreakerConfig{Threshold: 2, Cooldown: 30}
+
+	manager, err := NewManager(cfg, utils.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	executor := &flakyExecutor{failing: true}
+	manager.registerExecutor(executor)
+
+	ctx := context.Background()
+	mapping := config.Mapping{Name: "flaky"}
+	action := config.Action{Type: "flaky"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := manager.ExecuteMapping(ctx, mapping, action, config.TemplateData{}); err == nil {
+			t.Fatalf("expected failure")
+		}
+	}
+	if executor.calls != 6 {
+		t.Fatalf("expected 6 attempts, got %d", executor.calls)
+	}
+	if state := manager.BreakerStates()["flaky"].State; state != BreakerOpen {
+		t.Fatalf("expected open breaker, got %s", state)
+	}
+
+	if _, err := manager.ExecuteMapping(ctx, mapping, action, config.TemplateData{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if executor.calls != 6 {
+		t.Fatalf("open breaker must not call the executor")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	executor.failing = false
+	if _, err := manager.ExecuteMapping(ctx, mapping, action, config.TemplateData{}); err != nil {
+		t.Fatalf("expected recovery after cooldown, got %v", err)
+	}
+	if state := manager.BreakerStates()["flaky"].State; state != BreakerClosed {
+		t.Fatalf("expected closed breaker, got %s", state)
+	}
+
+	// Ein defektes Mapping sperrt andere Mappings desselben Aktionstyps nicht
+	executor.failing = true
+	for i := 0; i < 2; i++ {
+		manager.ExecuteMapping(ctx, mapping, action, config.TemplateData{})
+	}
+	executor.failing = false
+	if _, err := manager.ExecuteMapping(ctx, config.Mapping{Name: "other"}, action, config.TemplateData{}); err != nil {
+		t.Fatalf("expected other mapping to run, got %v", err)
+	}
+	if state := manager.BreakerStates()["flaky"].State; state != BreakerOpen {
+		t.Fatalf("expected open breaker for the failing mapping, got %s", state)
+	}
+}
+
+// commandFailingExecutor beendet jeden Aufruf mit einem Exit-Code
+type commandFailingExecutor struct {
+	calls int
+}
+
+func (e *commandFailingExecutor) GetName() string { return "exit" }
+
+func (e *commandFailingExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
+	e.calls++
+	return Result{ExitCode: 1}, &CommandError{Command: "false", ExitCode: 1}
+}
+
+func (e *commandFailingExecutor) Validate(action config.Action) error {
+	if _, ok := action.Parameters["invalid"]; ok {
+		return errors.New("ungültig")
+	}
+	return nil
+}
+
+func TestRetrySkipsPermanentErrors(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.General.Retry = config.RetryConfig{Attempts: 3, Backoff: 1, Multiplier: 1}
+	cfg.General.Breaker = config.BreakerConfig{Threshold: 1, Cooldown: 30000}
+
+	manager, err := NewManager(cfg, utils.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	executor := &commandFailingExecutor{}
+	manager.registerExecutor(executor)
+
+	ctx := context.Background()
+	mapping := config.Mapping{Name: "exit"}
+	for i := 0; i < 2; i++ {
+		var commandErr *CommandError
+		if _, err := manager.ExecuteMapping(ctx, mapping, config.Action{Type: "exit"}, config.TemplateData{}); !errors.As(err, &commandErr) {
+			t.Fatalf("expected CommandError, got %v", err)
+		}
+	}
+	if executor.calls != 2 {
+		t.Fatalf("exit codes must not be retried, got %d calls", executor.calls)
+	}
+
+	// Erst durch Templates ungültige Parameter werden vor der Ausführung erkannt
+	invalid := config.Action{Type: "exit", Parameters: map[string]interface{}{"invalid": "{{.Value}}"}}
+	var parameterErr *ParameterError
+	if _, err := manager.ExecuteMapping(ctx, mapping, invalid, config.TemplateData{}); !errors.As(err, &parameterErr) {
+		t.Fatalf("expected ParameterError, got %v", err)
+	}
+	if executor.calls != 2 {
+		t.Fatalf("invalid parameters must not reach the executor")
+	}
+
+	// Feste Parameter sind beim Laden geprüft und kosten vor jeder Ausführung keine weitere Prüfung
+	static := config.Action{Type: "exit", Parameters: map[string]interface{}{"invalid": true}}
+	if _, err := manager.ExecuteMapping(ctx, mapping, static, config.TemplateData{}); errors.As(err, &parameterErr) || executor.calls != 3 {
+		t.Fatalf("expected static parameters to reach the executor, got %v after %d calls", err, executor.calls)
+	}
+	if state := manager.BreakerStates()["exit"].State; state != BreakerClosed {
+		t.Fatalf("permanent errors must not open the breaker, got %s", state)
+	}
+}
+
+func TestReloadAudioBackend(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Audio-Backends gibt es nur unter Linux")
+	}
+	cfg := &config.Config{Audio: config.AudioConfig{Backend: config.AudioBackendPulse}}
+	cfg.Audio.Ducking = config.DuckingConfig{Enabled: true, Amount: 50}
+	manager, err := NewManager(cfg, utils.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	ctx := context.Background()
+	ducker, _ := manager.ducker()
+	controller := newDuckingTestController()
+	ducker.SetController(ctx, controller)
+	ducker.tick(ctx, time.Now())
+	volume, _ := manager.GetExecutor("volume")
+
+	// Ohne Änderung am Backend bleiben Executors und abgesenkte Streams erhalten
+	unchanged := *cfg
+	manager.Reload(&unchanged)
+	if executor, _ := manager.GetExecutor("volume"); executor != volume || controller.streams[0].Volume != 40 {
+		t.Fatalf("executors replaced without backend change")
+	}
+
+	// Ein anderes Backend ersetzt die Executors; die Ducking-Engine stellt über das alte Backend wieder her
+	changed := *cfg
+	changed.Audio.Backend = config.AudioBackendAmixer
+	manager.Reload(&changed)
+	if executor, _ := manager.GetExecutor("volume"); executor == volume {
+		t.Fatalf("volume executor not replaced after backend change")
+	}
+	if current, _ := manager.ducker(); current != ducker || controller.streams[0].Volume != 80 {
+		t.Fatalf("ducked streams not restored on backend change: %+v", controller.streams)
+	}
+
+	// Die ersetzten Executors geben ihre Verbindung zum Sound-Server frei
+	if conn := volume.(*VolumeExecutor).volumeController.(*linuxVolumeController).conn; !conn.closed {
+		t.Fatalf("connection of replaced volume executor not closed")
+	}
+	if _, err := volume.(*VolumeExecutor).GetState(ctx); !errors.Is(err, pulse.ErrClosed) {
+		t.Fatalf("replaced executor must not reconnect, got %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält den Mouse-Executor für Zeigerbewegungen, Klicks und das Mausrad.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// MouseExecutor verwaltet simulierte Mauseingaben
+type MouseExecutor struct {
+	BaseExecutor
+	mouse MouseController
+}
+
+// MouseController definiert die Schnittstelle für plattformspezifische Mauseingaben.
+// Positive Werte bewegen bzw. scrollen nach rechts und unten.
+type MouseController interface {
+	Move(ctx context.Context, x, y int, absolute bool) error
+	Button(ctx context.Context, button string, press bool) error
+	Scroll(ctx context.Context, dx, dy int) error
+}
+
+// mouseAction enthält die ausgewerteten Parameter einer Mouse-Aktion
+type mouseAction struct {
+	operation string
+	x, y      int
+	absolute  bool
+	// position gibt an, ob vor dem Klicken bzw. Drücken bewegt wird
+	position bool
+	button   string
+	count    int
+	steps    int
+	duration time.Duration
+}
+
+// NewMouseExecutor erstellt einen neuen Mouse-Executor
+func NewMouseExecutor(input config.InputConfig, logger utils.Logger) (*MouseExecutor, error) {
+	// Plattformspezifischen Mouse-Controller erstellen
+	controller, err := newMouseController(input)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Erstellen des Mouse-Controllers: %w", err)
+	}
+
+	executor := &MouseExecutor{
+		BaseExecutor: NewBaseExecutor("mouse", logger),
+		mouse:        controller,
+	}
+
+	return executor, nil
+}
+
+// Execute führt eine Mouse-Aktion aus
+func (e *MouseExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
+	e.LogDebug("Führe Mouse-Aktion aus", "parameters", action.Parameters)
+
+	m, err := parseMouseAction(action)
+	if err != nil {
+		return Result{}, err
+	}
+
+	switch m.operation {
+	case "move":
+		e.LogInfo("Bewege Mauszeiger", "x", m.x, "y", m.y, "absolute", m.absolute)
+		err = e.mouse.Move(ctx, m.x, m.y, m.absolute)
+
+	case "click":
+		e.LogInfo("Klicke Maustaste", "button", m.button, "count", m.count)
+		if err = e.moveTo(ctx, m); err == nil {
+			err = e.click(ctx, m.button, m.count)
+		}
+
+	case "press":
+		e.LogInfo("Drücke Maustaste", "button", m.button)
+		if err = e.moveTo(ctx, m); err == nil {
+			err = e.mouse.Button(ctx, m.button, true)
+		}
+
+	case "release":
+		e.LogInfo("Lasse Maustaste los", "button", m.button)
+		err = e.mouse.Button(ctx, m.button, false)
+
+	case "drag":
+		e.LogInfo("Ziehe mit gedrückter Maustaste", "button", m.button, "x", m.x, "y", m.y, "absolute", m.absolute)
+		err = e.drag(ctx, m)
+
+	case "scroll":
+		e.LogInfo("Scrolle", "x", m.x, "y", m.y)
+		err = e.mouse.Scroll(ctx, m.x, m.y)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{Changed: true}, nil
+}
+
+// moveTo bewegt den Zeiger vor einem Klick, falls eine Position angegeben ist
+func (e *MouseExecutor) moveTo(ctx context.Context, m mouseAction) error {
+	if !m.position {
+		return nil
+	}
+	return e.mouse.Move(ctx, m.x, m.y, m.absolute)
+}
+
+// click drückt eine Maustaste count-mal (2 = Doppelklick)
+func (e *MouseExecutor) click(ctx context.Context, button string, count int) error {
+	for i := 0; i < count; i++ {
+		if err := e.withButton(ctx, button, func() error { return nil }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drag hält die Maustaste gedrückt und bewegt den Zeiger in steps Schritten über duration.
+// Relative Bewegungen werden aufgeteilt, damit Programme die Zwischenpositionen sehen;
+// die Startposition absoluter Bewegungen ist unbekannt, sie springen direkt zum Ziel.
+func (e *MouseExecutor) drag(ctx context.Context, m mouseAction) error {
+	return e.withButton(ctx, m.button, func() error {
+		if m.absolute {
+			return e.mouse.Move(ctx, m.x, m.y, true)
+		}
+		for i := 1; i <= m.steps; i++ {
+			if i > 1 && m.duration > 0 {
+				if err := sleepContext(ctx, m.duration/time.Duration(m.steps)); err != nil {
+					return err
+				}
+			}
+			dx := m.x*i/m.steps - m.x*(i-1)/m.steps
+			dy := m.y*i/m.steps - m.y*(i-1)/m.steps
+			if err := e.mouse.Move(ctx, dx, dy, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withButton drückt eine Maustaste, führt fn aus und lässt die Taste wieder los.
+// Das Loslassen geschieht auch nach Fehlern und bei Abbruch des Context.
+func (e *MouseExecutor) withButton(ctx context.Context, button string, fn func() error) error {
+	if err := e.mouse.Button(ctx, button, true); err != nil {
+		return err
+	}
+	err := fn()
+	if releaseErr := e.mouse.Button(context.WithoutCancel(ctx), button, false); releaseErr != nil {
+		err = errors.Join(err, releaseErr)
+	}
+	return err
+}
+
+// Validate überprüft eine Mouse-Aktion auf Gültigkeit
+func (e *MouseExecutor) Validate(action config.Action) error {
+	m, err := parseMouseAction(action)
+	if err != nil {
+		return err
+	}
+	if m.operation == "scroll" || m.operation == "move" {
+		return nil
+	}
+	_, err = mouseButton(m.button, runtime.GOOS)
+	return err
+}
+
+// parseMouseAction liest und prüft die Parameter einer Mouse-Aktion
+func parseMouseAction(action config.Action) (mouseAction, error) {
+	var m mouseAction
+	var ok bool
+	if m.operation, ok = action.Parameters["operation"].(string); !ok {
+		return m, fmt.Errorf("mouse-Aktion benötigt 'operation' Parameter")
+	}
+	switch m.operation {
+	case "move", "click", "press", "release", "drag", "scroll":
+	default:
+		return m, fmt.Errorf("ungültige Operation: %s (erwartet: move, click, press, release, drag, scroll)", m.operation)
+	}
+
+	var err error
+	if m.x, err = mouseAxisParameter(action, "x"); err != nil {
+		return m, err
+	}
+	if m.y, err = mouseAxisParameter(action, "y"); err != nil {
+		return m, err
+	}
+
+	if absolute, ok := action.Parameters["absolute"]; ok {
+		if m.absolute, ok = absolute.(bool); !ok {
+			return m, fmt.Errorf("'absolute' Parameter muss true oder false sein")
+		}
+	}
+	if m.absolute && (m.x < 0 || m.y < 0) {
+		return m, fmt.Errorf("absolute Position darf nicht negativ sein: %d,%d", m.x, m.y)
+	}
+	if m.absolute && m.operation == "scroll" {
+		return m, fmt.Errorf("'scroll' kann nicht absolut sein")
+	}
+	_, hasX := action.Parameters["x"]
+	_, hasY := action.Parameters["y"]
+	m.position = hasX || hasY || m.absolute
+
+	if button, ok := action.Parameters["button"]; ok {
+		if m.button, ok = button.(string); !ok {
+			return m, fmt.Errorf("'button' Parameter muss ein String sein")
+		}
+	} else {
+		m.button = "left"
+	}
+
+	if m.count, err = intParameter(action, "count", 1); err != nil {
+		return m, err
+	}
+	if m.count < 1 || m.count > 10 {
+		return m, fmt.Errorf("count muss zwischen 1 und 10 liegen")
+	}
+	if m.steps, err = intParameter(action, "steps", 10); err != nil {
+		return m, err
+	}
+	if m.steps < 1 {
+		return m, fmt.Errorf("steps muss mindestens 1 sein")
+	}
+	if m.duration, err = millisecondsParameter(action, "duration", 100*time.Millisecond); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// mouseAxisParameter liest x bzw. y. Mit "encoder" ist der Wert der eines Endlos-Drehreglers
+// (z.B. über "value" aus dem Event übernommen) und wird in eine Schrittzahl umgerechnet.
+func mouseAxisParameter(action config.Action, name string) (int, error) {
+	value, err := intParameter(action, name, 0)
+	if err != nil {
+		return 0, err
+	}
+	encoder, ok := action.Parameters["encoder"]
+	if _, present := action.Parameters[name]; !ok || !present {
+		return value, nil
+	}
+	mode, ok := encoder.(string)
+	if !ok {
+		return 0, fmt.Errorf("'encoder' Parameter muss ein String sein")
+	}
+	return decodeEncoder(mode, value)
+}
+
+// decodeEncoder wandelt den Wert eines Endlos-Drehreglers (Relative-Modus) in eine Schrittzahl:
+// "twos_complement" (1 = +1, 127 = -1), "signed_bit" (1 = +1, 65 = -1) oder "offset" (65 = +1, 63 = -1)
+func decodeEncoder(mode string, value int) (int, error) {
+	if value < 0 || value > 127 {
+		return 0, fmt.Errorf("ungültiger Encoder-Wert: %d (muss zwischen 0 und 127 liegen)", value)
+	}
+	switch mode {
+	case "twos_complement":
+		if value >= 64 {
+			return value - 128, nil
+		}
+		return value, nil
+	case "signed_bit":
+		if value >= 64 {
+			return -(value - 64), nil
+		}
+		return value, nil
+	case "offset":
+		return value - 64, nil
+	default:
+		return 0, fmt.Errorf("ungültiger encoder: %s (erwartet: twos_complement, signed_bit, offset)", mode)
+	}
+}
+
+// mouseButton löst eine Maustaste auf: "left", "right", "middle", "back", "forward" oder
+// ein Name aus dem Tastenvokabular wie "MOUSE4"
+func mouseButton(button, goos string) (keyDef, error) {
+	def, err := lookupKey("MOUSE" + button)
+	if err != nil || def.button == 0 {
+		if def, err = lookupKey(button); err != nil {
+			return keyDef{}, err
+		}
+	}
+	if def.button == 0 {
+		return keyDef{}, fmt.Errorf("%s ist keine Maustaste", button)
+	}
+	if !def.availableOn(goos) {
+		return keyDef{}, fmt.Errorf("maustaste %s ist unter %s nicht verfügbar", button, goos)
+	}
+	return def, nil
+}
+
+// newMouseController erstellt einen plattformspezifischen Mouse-Controller
+func newMouseController(input config.InputConfig) (MouseController, error) {
+	switch runtime.GOOS {
+	case "windows":
+		return &windowsMouseController{}, nil
+	case "linux":
+		return newLinuxMouseController(input.Desktop), nil
+	default:
+		return nil, fmt.Errorf("plattform %s wird nicht unterstützt", runtime.GOOS)
+	}
+}
+
+// Windows-spezifische Mouse-Controller-Implementierung
+type windowsMouseController struct{}
+
+func (c *windowsMouseController) Move(ctx context.Context, x, y int, absolute bool) error {
+	// TODO: Implementierung mit Windows API
+	// - SendInput mit MOUSEEVENTF_MOVE (absolut zusätzlich MOUSEEVENTF_ABSOLUTE, Koordinaten 0-65535)
+	return nil
+}
+
+func (c *windowsMouseController) Button(ctx context.Context, button string, press bool) error {
+	if _, err := mouseButton(button, "windows"); err != nil {
+		return err
+	}
+	// TODO: Implementierung mit Windows API
+	// - SendInput mit MOUSEEVENTF_LEFTDOWN/-UP usw.
+	return nil
+}
+
+func (c *windowsMouseController) Scroll(ctx context.Context, dx, dy int) error {
+	// TODO: Implementierung mit Windows API
+	// - SendInput mit MOUSEEVENTF_WHEEL bzw. MOUSEEVENTF_HWHEEL (WHEEL_DELTA je Raste)
+	return nil
+}
@@ -0,0 +1,168 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// recordingMouse zeichnet Mauseingaben auf
+type recordingMouse struct {
+	events []string
+}
+
+func (m *recordingMouse) Move(ctx context.Context, x, y int, absolute bool) error {
+	if absolute {
+		m.events = append(m.events, fmt.Sprintf("@%d,%d", x, y))
+	} else {
+		m.events = append(m.events, fmt.Sprintf("~%d,%d", x, y))
+	}
+	return nil
+}
+
+func (m *recordingMouse) Button(ctx context.Context, button string, press bool) error {
+	if press {
+		m.events = append(m.events, "+"+button)
+	} else {
+		m.events = append(m.events, "-"+button)
+	}
+	return nil
+}
+
+func (m *recordingMouse) Scroll(ctx context.Context, dx, dy int) error {
+	m.events = append(m.events, fmt.Sprintf("scroll%d,%d", dx, dy))
+	return nil
+}
+
+// fakePointerDevice zeichnet evdev-Ereignisse des virtuellen Zeigers auf
+type fakePointerDevice struct {
+	fakeKeyDevice
+}
+
+func (d *fakePointerDevice) Move(dx, dy int32) error {
+	d.events = append(d.events, fmt.Sprintf("rel%d,%d", dx, dy))
+	return nil
+}
+
+func (d *fakePointerDevice) Scroll(vertical, horizontal int32) error {
+	d.events = append(d.events, fmt.Sprintf("wheel%d,%d", vertical, horizontal))
+	return nil
+}
+
+func (d *fakePointerDevice) MoveTo(x, y int32) error {
+	d.events = append(d.events, fmt.Sprintf("abs%d,%d", x, y))
+	return nil
+}
+
+func TestMouseExecutor(t *testing.T) {
+	mouse := &recordingMouse{}
+	executor := &MouseExecutor{BaseExecutor: NewBaseExecutor("mouse", utils.NewNullLogger()), mouse: mouse}
+
+	tests := []struct {
+		parameters map[string]interface{}
+		want       string
+	}{
+		{map[string]interface{}{"operation": "move", "x": 640, "y": 480, "absolute": true}, "[@640,480]"},
+		{map[string]interface{}{"operation": "click", "button": "right", "count": 2}, "[+right -right +right -right]"},
+		{map[string]interface{}{"operation": "click", "x": 10, "y": -5}, "[~10,-5 +left -left]"},
+		{map[string]interface{}{"operation": "drag", "x": 10, "y": 4, "steps": 3, "duration": 0}, "[+left ~3,1 ~3,1 ~4,2 -left]"},
+		{map[string]interface{}{"operation": "scroll", "y": 127, "encoder": "twos_complement"}, "[scroll0,-1]"},
+	}
+	for _, test := range tests {
+		mouse.events = nil
+		action := config.Action{Type: "mouse", Parameters: test.parameters}
+		if err := executor.Validate(action); err != nil {
+			t.Fatalf("Validate(%v): %v", test.parameters, err)
+		}
+		if _, err := executor.Execute(context.Background(), action); err != nil {
+			t.Fatalf("Execute(%v): %v", test.parameters, err)
+		}
+		if got := fmt.Sprint(mouse.events); got != test.want {
+			t.Fatalf("Execute(%v) = %s, want %s", test.parameters, got, test.want)
+		}
+	}
+
+	// Die Taste wird auch bei abgebrochenem Context losgelassen
+	mouse.events = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	action := config.Action{Type: "mouse", Parameters: map[string]interface{}{"operation": "drag", "x": 100, "steps": 5, "duration": 1000}}
+	if _, err := executor.Execute(ctx, action); err == nil {
+		t.Fatalf("expected error for cancelled drag")
+	}
+	if got := fmt.Sprint(mouse.events); got != "[+left ~20,0 -left]" {
+		t.Fatalf("unexpected events for cancelled drag: %s", got)
+	}
+
+	for _, parameters := range []map[string]interface{}{
+		{"x": 1},
+		{"operation": "wiggle"},
+		{"operation": "click", "button": "ENTER"},
+		{"operation": "move", "x": -1, "absolute": true},
+		{"operation": "scroll", "y": 1, "encoder": "gray"},
+		{"operation": "click", "count": 0},
+	} {
+		if err := executor.Validate(config.Action{Type: "mouse", Parameters: parameters}); err == nil {
+			t.Fatalf("expected error for %v", parameters)
+		}
+	}
+}
+
+func TestDecodeEncoder(t *testing.T) {
+	tests := []struct {
+		mode  string
+		value int
+		want  int
+	}{
+		{"twos_complement", 1, 1},
+		{"twos_complement", 127, -1},
+		{"twos_complement", 65, -63},
+		{"signed_bit", 3, 3},
+		{"signed_bit", 67, -3},
+		{"offset", 64, 0},
+		{"offset", 62, -2},
+	}
+	for _, test := range tests {
+		got, err := decodeEncoder(test.mode, test.value)
+		if err != nil || got != test.want {
+			t.Fatalf("decodeEncoder(%s, %d) = %d, %v, want %d", test.mode, test.value, got, err, test.want)
+		}
+	}
+}
+
+func TestMouseBackends(t *testing.T) {
+	ctx := context.Background()
+
+	display := &fakeX11Display{}
+	x11Mouse := &x11MouseController{display: func(ctx context.Context) (x11Display, error) { return display, nil }}
+	x11Mouse.Move(ctx, 5, -3, false)
+	x11Mouse.Move(ctx, 100000, 20, true)
+	x11Mouse.Scroll(ctx, -1, 2)
+	x11Mouse.Button(ctx, "back", true)
+	if got := fmt.Sprint(display.events); got != "[~5,-3 @32767,20 +b5 -b5 +b5 -b5 +b6 -b6 +b8]" {
+		t.Fatalf("unexpected X11 events: %s", got)
+	}
+
+	device := &fakePointerDevice{}
+	uinputMouse := &uinputMouseController{
+		open:         func() (pointerDevice, error) { return device, nil },
+		openAbsolute: func() (absolutePointerDevice, error) { return device, nil },
+	}
+	uinputMouse.Move(ctx, 200, 100, true)
+	uinputMouse.Move(ctx, -4, 2, false)
+	uinputMouse.Scroll(ctx, 1, 3)
+	uinputMouse.Button(ctx, "middle", true)
+	if got := fmt.Sprint(device.events); got != "[abs200,100 rel-4,2 wheel-3,1 +274]" {
+		t.Fatalf("unexpected uinput events: %s", got)
+	}
+
+	// Ohne Desktopgröße sind absolute Bewegungen über uinput nicht möglich
+	unsized := newUinputMouseController(config.DesktopSize{})
+	if err := unsized.Move(ctx, 10, 10, true); !errors.Is(err, errUinputDesktopUnknown) {
+		t.Fatalf("expected missing desktop size error, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält den Mouse-Controller für uinput (Wayland und Konsole) und die Auswahl des Linux-Backends.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/internal/uinput"
+)
+
+// errUinputDesktopUnknown wird bei absoluten Bewegungen ohne konfigurierte Desktopgröße zurückgegeben
+var errUinputDesktopUnknown = errors.New("absolute Mausbewegungen über uinput benötigen die Desktopgröße " +
+	"(input.desktop.width und input.desktop.height in der Konfiguration)")
+
+// newLinuxMouseController erstellt den Mouse-Controller für die aktuelle Sitzung
+func newLinuxMouseController(desktop config.DesktopSize) MouseController {
+	if keyboardSessionBackend(os.Getenv) == keyboardBackendX11 {
+		return newX11MouseController()
+	}
+	return newUinputMouseController(desktop)
+}
+
+// pointerDevice ist ein Gerät, über das evdev-Maustasten, Bewegungen und das Mausrad gesendet werden
+type pointerDevice interface {
+	keyDevice
+	Move(dx, dy int32) error
+	Scroll(vertical, horizontal int32) error
+}
+
+// absolutePointerDevice ist ein Gerät mit absoluten Koordinaten, dessen Bereich dem Desktop entspricht
+type absolutePointerDevice interface {
+	MoveTo(x, y int32) error
+	Close() error
+}
+
+// uinputMouseController simuliert Mauseingaben über virtuelle uinput-Geräte: relative Bewegungen,
+// Tasten und Mausrad über eine Maus, absolute Positionen über ein zweites Zeigegerät mit absoluten
+// Achsen, das der Compositor auf den gesamten Desktop abbildet.
+type uinputMouseController struct {
+	open         func() (pointerDevice, error)
+	openAbsolute func() (absolutePointerDevice, error)
+	device       pointerDevice
+	absolute     absolutePointerDevice
+	mutex        sync.Mutex
+}
+
+// newUinputMouseController erstellt einen Controller, der die virtuellen Geräte erst bei der ersten Eingabe anlegt
+func newUinputMouseController(desktop config.DesktopSize) *uinputMouseController {
+	return &uinputMouseController{
+		open: func() (pointerDevice, error) {
+			return uinput.Open("MidiDaemon Mouse")
+		},
+		openAbsolute: func() (absolutePointerDevice, error) {
+			if desktop.Width == 0 || desktop.Height == 0 {
+				return nil, errUinputDesktopUnknown
+			}
+			return uinput.OpenAbsolute("MidiDaemon Pointer", int32(desktop.Width), int32(desktop.Height))
+		},
+	}
+}
+
+// Move bewegt den Zeiger relativ bzw. absolut
+func (c *uinputMouseController) Move(ctx context.Context, x, y int, absolute bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if absolute {
+		device, err := c.getAbsolute()
+		if err != nil {
+			return err
+		}
+		return device.MoveTo(clampInt32(max(x, 0)), clampInt32(max(y, 0)))
+	}
+
+	device, err := c.get()
+	if err != nil {
+		return err
+	}
+	return device.Move(clampInt32(x), clampInt32(y))
+}
+
+// Button drückt bzw. löst eine Maustaste
+func (c *uinputMouseController) Button(ctx context.Context, button string, press bool) error {
+	def, err := mouseButton(button, "linux")
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	device, err := c.get()
+	if err != nil {
+		return err
+	}
+	return device.Key(def.evdev, press)
+}
+
+// Scroll dreht das Mausrad; evdev zählt nach oben positiv, daher wird dy umgekehrt
+func (c *uinputMouseController) Scroll(ctx context.Context, dx, dy int) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	device, err := c.get()
+	if err != nil {
+		return err
+	}
+	return device.Scroll(-clampInt32(dy), clampInt32(dx))
+}
+
+// get gibt das virtuelle Gerät zurück und legt es bei Bedarf an
+func (c *uinputMouseController) get() (pointerDevice, error) {
+	if c.device != nil {
+		return c.device, nil
+	}
+	device, err := c.open()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Anlegen der virtuellen Maus: %w", err)
+	}
+	c.device = device
+	return device, nil
+}
+
+// getAbsolute gibt das absolute Zeigegerät zurück und legt es bei Bedarf an
+func (c *uinputMouseController) getAbsolute() (absolutePointerDevice, error) {
+	if c.absolute != nil {
+		return c.absolute, nil
+	}
+	device, err := c.openAbsolute()
+	if errors.Is(err, errUinputDesktopUnknown) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Anlegen des absoluten Zeigegeräts: %w", err)
+	}
+	c.absolute = device
+	return device, nil
+}
+
+// clampInt32 begrenzt einen Wert auf den Wertebereich eines evdev-Ereignisses
+func clampInt32(v int) int32 {
+	return int32(max(math.MinInt32+1, min(math.MaxInt32, v)))
+}
@@ -0,0 +1,101 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält den Mouse-Controller für X11 (XTEST-Erweiterung).
+
+package actions
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+// X11-Maustasten des Mausrads
+const (
+	x11WheelUp    = 4
+	x11WheelDown  = 5
+	x11WheelLeft  = 6
+	x11WheelRight = 7
+)
+
+// x11MouseController simuliert Mauseingaben über die XTEST-Erweiterung des X-Servers
+type x11MouseController struct {
+	display func(ctx context.Context) (x11Display, error)
+	mutex   sync.Mutex
+}
+
+// newX11MouseController erstellt einen Controller für $DISPLAY, der sich erst bei der ersten Eingabe verbindet
+func newX11MouseController() *x11MouseController {
+	conn := &x11Connection{}
+	return &x11MouseController{display: conn.get}
+}
+
+// Move bewegt den Zeiger relativ bzw. absolut auf dem Bildschirm, auf dem er sich befindet
+func (c *x11MouseController) Move(ctx context.Context, x, y int, absolute bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	display, err := c.display(ctx)
+	if err != nil {
+		return err
+	}
+	if err := display.FakeMotion(ctx, clampInt16(x), clampInt16(y), !absolute); err != nil {
+		return err
+	}
+	return display.Sync(ctx)
+}
+
+// Button drückt bzw. löst eine Maustaste
+func (c *x11MouseController) Button(ctx context.Context, button string, press bool) error {
+	def, err := mouseButton(button, "linux")
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	display, err := c.display(ctx)
+	if err != nil {
+		return err
+	}
+	if err := display.FakeButton(ctx, def.button, press); err != nil {
+		return err
+	}
+	return display.Sync(ctx)
+}
+
+// Scroll dreht das Mausrad; X11 bildet jede Raste als Klick der Tasten 4-7 ab
+func (c *x11MouseController) Scroll(ctx context.Context, dx, dy int) error {
+	var inputs []x11Input
+	inputs = appendWheel(inputs, dy, x11WheelDown, x11WheelUp)
+	inputs = appendWheel(inputs, dx, x11WheelRight, x11WheelLeft)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	display, err := c.display(ctx)
+	if err != nil {
+		return err
+	}
+	for _, input := range inputs {
+		if err := pressKeys(ctx, display, []x11Input{input}, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendWheel hängt |steps| Klicks der Taste für die positive bzw. negative Richtung an
+func appendWheel(inputs []x11Input, steps int, positive, negative uint8) []x11Input {
+	button := positive
+	if steps < 0 {
+		button, steps = negative, -steps
+	}
+	for i := 0; i < steps; i++ {
+		inputs = append(inputs, x11Input{button: true, detail: button})
+	}
+	return inputs
+}
+
+// clampInt16 begrenzt eine Koordinate auf den Wertebereich des X11-Protokolls
+func clampInt16(v int) int16 {
+	return int16(max(math.MinInt16, min(math.MaxInt16, v)))
+}
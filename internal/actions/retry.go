@@ -0,0 +1,270 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält Wiederholungen und den Circuit-Breaker für fehlschlagende Aktionen.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+// ErrCircuitOpen wird zurückgegeben, wenn ein Mapping bzw. Makro-Schritt durch den Circuit-Breaker gesperrt ist
+var ErrCircuitOpen = errors.New("circuit-breaker offen")
+
+// ParameterError meldet eine Aktion mit ungültigen Parametern. Eine Wiederholung kann daran nichts ändern.
+type ParameterError struct {
+	Type string
+	Err  error
+}
+
+// Error gibt den Aktionstyp und den Grund zurück
+func (e *ParameterError) Error() string {
+	return fmt.Sprintf("ungültige Parameter für Aktion '%s': %v", e.Type, e.Err)
+}
+
+// Unwrap gibt den ursprünglichen Fehler zurück
+func (e *ParameterError) Unwrap() error {
+	return e.Err
+}
+
+// isPermanent gibt zurück, ob ein Fehler nicht vorübergehend ist: Befehle, die mit einem Exit-Code
+// beendet wurden, schlagen bei einer Wiederholung genauso fehl und sagen nichts über den Zustand
+// eines Backends aus
+func isPermanent(err error) bool {
+	var commandErr *CommandError
+	return errors.As(err, &commandErr)
+}
+
+// validateRendered prüft eine Aktion, deren Parameter erst durch Templates feststehen. Aktionen ohne
+// Templates wurden bereits beim Laden der Konfiguration geprüft und werden nicht erneut geprüft.
+func (m *Manager) validateRendered(action, rendered config.Action) error {
+	if !action.HasTemplates() {
+		return nil
+	}
+	if err := m.ValidateAction(rendered); err != nil {
+		return &ParameterError{Type: rendered.Type, Err: err}
+	}
+	return nil
+}
+
+// Zustände eines Circuit-Breakers
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// BreakerStatus beschreibt den Zustand des Circuit-Breakers eines Mappings bzw. Makro-Schritts
+type BreakerStatus struct {
+	State     string    `json:"state"`
+	Failures  int       `json:"failures"`
+	LastError string    `json:"last_error,omitempty"`
+	OpenUntil time.Time `json:"open_until,omitempty"`
+}
+
+// breaker sperrt ein Mapping bzw. einen Makro-Schritt nach wiederholten Fehlschlägen für eine Sperrzeit
+type breaker struct {
+	state     string
+	failures  int
+	lastError string
+	openUntil time.Time
+	probing   bool
+	mutex     sync.Mutex
+}
+
+// newBreaker erstellt einen geschlossenen Circuit-Breaker
+func newBreaker() *breaker {
+	return &breaker{state: BreakerClosed}
+}
+
+// allow prüft ob eine Ausführung erlaubt ist; nach Ablauf der Sperrzeit wird genau ein Testversuch zugelassen
+func (b *breaker) allow(now time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if now.Before(b.openUntil) {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success setzt den Breaker zurück und gibt zurück, ob er vorher nicht geschlossen war
+func (b *breaker) success() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	recovered := b.state != BreakerClosed
+	b.state = BreakerClosed
+	b.failures = 0
+	b.lastError = ""
+	b.probing = false
+	return recovered
+}
+
+// failure zählt einen Fehlschlag und gibt zurück, ob der Breaker dadurch geöffnet wurde
+func (b *breaker) failure(err error, cfg config.BreakerConfig, now time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures++
+	b.lastError = err.Error()
+	b.probing = false
+
+	if !cfg.Enabled() {
+		return false
+	}
+	if b.state == BreakerHalfOpen || b.failures >= cfg.Threshold {
+		opened := b.state != BreakerOpen
+		b.state = BreakerOpen
+		b.openUntil = now.Add(time.Duration(cfg.Cooldown) * time.Millisecond)
+		return opened
+	}
+	return false
+}
+
+// release gibt einen Testversuch frei, der ohne Ergebnis abgebrochen wurde
+func (b *breaker) release() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.probing = false
+}
+
+// status gibt den aktuellen Zustand zurück
+func (b *breaker) status() BreakerStatus {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	status := BreakerStatus{State: b.state, Failures: b.failures, LastError: b.lastError}
+	if b.state == BreakerOpen {
+		status.OpenUntil = b.openUntil
+	}
+	return status
+}
+
+// breakerKey gibt den Schlüssel des Circuit-Breakers eines Mappings bzw. Makro-Schritts zurück
+// ("mapping" bzw. "mapping/schritt"), damit ein defektes Mapping nicht alle Mappings desselben
+// Aktionstyps sperrt. Unbenannte Mappings teilen sich den Breaker ihres Aktionstyps.
+func breakerKey(mapping config.Mapping, step string, action config.Action) string {
+	key := mapping.Name
+	if key == "" {
+		key = action.Type
+	}
+	if step != "" {
+		key += "/" + step
+	}
+	return key
+}
+
+// breakerFor gibt den Circuit-Breaker zu einem Schlüssel aus breakerKey zurück
+func (m *Manager) breakerFor(key string) *breaker {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	b, exists := m.breakers[key]
+	if !exists {
+		b = newBreaker()
+		m.breakers[key] = b
+	}
+	return b
+}
+
+// executeWithRetry führt eine Aktion eines Mappings bzw. Makro-Schritts (step) mit Wiederholungen
+// aus und berücksichtigt den Circuit-Breaker. Exit-Codes von Befehlen werden weder wiederholt noch
+// vom Circuit-Breaker gezählt.
+func (m *Manager) executeWithRetry(ctx context.Context, mapping config.Mapping, step string, action config.Action) (Result, error) {
+	m.mutex.RLock()
+	cfg := m.config
+	m.mutex.RUnlock()
+
+	key := breakerKey(mapping, step, action)
+	b := m.breakerFor(key)
+	if !b.allow(time.Now()) {
+		return Result{}, fmt.Errorf("aktion '%s' von '%s' vorübergehend gesperrt: %w", action.Type, key, ErrCircuitOpen)
+	}
+
+	policy := cfg.RetryPolicy(mapping, action.Type)
+	attempts := policy.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var result Result
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		result, err = m.Execute(ctx, action)
+		if err == nil {
+			if b.success() {
+				m.logger.Info("Circuit-Breaker geschlossen, Aktion funktioniert wieder", "type", action.Type, "breaker", key)
+			}
+			return result, nil
+		}
+
+		// Abbruch und dauerhafte Fehler sind keine Fehler des Backends
+		if ctx.Err() != nil || isPermanent(err) {
+			b.release()
+			return result, err
+		}
+
+		if attempt < attempts {
+			delay := policy.Delay(attempt)
+			m.logger.Debug("Wiederhole Aktion",
+				"type", action.Type,
+				"mapping", mapping.Name,
+				"attempt", attempt+1,
+				"of", attempts,
+				"delay", delay,
+				"error", err,
+			)
+			if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+				b.release()
+				return result, err
+			}
+		}
+	}
+
+	if b.failure(err, cfg.General.Breaker, time.Now()) {
+		m.logger.Warn("Circuit-Breaker geöffnet, Aktion vorübergehend gesperrt",
+			"type", action.Type,
+			"breaker", key,
+			"failures", b.status().Failures,
+			"cooldown", time.Duration(cfg.General.Breaker.Cooldown)*time.Millisecond,
+			"error", err,
+		)
+	}
+	return result, err
+}
+
+// BreakerStates gibt den Zustand der Circuit-Breaker aller bisher ausgeführten Mappings bzw.
+// Makro-Schritte zurück (Schlüssel "mapping" bzw. "mapping/schritt")
+func (m *Manager) BreakerStates() map[string]BreakerStatus {
+	m.mutex.RLock()
+	breakers := make(map[string]*breaker, len(m.breakers))
+	for key, b := range m.breakers {
+		breakers[key] = b
+	}
+	m.mutex.RUnlock()
+
+	states := make(map[string]BreakerStatus, len(breakers))
+	for key, b := range breakers {
+		states[key] = b.status()
+	}
+	return states
+}
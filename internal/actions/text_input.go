@@ -0,0 +1,186 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält die Texteingabe mit Escapes für Sondertasten.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+// textSegment ist ein Abschnitt eines zu tippenden Texts: entweder Zeichen oder eine Tastenkombination
+type textSegment struct {
+	text string
+	keys []string
+}
+
+// textBraces sind die Escapes für geschweifte Klammern. "{{" scheidet aus, da Parameter mit "{{"
+// als Template ausgewertet werden.
+var textBraces = map[string]string{"LBRACE": "{", "RBRACE": "}"}
+
+// parseTextInput zerlegt einen Text in Zeichen und Sondertasten. Sondertasten stehen in
+// geschweiften Klammern ("{ENTER}", "{CTRL+A}"), "{LBRACE}" und "{RBRACE}" für die Klammern selbst.
+// Eine einzelne schließende Klammer wird unverändert getippt.
+func parseTextInput(text string) ([]textSegment, error) {
+	var segments []textSegment
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, textSegment{text: current.String()})
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == '{' {
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("nicht geschlossene Klammer an Position %d (\"{LBRACE}\" für eine Klammer)", i)
+			}
+			escape := text[i+1 : i+end]
+			if brace, ok := textBraces[strings.ToUpper(strings.TrimSpace(escape))]; ok {
+				current.WriteString(brace)
+				i += end
+				continue
+			}
+			keys := splitKeyEscape(escape)
+			if len(keys) == 0 {
+				return nil, fmt.Errorf("leere Sondertaste an Position %d", i)
+			}
+			i += end
+			flush()
+			segments = append(segments, textSegment{keys: keys})
+			continue
+		}
+		current.WriteByte(text[i])
+	}
+	flush()
+	return segments, nil
+}
+
+// splitKeyEscape zerlegt den Inhalt einer Sondertaste wie "CTRL+SHIFT+T" in Tasten. "+" allein
+// bzw. am Ende ("CTRL++") bezeichnet die Plus-Taste.
+func splitKeyEscape(escape string) []string {
+	escape = strings.TrimSpace(escape)
+	if escape == "" {
+		return nil
+	}
+	if escape == "+" {
+		return []string{"+"}
+	}
+	plus := strings.HasSuffix(escape, "++")
+	if plus {
+		escape = strings.TrimSuffix(escape, "++")
+	}
+	var keys []string
+	for _, key := range strings.Split(escape, "+") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if plus {
+		keys = append(keys, "+")
+	}
+	return keys
+}
+
+// typeText tippt die Abschnitte eines Texts. Mit charDelay wird zwischen allen Zeichen und
+// Sondertasten gewartet, sonst geht jeder Textabschnitt in einem Aufruf an den Controller.
+func typeText(ctx context.Context, keyboard KeyboardController, segments []textSegment, charDelay time.Duration) error {
+	first := true
+	wait := func() error {
+		if first || charDelay <= 0 {
+			first = false
+			return nil
+		}
+		return sleepContext(ctx, charDelay)
+	}
+
+	for _, segment := range segments {
+		if segment.keys != nil {
+			if err := wait(); err != nil {
+				return err
+			}
+			if err := keyboard.SendKeyCombination(ctx, segment.keys); err != nil {
+				return fmt.Errorf("fehler beim Senden von {%s}: %w", strings.Join(segment.keys, "+"), err)
+			}
+			continue
+		}
+		if charDelay <= 0 {
+			first = false
+			if err := keyboard.SendText(ctx, segment.text); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, r := range segment.text {
+			if err := wait(); err != nil {
+				return err
+			}
+			if err := keyboard.SendText(ctx, string(r)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// textParameter gibt den zu tippenden Text zurück. Ein String wird unverändert übernommen,
+// eine Liste wird zusammengefügt.
+func textParameter(action config.Action, keyList []string) string {
+	if text, ok := action.Parameters["keys"].(string); ok {
+		return text
+	}
+	return strings.Join(keyList, "")
+}
+
+// millisecondsParameter liest eine Dauer in Millisekunden oder als Go-Dauer ("1.5s")
+func millisecondsParameter(action config.Action, name string, fallback time.Duration) (time.Duration, error) {
+	value, ok := action.Parameters[name]
+	if !ok {
+		return fallback, nil
+	}
+	var d time.Duration
+	switch v := value.(type) {
+	case int:
+		d = time.Duration(v) * time.Millisecond
+	case float64:
+		d = time.Duration(v * float64(time.Millisecond))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("ungültiger '%s' Parameter: %s", name, v)
+		}
+		d = parsed
+	default:
+		return 0, fmt.Errorf("ungültiger '%s' Parameter: %v", name, value)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s muss positiv sein", name)
+	}
+	return d, nil
+}
+
+// validateText überprüft einen Text mit Sondertasten
+func (e *KeyCombinationExecutor) validateText(text string) error {
+	if !utf8.ValidString(text) {
+		return fmt.Errorf("text ist kein gültiges UTF-8")
+	}
+	segments, err := parseTextInput(text)
+	if err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		for _, key := range segment.keys {
+			if err := e.validateKey(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// recordingKeyboard zeichnet die Aufrufe eines Keyboard-Controllers auf
+type recordingKeyboard struct {
+	calls []string
+}
+
+func (k *recordingKeyboard) SendKey(ctx context.Context, key string) error {
+	k.calls = append(k.calls, "key:"+key)
+	return nil
+}
+
+func (k *recordingKeyboard) SendKeyCombination(ctx context.Context, keys []string) error {
+	k.calls = append(k.calls, fmt.Sprint("combo:", keys))
+	return nil
+}
+
+func (k *recordingKeyboard) SendText(ctx context.Context, text string) error {
+	k.calls = append(k.calls, "text:"+text)
+	return nil
+}
+
+func (k *recordingKeyboard) HoldKey(ctx context.Context, key string, duration time.Duration) error {
+	k.calls = append(k.calls, "hold:"+key)
+	return nil
+}
+
+func TestParseTextInput(t *testing.T) {
+	segments, err := parseTextInput("Grüße {LBRACE}Team}{ENTER}{CTRL+SHIFT+T}{ctrl++}")
+	if err != nil {
+		t.Fatalf("parseTextInput: %v", err)
+	}
+	if got := fmt.Sprint(segments); got != "[{Grüße {Team} []} { [ENTER]} { [CTRL SHIFT T]} { [ctrl +]}]" {
+		t.Fatalf("unexpected segments: %s", got)
+	}
+	for _, text := range []string{"{ENTER", "{}", "{ }"} {
+		if _, err := parseTextInput(text); err == nil {
+			t.Fatalf("expected error for %q", text)
+		}
+	}
+}
+
+func TestKeyCombinationText(t *testing.T) {
+	keyboard := &recordingKeyboard{}
+	executor := &KeyCombinationExecutor{BaseExecutor: NewBaseExecutor("key_combination", utils.NewNullLogger()), keyboard: keyboard}
+
+	action := config.Action{Type: "key_combination", Parameters: map[string]interface{}{
+		"type": "text",
+		"keys": "Hallo, Welt{ENTER}",
+	}}
+	if err := executor.Validate(action); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if _, err := executor.Execute(context.Background(), action); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got := fmt.Sprint(keyboard.calls); got != "[text:Hallo, Welt combo:[ENTER]]" {
+		t.Fatalf("unexpected calls: %s", got)
+	}
+
+	keyboard.calls = nil
+	action.Parameters["keys"] = "ab{TAB}"
+	action.Parameters["char_delay"] = 1
+	if _, err := executor.Execute(context.Background(), action); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got := fmt.Sprint(keyboard.calls); got != "[text:a text:b combo:[TAB]]" {
+		t.Fatalf("unexpected calls with char_delay: %s", got)
+	}
+
+	action.Parameters["keys"] = "{NOPE}"
+	if err := executor.Validate(action); err == nil {
+		t.Fatalf("expected error for unknown key escape")
+	}
+}
@@ -0,0 +1,184 @@
+// Package actions verwaltet die Ausführung von Systemaktionen basierend auf MIDI-Events.
+// Diese Datei enthält die Benutzervariablen und den Variable-Executor.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// Variables speichert die Benutzervariablen zur Laufzeit
+type Variables struct {
+	values map[string]interface{}
+	mutex  sync.RWMutex
+}
+
+// NewVariables erstellt einen Variablenspeicher mit den Startwerten aus der Konfiguration
+func NewVariables(initial map[string]interface{}) *Variables {
+	values := make(map[string]interface{}, len(initial))
+	for name, value := range initial {
+		values[name] = value
+	}
+	return &Variables{values: values}
+}
+
+// Get gibt den Wert einer Variable zurück
+func (v *Variables) Get(name string) (interface{}, bool) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	value, exists := v.values[name]
+	return value, exists
+}
+
+// Set setzt den Wert einer deklarierten Variable
+func (v *Variables) Set(name string, value interface{}) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if _, exists := v.values[name]; !exists {
+		return fmt.Errorf("variable '%s' ist nicht deklariert", name)
+	}
+	v.values[name] = value
+	return nil
+}
+
+// Snapshot gibt eine Kopie aller Variablen zurück
+func (v *Variables) Snapshot() map[string]interface{} {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	values := make(map[string]interface{}, len(v.values))
+	for name, value := range v.values {
+		values[name] = value
+	}
+	return values
+}
+
+// Reconfigure übernimmt neu deklarierte Variablen und behält die Werte bestehender
+func (v *Variables) Reconfigure(declared map[string]interface{}) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	values := make(map[string]interface{}, len(declared))
+	for name, initial := range declared {
+		if current, exists := v.values[name]; exists {
+			values[name] = current
+		} else {
+			values[name] = initial
+		}
+	}
+	v.values = values
+}
+
+// VariableExecutor setzt Benutzervariablen
+type VariableExecutor struct {
+	BaseExecutor
+	variables *Variables
+}
+
+// NewVariableExecutor erstellt einen neuen Variable-Executor
+func NewVariableExecutor(variables *Variables, logger utils.Logger) (*VariableExecutor, error) {
+	executor := &VariableExecutor{
+		BaseExecutor: NewBaseExecutor("variable", logger),
+		variables:    variables,
+	}
+
+	return executor, nil
+}
+
+// Execute führt eine Variable-Aktion aus
+func (e *VariableExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
+	e.LogDebug("Führe Variable-Aktion aus", "parameters", action.Parameters)
+
+	name, ok := action.Parameters["name"].(string)
+	if !ok {
+		return Result{}, fmt.Errorf("variable-Aktion benötigt 'name' Parameter")
+	}
+
+	// Operation bestimmen
+	operation := "set"
+	if opParam, ok := action.Parameters["operation"].(string); ok {
+		operation = opParam
+	}
+
+	current, _ := e.variables.Get(name)
+
+	var value interface{}
+	switch operation {
+	case "set":
+		var ok bool
+		if value, ok = action.Parameters["value"]; !ok {
+			return Result{}, fmt.Errorf("'set' Operation benötigt 'value' Parameter")
+		}
+
+	case "unset":
+		value = nil
+
+	case "toggle":
+		on, _ := current.(bool)
+		value = !on
+
+	case "increment", "decrement":
+		step := 1.0
+		if stepParam, ok := action.Parameters["step"].(float64); ok {
+			step = stepParam
+		} else if stepParam, ok := action.Parameters["step"].(int); ok {
+			step = float64(stepParam)
+		}
+		if operation == "decrement" {
+			step = -step
+		}
+
+		switch v := current.(type) {
+		case int:
+			value = float64(v) + step
+		case float64:
+			value = v + step
+		case nil:
+			value = step
+		default:
+			return Result{}, fmt.Errorf("variable '%s' ist keine Zahl: %v", name, current)
+		}
+
+	default:
+		return Result{}, fmt.Errorf("ungültige Operation: %s (erwartet: set, unset, toggle, increment, decrement)", operation)
+	}
+
+	e.LogInfo("Setze Variable", "name", name, "value", value)
+	if err := e.variables.Set(name, value); err != nil {
+		return Result{}, err
+	}
+	return Result{Changed: !reflect.DeepEqual(value, current), State: fmt.Sprint(value)}, nil
+}
+
+// Validate überprüft eine Variable-Aktion auf Gültigkeit
+func (e *VariableExecutor) Validate(action config.Action) error {
+	name, ok := action.Parameters["name"].(string)
+	if !ok || name == "" {
+		return fmt.Errorf("variable-Aktion benötigt 'name' Parameter")
+	}
+
+	// Ob die Variable deklariert ist, prüft bereits das Laden der Konfiguration; beim Neuladen
+	// kennt der Speicher neu deklarierte Variablen noch nicht
+	if opParam, ok := action.Parameters["operation"]; ok {
+		operation, ok := opParam.(string)
+		if !ok {
+			return fmt.Errorf("'operation' Parameter muss ein String sein")
+		}
+		validOperations := map[string]bool{
+			"set":       true,
+			"unset":     true,
+			"toggle":    true,
+			"increment": true,
+			"decrement": true,
+		}
+		if !validOperations[operation] {
+			return fmt.Errorf("ungültige Operation: %s", operation)
+		}
+	}
+
+	return nil
+}
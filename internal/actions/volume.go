@@ -4,32 +4,65 @@
 package actions
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"runtime"
 	"strconv"
+	"sync"
 
 	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/internal/pulse"
 	"github.com/Xcruser/MidiDaemon/pkg/utils"
 )
 
+// masterFadeTarget ist der Name der Systemlautstärke für Überblendungen
+const masterFadeTarget = "volume"
+
+// defaultUnmuteVolume ist die Lautstärke nach dem Aufheben einer Stummschaltung,
+// wenn das Backend keine Stummschaltung kennt und keine vorherige Lautstärke gespeichert ist
+const defaultUnmuteVolume = 50
+
+// errMuteUnsupported meldet ein Backend ohne eigene Stummschaltung; stummgeschaltet wird dann über die Lautstärke
+var errMuteUnsupported = errors.New("das Audio-Backend unterstützt keine Stummschaltung")
+
 // VolumeExecutor verwaltet die Lautstärkesteuerung
 type VolumeExecutor struct {
 	BaseExecutor
 	volumeController VolumeController
+	fader            *fader
+
+	// Gespeicherte Lautstärke für Backends ohne Stummschaltung (-1 = nicht stummgeschaltet)
+	mutex       sync.Mutex
+	savedVolume int
 }
 
-// VolumeController definiert die Schnittstelle für plattformspezifische Lautstärkesteuerung
+// VolumeController definiert die Schnittstelle für plattformspezifische Lautstärkesteuerung.
+// GetMute und SetMute geben errMuteUnsupported zurück, wenn das Backend keine Stummschaltung kennt.
 type VolumeController interface {
-	GetVolume() (int, error)
-	SetVolume(volume int) error
-	IncreaseVolume(percent int) error
-	DecreaseVolume(percent int) error
+	GetVolume(ctx context.Context) (int, error)
+	SetVolume(ctx context.Context, volume int) error
+	IncreaseVolume(ctx context.Context, percent int) error
+	DecreaseVolume(ctx context.Context, percent int) error
+	GetMute(ctx context.Context) (bool, error)
+	SetMute(ctx context.Context, muted bool) error
+}
+
+// VolumeState ist der aktuelle Zustand der Systemlautstärke (z.B. für LED-Feedback)
+type VolumeState struct {
+	Volume int  `json:"volume"`
+	Muted  bool `json:"muted"`
+}
+
+// VolumeWatcher wird von Volume-Controllern implementiert, die Änderungen der Lautstärke melden können
+type VolumeWatcher interface {
+	WatchVolume(ctx context.Context, onChange func(volume int, muted bool)) error
 }
 
 // NewVolumeExecutor erstellt einen neuen Volume-Executor
-func NewVolumeExecutor(logger utils.Logger) (*VolumeExecutor, error) {
+func NewVolumeExecutor(audio config.AudioConfig, logger utils.Logger) (*VolumeExecutor, error) {
 	// Plattformspezifischen Volume-Controller erstellen
-	controller, err := newVolumeController()
+	controller, err := newVolumeController(audio)
 	if err != nil {
 		return nil, fmt.Errorf("fehler beim Erstellen des Volume-Controllers: %w", err)
 	}
@@ -37,24 +70,26 @@ func NewVolumeExecutor(logger utils.Logger) (*VolumeExecutor, error) {
 	executor := &VolumeExecutor{
 		BaseExecutor:     NewBaseExecutor("volume", logger),
 		volumeController: controller,
+		fader:            newFader(),
+		savedVolume:      -1,
 	}
 
 	return executor, nil
 }
 
 // Execute führt eine Volume-Aktion aus
-func (e *VolumeExecutor) Execute(action config.Action) error {
+func (e *VolumeExecutor) Execute(ctx context.Context, action config.Action) (Result, error) {
 	e.LogDebug("Führe Volume-Aktion aus", "parameters", action.Parameters)
 
 	// Direction-Parameter extrahieren
 	direction, ok := action.Parameters["direction"]
 	if !ok {
-		return fmt.Errorf("volume-Aktion benötigt 'direction' Parameter")
+		return Result{}, fmt.Errorf("volume-Aktion benötigt 'direction' Parameter")
 	}
 
 	directionStr, ok := direction.(string)
 	if !ok {
-		return fmt.Errorf("'direction' Parameter muss ein String sein")
+		return Result{}, fmt.Errorf("'direction' Parameter muss ein String sein")
 	}
 
 	// Prozent-Parameter extrahieren (optional, Standard: 5%)
@@ -72,56 +107,188 @@ func (e *VolumeExecutor) Execute(action config.Action) error {
 		}
 	}
 
+	// Direkte Änderungen beenden eine laufende Überblendung
+	if directionStr != "fade" {
+		e.fader.cancel(masterFadeTarget)
+	}
+
 	// Volume-Aktion ausführen
+	var err error
 	switch directionStr {
+	case "fade":
+		return e.fade(ctx, action)
+
 	case "up", "increase":
 		e.LogInfo("Erhöhe Lautstärke", "percent", percent)
-		return e.volumeController.IncreaseVolume(percent)
+		err = e.volumeController.IncreaseVolume(ctx, percent)
 
 	case "down", "decrease":
 		e.LogInfo("Verringere Lautstärke", "percent", percent)
-		return e.volumeController.DecreaseVolume(percent)
+		err = e.volumeController.DecreaseVolume(ctx, percent)
 
 	case "set":
 		// Spezifische Lautstärke setzen
-		if volumeParam, ok := action.Parameters["volume"]; ok {
-			var volume int
-			switch v := volumeParam.(type) {
-			case int:
-				volume = v
-			case float64:
-				volume = int(v)
-			case string:
-				if parsed, err := strconv.Atoi(v); err == nil {
-					volume = parsed
-				} else {
-					return fmt.Errorf("ungültiger 'volume' Parameter: %v", volumeParam)
-				}
-			default:
-				return fmt.Errorf("ungültiger 'volume' Parameter: %v", volumeParam)
-			}
+		volumeParam, ok := action.Parameters["volume"]
+		if !ok {
+			return Result{}, fmt.Errorf("'set' direction benötigt 'volume' Parameter")
+		}
 
-			if volume < 0 || volume > 100 {
-				return fmt.Errorf("volume muss zwischen 0 und 100 liegen, got: %d", volume)
+		var volume int
+		switch v := volumeParam.(type) {
+		case int:
+			volume = v
+		case float64:
+			volume = int(v)
+		case string:
+			if parsed, err := strconv.Atoi(v); err == nil {
+				volume = parsed
+			} else {
+				return Result{}, fmt.Errorf("ungültiger 'volume' Parameter: %v", volumeParam)
 			}
+		default:
+			return Result{}, fmt.Errorf("ungültiger 'volume' Parameter: %v", volumeParam)
+		}
 
-			e.LogInfo("Setze Lautstärke", "volume", volume)
-			return e.volumeController.SetVolume(volume)
+		if volume < 0 || volume > 100 {
+			return Result{}, fmt.Errorf("volume muss zwischen 0 und 100 liegen, got: %d", volume)
 		}
-		return fmt.Errorf("'set' direction benötigt 'volume' Parameter")
 
-	case "mute":
-		e.LogInfo("Stummschalten")
-		return e.volumeController.SetVolume(0)
+		e.LogInfo("Setze Lautstärke", "volume", volume)
+		err = e.volumeController.SetVolume(ctx, volume)
 
-	case "unmute":
-		e.LogInfo("Stummschaltung aufheben")
-		// Auf 50% setzen als Standard
-		return e.volumeController.SetVolume(50)
+	case "mute", "unmute", "toggle_mute":
+		muted := directionStr == "mute"
+		if directionStr == "toggle_mute" {
+			state, err := e.GetState(ctx)
+			if err != nil {
+				return Result{}, fmt.Errorf("fehler beim Abfragen der Stummschaltung: %w", err)
+			}
+			muted = !state.Muted
+		}
+		if muted {
+			e.LogInfo("Stummschalten")
+		} else {
+			e.LogInfo("Stummschaltung aufheben")
+		}
+		if err := e.setMute(ctx, muted); err != nil {
+			return Result{}, err
+		}
+		if muted {
+			return Result{Changed: true, State: "muted"}, nil
+		}
+		return Result{Changed: true, State: "unmuted"}, nil
 
 	default:
-		return fmt.Errorf("ungültige direction: %s (erwartet: up, down, set, mute, unmute)", directionStr)
+		return Result{}, fmt.Errorf("ungültige direction: %s (erwartet: up, down, set, fade, mute, unmute, toggle_mute)", directionStr)
+	}
+
+	if err != nil {
+		return Result{}, err
 	}
+
+	// Eine manuell geänderte Lautstärke beendet die nachgebildete Stummschaltung
+	e.mutex.Lock()
+	e.savedVolume = -1
+	e.mutex.Unlock()
+
+	result := Result{Changed: true}
+	if volume, err := e.volumeController.GetVolume(ctx); err == nil {
+		result.State = strconv.Itoa(volume)
+	}
+	return result, nil
+}
+
+// fade blendet die Systemlautstärke von der aktuellen (oder "from") zur Ziel-Lautstärke über
+func (e *VolumeExecutor) fade(ctx context.Context, action config.Action) (Result, error) {
+	volume, duration, easing, err := fadeParameters(action.Parameters)
+	if err != nil {
+		return Result{}, err
+	}
+	from, err := intParameter(action, "from", -1)
+	if err != nil {
+		return Result{}, err
+	}
+	if from < 0 {
+		if from, err = e.volumeController.GetVolume(ctx); err != nil {
+			return Result{}, fmt.Errorf("fehler beim Abfragen der Lautstärke: %w", err)
+		}
+	}
+
+	e.mutex.Lock()
+	e.savedVolume = -1
+	e.mutex.Unlock()
+
+	e.LogInfo("Blende Lautstärke über", "from", from, "to", volume, "duration", duration)
+	err = e.fader.fade(ctx, masterFadeTarget, from, volume, duration, easing, e.volumeController.SetVolume)
+	if errors.Is(err, errFadeCanceled) {
+		e.LogDebug("Überblendung abgebrochen", "to", volume)
+		return Result{Changed: true, Output: "abgebrochen"}, nil
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Changed: from != volume, State: strconv.Itoa(volume)}, nil
+}
+
+// setMute schaltet stumm oder hebt die Stummschaltung auf. Kennt das Backend keine Stummschaltung,
+// wird die Lautstärke auf 0 gesetzt und beim Aufheben die vorherige Lautstärke wiederhergestellt.
+func (e *VolumeExecutor) setMute(ctx context.Context, muted bool) error {
+	err := e.volumeController.SetMute(ctx, muted)
+	if !errors.Is(err, errMuteUnsupported) {
+		return err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if muted {
+		if e.savedVolume >= 0 {
+			return nil
+		}
+		volume, err := e.volumeController.GetVolume(ctx)
+		if err != nil {
+			return err
+		}
+		if err := e.volumeController.SetVolume(ctx, 0); err != nil {
+			return err
+		}
+		e.savedVolume = volume
+		return nil
+	}
+
+	volume := e.savedVolume
+	if volume < 0 {
+		// Nichts gespeichert: nur eingreifen, wenn die Lautstärke auf 0 steht
+		current, err := e.volumeController.GetVolume(ctx)
+		if err != nil || current > 0 {
+			return err
+		}
+		volume = defaultUnmuteVolume
+	}
+	if err := e.volumeController.SetVolume(ctx, volume); err != nil {
+		return err
+	}
+	e.savedVolume = -1
+	return nil
+}
+
+// GetState gibt Lautstärke und Stummschaltung zurück. Bei Backends ohne Stummschaltung gilt die
+// Lautstärke als stummgeschaltet, solange eine vorherige Lautstärke gespeichert ist.
+func (e *VolumeExecutor) GetState(ctx context.Context) (VolumeState, error) {
+	volume, err := e.volumeController.GetVolume(ctx)
+	if err != nil {
+		return VolumeState{}, err
+	}
+	muted, err := e.volumeController.GetMute(ctx)
+	if errors.Is(err, errMuteUnsupported) {
+		e.mutex.Lock()
+		muted, err = e.savedVolume >= 0, nil
+		e.mutex.Unlock()
+	}
+	if err != nil {
+		return VolumeState{}, err
+	}
+	return VolumeState{Volume: volume, Muted: muted}, nil
 }
 
 // Validate überprüft eine Volume-Aktion auf Gültigkeit
@@ -139,21 +306,30 @@ func (e *VolumeExecutor) Validate(action config.Action) error {
 
 	// Gültige Directions überprüfen
 	validDirections := map[string]bool{
-		"up":      true,
-		"down":    true,
-		"increase": true,
-		"decrease": true,
-		"set":     true,
-		"mute":    true,
-		"unmute":  true,
+		"up":          true,
+		"down":        true,
+		"increase":    true,
+		"decrease":    true,
+		"set":         true,
+		"mute":        true,
+		"unmute":      true,
+		"toggle_mute": true,
+		"fade":        true,
 	}
 
 	if !validDirections[directionStr] {
 		return fmt.Errorf("ungültige direction: %s", directionStr)
 	}
 
-	// Bei "set" direction muss volume-Parameter vorhanden sein
-	if directionStr == "set" {
+	// Bei "fade" müssen Ziel, Dauer und Verlauf gültig sein
+	if directionStr == "fade" {
+		if _, _, _, err := fadeParameters(action.WithValue(0).Parameters); err != nil {
+			return err
+		}
+	}
+
+	// Bei "set" direction muss volume-Parameter vorhanden sein (oder aus dem Event-Wert stammen)
+	if directionStr == "set" && (action.Value == nil || action.Value.Parameter != "volume") {
 		if _, ok := action.Parameters["volume"]; !ok {
 			return fmt.Errorf("'set' direction benötigt 'volume' Parameter")
 		}
@@ -187,16 +363,31 @@ func (e *VolumeExecutor) Validate(action config.Action) error {
 
 // GetCurrentVolume gibt die aktuelle Lautstärke zurück
 func (e *VolumeExecutor) GetCurrentVolume() (int, error) {
-	return e.volumeController.GetVolume()
+	return e.volumeController.GetVolume(context.Background())
+}
+
+// WatchVolume meldet Änderungen der Lautstärke, sofern der Controller das unterstützt
+func (e *VolumeExecutor) WatchVolume(ctx context.Context, onChange func(volume int, muted bool)) error {
+	watcher, ok := e.volumeController.(VolumeWatcher)
+	if !ok {
+		return fmt.Errorf("lautstärke-Änderungen werden auf %s nicht unterstützt", runtime.GOOS)
+	}
+	return watcher.WatchVolume(ctx, onChange)
+}
+
+// Close bricht laufende Überblendungen ab und gibt die Verbindung zum Audio-Backend frei
+func (e *VolumeExecutor) Close() error {
+	e.fader.stopAll()
+	return closeController(e.volumeController)
 }
 
 // newVolumeController erstellt einen plattformspezifischen Volume-Controller
-func newVolumeController() (VolumeController, error) {
+func newVolumeController(audio config.AudioConfig) (VolumeController, error) {
 	switch runtime.GOOS {
 	case "windows":
 		return newWindowsVolumeController()
 	case "linux":
-		return newLinuxVolumeController()
+		return newLinuxVolumeController(audio, execRunner{})
 	default:
 		return nil, fmt.Errorf("plattform %s wird nicht unterstützt", runtime.GOOS)
 	}
@@ -209,22 +400,34 @@ func newWindowsVolumeController() (VolumeController, error) {
 	return &windowsVolumeController{}, nil
 }
 
-func (c *windowsVolumeController) GetVolume() (int, error) {
+func (c *windowsVolumeController) GetVolume(ctx context.Context) (int, error) {
 	// TODO: Implementierung mit Windows API
 	// - GetMasterVolumeLevel aufrufen
 	// - Lautstärke in Prozent zurückgeben
 	return 50, nil // Platzhalter
 }
 
-func (c *windowsVolumeController) SetVolume(volume int) error {
+func (c *windowsVolumeController) SetVolume(ctx context.Context, volume int) error {
 	// TODO: Implementierung mit Windows API
 	// - SetMasterVolumeLevel aufrufen
 	// - Lautstärke auf den angegebenen Wert setzen
 	return nil
 }
 
-func (c *windowsVolumeController) IncreaseVolume(percent int) error {
-	current, err := c.GetVolume()
+func (c *windowsVolumeController) GetMute(ctx context.Context) (bool, error) {
+	// TODO: Implementierung mit Windows API
+	// - IAudioEndpointVolume.GetMute aufrufen
+	return false, errMuteUnsupported
+}
+
+func (c *windowsVolumeController) SetMute(ctx context.Context, muted bool) error {
+	// TODO: Implementierung mit Windows API
+	// - IAudioEndpointVolume.SetMute aufrufen
+	return errMuteUnsupported
+}
+
+func (c *windowsVolumeController) IncreaseVolume(ctx context.Context, percent int) error {
+	current, err := c.GetVolume(ctx)
 	if err != nil {
 		return err
 	}
@@ -234,11 +437,11 @@ func (c *windowsVolumeController) IncreaseVolume(percent int) error {
 		newVolume = 100
 	}
 
-	return c.SetVolume(newVolume)
+	return c.SetVolume(ctx, newVolume)
 }
 
-func (c *windowsVolumeController) DecreaseVolume(percent int) error {
-	current, err := c.GetVolume()
+func (c *windowsVolumeController) DecreaseVolume(ctx context.Context, percent int) error {
+	current, err := c.GetVolume(ctx)
 	if err != nil {
 		return err
 	}
@@ -248,32 +451,69 @@ func (c *windowsVolumeController) DecreaseVolume(percent int) error {
 		newVolume = 0
 	}
 
-	return c.SetVolume(newVolume)
+	return c.SetVolume(ctx, newVolume)
 }
 
-// Linux-spezifische Volume-Controller-Implementierung
-type linuxVolumeController struct{}
+// Linux-spezifische Volume-Controller-Implementierung über das native PulseAudio-Protokoll
+// (funktioniert auch mit PipeWire über pipewire-pulse). Gesteuert wird der Standard-Sink.
+// Alternativ stehen die Kommandozeilen-Backends wpctl, pactl und amixer zur Verfügung.
+type linuxVolumeController struct {
+	conn *pulseConnection
+}
 
-func newLinuxVolumeController() (VolumeController, error) {
-	return &linuxVolumeController{}, nil
+// Close trennt die Verbindung zum Sound-Server
+func (c *linuxVolumeController) Close() error {
+	return c.conn.close()
 }
 
-func (c *linuxVolumeController) GetVolume() (int, error) {
-	// TODO: Implementierung mit ALSA oder PulseAudio
-	// - amixer get Master aufrufen
-	// - Lautstärke aus der Ausgabe extrahieren
-	return 50, nil // Platzhalter
+// newLinuxVolumeController wählt das konfigurierte bzw. ein verfügbares Linux-Backend
+func newLinuxVolumeController(cfg config.AudioConfig, runner CommandRunner) (VolumeController, error) {
+	conn := newPulseConnection()
+	switch backend := resolveAudioBackend(cfg.Backend, runner, conn.socketPath); backend {
+	case config.AudioBackendPulse:
+		return &linuxVolumeController{conn: conn}, nil
+	case config.AudioBackendWpctl:
+		return newWpctlController(runner), nil
+	case config.AudioBackendPactl:
+		return newPactlController(runner), nil
+	case config.AudioBackendAmixer:
+		return newAmixerController(runner, cfg.Card, cfg.Control), nil
+	default:
+		return nil, fmt.Errorf("unbekanntes Audio-Backend: %s", backend)
+	}
 }
 
-func (c *linuxVolumeController) SetVolume(volume int) error {
-	// TODO: Implementierung mit ALSA oder PulseAudio
-	// - amixer set Master aufrufen
-	// - Lautstärke auf den angegebenen Wert setzen
-	return nil
+// defaultSink fragt den aktuellen Standard-Sink ab
+func (c *linuxVolumeController) defaultSink(ctx context.Context) (*pulse.Client, *pulse.Device, error) {
+	client, err := c.conn.get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	sink, err := client.Sink(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, sink, nil
+}
+
+func (c *linuxVolumeController) GetVolume(ctx context.Context) (int, error) {
+	_, sink, err := c.defaultSink(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return sink.Percent(), nil
+}
+
+func (c *linuxVolumeController) SetVolume(ctx context.Context, volume int) error {
+	client, sink, err := c.defaultSink(ctx)
+	if err != nil {
+		return err
+	}
+	return client.SetSinkVolume(ctx, sink.Name, pulse.ScaleVolume(sink.Volume, volume))
 }
 
-func (c *linuxVolumeController) IncreaseVolume(percent int) error {
-	current, err := c.GetVolume()
+func (c *linuxVolumeController) IncreaseVolume(ctx context.Context, percent int) error {
+	current, err := c.GetVolume(ctx)
 	if err != nil {
 		return err
 	}
@@ -283,11 +523,11 @@ func (c *linuxVolumeController) IncreaseVolume(percent int) error {
 		newVolume = 100
 	}
 
-	return c.SetVolume(newVolume)
+	return c.SetVolume(ctx, newVolume)
 }
 
-func (c *linuxVolumeController) DecreaseVolume(percent int) error {
-	current, err := c.GetVolume()
+func (c *linuxVolumeController) DecreaseVolume(ctx context.Context, percent int) error {
+	current, err := c.GetVolume(ctx)
 	if err != nil {
 		return err
 	}
@@ -297,5 +537,72 @@ func (c *linuxVolumeController) DecreaseVolume(percent int) error {
 		newVolume = 0
 	}
 
-	return c.SetVolume(newVolume)
-} 
\ No newline at end of file
+	return c.SetVolume(ctx, newVolume)
+}
+
+// GetMute gibt zurück, ob der Standard-Sink stummgeschaltet ist
+func (c *linuxVolumeController) GetMute(ctx context.Context) (bool, error) {
+	_, sink, err := c.defaultSink(ctx)
+	if err != nil {
+		return false, err
+	}
+	return sink.Muted, nil
+}
+
+// SetMute schaltet den Standard-Sink stumm oder wieder laut
+func (c *linuxVolumeController) SetMute(ctx context.Context, muted bool) error {
+	client, sink, err := c.defaultSink(ctx)
+	if err != nil {
+		return err
+	}
+	return client.SetSinkMute(ctx, sink.Name, muted)
+}
+
+// WatchVolume meldet Änderungen von Lautstärke und Stummschaltung des Standard-Sinks,
+// auch wenn ein anderer Sink zum Standard wird. Blockiert, bis ctx endet oder die Verbindung abbricht.
+func (c *linuxVolumeController) WatchVolume(ctx context.Context, onChange func(volume int, muted bool)) error {
+	// Eigene Verbindung, damit Abfragen der Aktionen nicht mit den Ereignissen konkurrieren
+	client, err := pulse.Dial(ctx, c.conn.socketPath, "MidiDaemon (Monitor)")
+	if err != nil {
+		return fmt.Errorf("fehler beim Verbinden mit PulseAudio/PipeWire: %w", err)
+	}
+	defer client.Close()
+
+	events, err := client.Subscribe(ctx, pulse.SubscribeSink|pulse.SubscribeServer)
+	if err != nil {
+		return err
+	}
+
+	lastVolume, lastMuted := -1, false
+	report := func() error {
+		sink, err := client.Sink(ctx, "")
+		if err != nil {
+			return err
+		}
+		if volume := sink.Percent(); volume != lastVolume || sink.Muted != lastMuted {
+			lastVolume, lastMuted = volume, sink.Muted
+			onChange(volume, sink.Muted)
+		}
+		return nil
+	}
+	if err := report(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return client.Err()
+			}
+			if event.Kind() == pulse.EventRemove {
+				continue
+			}
+			if err := report(); err != nil && ctx.Err() == nil && !pulse.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+}
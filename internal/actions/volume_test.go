@@ -0,0 +1,130 @@
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// fakeVolumeController speichert Lautstärke und Stummschaltung; ohne hasMute fehlt die Stummschaltung
+type fakeVolumeController struct {
+	volume  int
+	muted   bool
+	hasMute bool
+}
+
+func (c *fakeVolumeController) GetVolume(ctx context.Context) (int, error) { return c.volume, nil }
+
+func (c *fakeVolumeController) SetVolume(ctx context.Context, volume int) error {
+	c.volume = volume
+	return nil
+}
+
+func (c *fakeVolumeController) IncreaseVolume(ctx context.Context, percent int) error {
+	return c.SetVolume(ctx, clampPercent(c.volume+percent))
+}
+
+func (c *fakeVolumeController) DecreaseVolume(ctx context.Context, percent int) error {
+	return c.SetVolume(ctx, clampPercent(c.volume-percent))
+}
+
+func (c *fakeVolumeController) GetMute(ctx context.Context) (bool, error) {
+	if !c.hasMute {
+		return false, errMuteUnsupported
+	}
+	return c.muted, nil
+}
+
+func (c *fakeVolumeController) SetMute(ctx context.Context, muted bool) error {
+	if !c.hasMute {
+		return errMuteUnsupported
+	}
+	c.muted = muted
+	return nil
+}
+
+func newTestVolumeExecutor(controller VolumeController) *VolumeExecutor {
+	return &VolumeExecutor{
+		BaseExecutor:     NewBaseExecutor("volume", utils.NewNullLogger()),
+		volumeController: controller,
+		fader:            newFader(),
+		savedVolume:      -1,
+	}
+}
+
+func runVolume(t *testing.T, e *VolumeExecutor, params map[string]interface{}) Result {
+	t.Helper()
+	action := config.Action{Type: "volume", Parameters: params}
+	if err := e.Validate(action); err != nil {
+		t.Fatalf("Validate(%v): %v", params, err)
+	}
+	result, err := e.Execute(context.Background(), action)
+	if err != nil {
+		t.Fatalf("Execute(%v): %v", params, err)
+	}
+	return result
+}
+
+func TestVolumeToggleMute(t *testing.T) {
+	controller := &fakeVolumeController{volume: 65, hasMute: true}
+	e := newTestVolumeExecutor(controller)
+
+	// Echte Stummschaltung lässt die Lautstärke unverändert
+	result := runVolume(t, e, map[string]interface{}{"direction": "toggle_mute"})
+	if result.State != "muted" || !controller.muted || controller.volume != 65 {
+		t.Fatalf("toggle_mute: %+v, %+v", result, controller)
+	}
+	result = runVolume(t, e, map[string]interface{}{"direction": "toggle_mute"})
+	if result.State != "unmuted" || controller.muted || controller.volume != 65 {
+		t.Fatalf("toggle_mute back: %+v, %+v", result, controller)
+	}
+}
+
+func TestVolumeMuteFallback(t *testing.T) {
+	controller := &fakeVolumeController{volume: 65}
+	e := newTestVolumeExecutor(controller)
+	ctx := context.Background()
+
+	// Ohne Stummschaltung im Backend wird die Lautstärke gespeichert und wiederhergestellt
+	runVolume(t, e, map[string]interface{}{"direction": "mute"})
+	if state, err := e.GetState(ctx); err != nil || !state.Muted || controller.volume != 0 {
+		t.Fatalf("mute: %+v, %v, volume %d", state, err, controller.volume)
+	}
+	runVolume(t, e, map[string]interface{}{"direction": "mute"})
+	runVolume(t, e, map[string]interface{}{"direction": "toggle_mute"})
+	if state, _ := e.GetState(ctx); state.Muted || controller.volume != 65 {
+		t.Fatalf("unmute did not restore: %+v", state)
+	}
+
+	// Eine manuelle Änderung beendet die Stummschaltung
+	runVolume(t, e, map[string]interface{}{"direction": "mute"})
+	runVolume(t, e, map[string]interface{}{"direction": "up", "percent": 10})
+	runVolume(t, e, map[string]interface{}{"direction": "unmute"})
+	if state, _ := e.GetState(ctx); state.Muted || controller.volume != 10 {
+		t.Fatalf("unexpected state after manual change: %+v", state)
+	}
+
+	// Ohne gespeicherte Lautstärke hebt unmute nur eine Lautstärke von 0 an
+	controller.volume = 0
+	runVolume(t, e, map[string]interface{}{"direction": "unmute"})
+	if controller.volume != defaultUnmuteVolume {
+		t.Fatalf("unmute from 0 = %d", controller.volume)
+	}
+}
+
+func TestVolumeFade(t *testing.T) {
+	controller := &fakeVolumeController{volume: 80, hasMute: true}
+	e := newTestVolumeExecutor(controller)
+
+	result := runVolume(t, e, map[string]interface{}{"direction": "fade", "volume": 0, "duration": 60, "easing": "ease_out"})
+	if result.State != "0" || controller.volume != 0 {
+		t.Fatalf("fade: %+v, volume %d", result, controller.volume)
+	}
+
+	action := config.Action{Type: "volume", Parameters: map[string]interface{}{"direction": "fade", "volume": 50, "easing": "bounce"}}
+	if err := e.Validate(action); err == nil {
+		t.Fatalf("expected error for unknown easing")
+	}
+}
@@ -0,0 +1,82 @@
+// Package api stellt eine lokale HTTP-Schnittstelle zum Abfragen des Daemon-Zustands bereit.
+// Diese Datei enthält den Client für die Kommandozeile.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/actions"
+)
+
+// FetchHistory fragt den Verlauf eines laufenden Daemons ab
+func FetchHistory(addr string, filter actions.HistoryFilter) ([]actions.HistoryEntry, error) {
+	query := url.Values{}
+	if filter.Mapping != "" {
+		query.Set("mapping", filter.Mapping)
+	}
+	if filter.ErrorsOnly {
+		query.Set("errors", "1")
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://%s/history?%s", addr, query.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("status-API unter %s nicht erreichbar: %w", addr, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("status-API antwortet mit %s: %s", resp.Status, body)
+	}
+
+	var entries []actions.HistoryEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("ungültige Antwort der Status-API: %w", err)
+	}
+	return entries, nil
+}
+
+// PrintHistory gibt Verlaufseinträge zeilenweise lesbar aus
+func PrintHistory(w io.Writer, entries []actions.HistoryEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "Keine Einträge")
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Mapping
+		if entry.Step != "" {
+			name += "/" + entry.Step
+		}
+
+		outcome := "ok"
+		if entry.Error != "" {
+			outcome = "FEHLER: " + entry.Error
+		} else if entry.Result != nil && entry.Result.State != "" {
+			outcome = "ok -> " + entry.Result.State
+		}
+
+		params, _ := json.Marshal(entry.Parameters)
+		fmt.Fprintf(w, "%s  %-24s %-8s %s value=%d  %s %s (%s)\n",
+			entry.Time.Format("15:04:05.000"),
+			name,
+			entry.Event.Type,
+			entry.Action,
+			entry.Event.Value,
+			params,
+			outcome,
+			entry.Duration.Round(time.Millisecond),
+		)
+	}
+}
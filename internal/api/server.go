@@ -0,0 +1,138 @@
+// Package api stellt eine lokale HTTP-Schnittstelle zum Abfragen des Daemon-Zustands bereit.
+// Diese Datei enthält den Server.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/actions"
+	"github.com/Xcruser/MidiDaemon/internal/midi"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// DefaultAddr ist die Standardadresse der Status-API
+const DefaultAddr = "127.0.0.1:7373"
+
+// StatusProvider liefert die Daten für die Status-API (implementiert von midi.Handler)
+type StatusProvider interface {
+	History(filter actions.HistoryFilter) []actions.HistoryEntry
+	LayerStatus() midi.LayerStatus
+	MappingStates() map[string]bool
+	BreakerStates() map[string]actions.BreakerStatus
+	Variables() map[string]interface{}
+	VolumeState() (actions.VolumeState, error)
+}
+
+// Status ist die Antwort auf /status
+type Status struct {
+	Layers    midi.LayerStatus                 `json:"layers"`
+	States    map[string]bool                  `json:"states"`
+	Breakers  map[string]actions.BreakerStatus `json:"breakers"`
+	Variables map[string]interface{}           `json:"variables"`
+	Volume    *actions.VolumeState             `json:"volume,omitempty"` // fehlt, wenn kein Audio-Backend erreichbar ist
+}
+
+// Server beantwortet Abfragen zum Zustand des Daemons
+type Server struct {
+	addr     string
+	provider StatusProvider
+	logger   utils.Logger
+}
+
+// NewServer erstellt einen neuen Status-Server
+func NewServer(addr string, provider StatusProvider, logger utils.Logger) *Server {
+	return &Server{
+		addr:     addr,
+		provider: provider,
+		logger:   logger,
+	}
+}
+
+// Handler gibt den HTTP-Handler mit allen Endpunkten zurück
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/history", s.handleHistory)
+	mux.HandleFunc("/status", s.handleStatus)
+	return mux
+}
+
+// Start startet den Server und beendet ihn, sobald der Context abgebrochen wird
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.Info("Status-API gestartet", "addr", s.addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("fehler beim Starten der Status-API: %w", err)
+	}
+	return nil
+}
+
+// handleHistory beantwortet /history?mapping=<name>&errors=1&limit=<n>
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "nur GET erlaubt", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := actions.HistoryFilter{
+		Mapping:    query.Get("mapping"),
+		ErrorsOnly: query.Get("errors") == "1" || query.Get("errors") == "true",
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			http.Error(w, "ungültiges limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+
+	s.writeJSON(w, s.provider.History(filter))
+}
+
+// handleStatus beantwortet /status mit Layern, Mapping-Zuständen, Circuit-Breakern, Variablen und Lautstärke
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "nur GET erlaubt", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := Status{
+		Layers:    s.provider.LayerStatus(),
+		States:    s.provider.MappingStates(),
+		Breakers:  s.provider.BreakerStates(),
+		Variables: s.provider.Variables(),
+	}
+	if volume, err := s.provider.VolumeState(); err == nil {
+		status.Volume = &volume
+	}
+	s.writeJSON(w, status)
+}
+
+// writeJSON schreibt eine JSON-Antwort
+func (s *Server) writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		s.logger.Error("Fehler beim Schreiben der API-Antwort", "error", err)
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Xcruser/MidiDaemon/internal/actions"
+	"github.com/Xcruser/MidiDaemon/internal/midi"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// fakeProvider liefert feste Daten und merkt sich den letzten Filter
+type fakeProvider struct {
+	filter actions.HistoryFilter
+}
+
+func (p *fakeProvider) History(filter actions.HistoryFilter) []actions.HistoryEntry {
+	p.filter = filter
+	return []actions.HistoryEntry{{Mapping: "Mute", Action: "audio_source", Error: "timeout"}}
+}
+
+func (p *fakeProvider) LayerStatus() midi.LayerStatus  { return midi.LayerStatus{Active: "base"} }
+func (p *fakeProvider) MappingStates() map[string]bool { return map[string]bool{"Mute": true} }
+func (p *fakeProvider) Variables() map[string]interface{} {
+	return map[string]interface{}{}
+}
+func (p *fakeProvider) VolumeState() (actions.VolumeState, error) {
+	return actions.VolumeState{Volume: 40, Muted: true}, nil
+}
+func (p *fakeProvider) BreakerStates() map[string]actions.BreakerStatus {
+	return map[string]actions.BreakerStatus{"audio_source": {State: actions.BreakerOpen}}
+}
+
+func TestHistoryEndpoint(t *testing.T) {
+	provider := &fakeProvider{}
+	server := httptest.NewServer(NewServer("", provider, utils.NewNullLogger()).Handler())
+	defer server.Close()
+
+	entries, err := FetchHistory(server.Listener.Addr().String(), actions.HistoryFilter{Mapping: "Mute", ErrorsOnly: true, Limit: 5})
+	if err != nil {
+		t.Fatalf("FetchHistory: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Error != "timeout" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if provider.filter != (actions.HistoryFilter{Mapping: "Mute", ErrorsOnly: true, Limit: 5}) {
+		t.Fatalf("filter not passed through: %+v", provider.filter)
+	}
+
+	resp, err := http.Get(server.URL + "/status")
+	if err != nil {
+		t.Fatalf("GET /status: %v", err)
+	}
+	defer resp.Body.Close()
+	var status Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if status.Breakers["audio_source"].State != actions.BreakerOpen || status.Layers.Active != "base" || status.Volume == nil || !status.Volume.Muted {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
@@ -0,0 +1,110 @@
+// Package config verwaltet die Konfiguration des MidiDaemon.
+// Diese Datei enthält die Einstellungen für die Audio-Backends.
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Audio-Backends unter Linux
+const (
+	AudioBackendAuto   = "auto"   // automatisch wählen
+	AudioBackendPulse  = "pulse"  // natives PulseAudio-Protokoll (auch PipeWire über pipewire-pulse)
+	AudioBackendWpctl  = "wpctl"  // WirePlumber-Kommandozeile
+	AudioBackendPactl  = "pactl"  // PulseAudio-Kommandozeile
+	AudioBackendAmixer = "amixer" // ALSA-Mixer (nur Lautstärke)
+)
+
+// AudioConfig enthält die Einstellungen für Lautstärke und Audioquellen
+type AudioConfig struct {
+	// Backend: "auto", "pulse", "wpctl", "pactl" oder "amixer" (nur Linux)
+	Backend string `json:"backend,omitempty"`
+
+	// ALSA-Karte für das amixer-Backend (leer = Standardkarte)
+	Card string `json:"card,omitempty"`
+
+	// ALSA-Regler für das amixer-Backend
+	Control string `json:"control,omitempty"`
+
+	// Datei für gespeicherte Audio-Szenen (Standard: scenes.json neben der Konfiguration)
+	ScenesFile string `json:"scenes_file,omitempty"`
+
+	// Automatisches Absenken anderer Anwendungen bei aktivem Mikrofon
+	Ducking DuckingConfig `json:"ducking"`
+}
+
+// DuckingConfig enthält die Einstellungen für das automatische Absenken (Ducking)
+type DuckingConfig struct {
+	// Beim Start aktiv (zur Laufzeit über die Aktion "ducking" umschaltbar)
+	Enabled bool `json:"enabled"`
+
+	// Eingabegerät, das das Absenken auslöst (leer oder "default" = Standard-Mikrofon)
+	Source string `json:"source,omitempty"`
+
+	// Absenkung in Prozent der Lautstärke jedes Streams
+	Amount int `json:"amount,omitempty"`
+
+	// Anwendungen, die nicht abgesenkt werden (Muster auf Anwendungs- oder Programmnamen)
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Abfrageintervall in Millisekunden
+	Interval int `json:"interval,omitempty"`
+
+	// Wartezeit in Millisekunden nach dem Ende der Aktivität, bevor die Lautstärken wiederhergestellt werden
+	Release int `json:"release,omitempty"`
+}
+
+// ScenesPath gibt den Pfad der Szenen-Datei zurück. Relative Pfade beziehen sich auf das Verzeichnis
+// der Konfigurationsdatei; ohne Konfigurationsdatei und ohne scenes_file ist er leer (Szenen nur im Speicher).
+func (c *Config) ScenesPath() string {
+	file := c.Audio.ScenesFile
+	if c.path == "" || filepath.IsAbs(file) {
+		return file
+	}
+	if file == "" {
+		file = "scenes.json"
+	}
+	return filepath.Join(filepath.Dir(c.path), file)
+}
+
+// setAudioDefaults setzt Standardwerte für die Audio-Einstellungen
+func setAudioDefaults(audio *AudioConfig) {
+	if audio.Backend == "" {
+		audio.Backend = AudioBackendAuto
+	}
+	if audio.Control == "" {
+		audio.Control = "Master"
+	}
+	if audio.Ducking.Amount == 0 {
+		audio.Ducking.Amount = 50
+	}
+	if audio.Ducking.Interval == 0 {
+		audio.Ducking.Interval = 250
+	}
+	if audio.Ducking.Release == 0 {
+		audio.Ducking.Release = 1000
+	}
+}
+
+// validateAudio überprüft die Audio-Einstellungen auf Gültigkeit
+func validateAudio(audio *AudioConfig) error {
+	switch audio.Backend {
+	case AudioBackendAuto, AudioBackendPulse, AudioBackendWpctl, AudioBackendPactl, AudioBackendAmixer:
+	default:
+		return fmt.Errorf("unbekanntes Backend: %s (erwartet: auto, pulse, wpctl, pactl, amixer)", audio.Backend)
+	}
+
+	ducking := audio.Ducking
+	if ducking.Amount < 1 || ducking.Amount > 100 {
+		return fmt.Errorf("ducking.amount muss zwischen 1 und 100 liegen, got: %d", ducking.Amount)
+	}
+	if ducking.Interval < 20 {
+		return fmt.Errorf("ducking.interval muss mindestens 20 ms betragen, got: %d", ducking.Interval)
+	}
+	if ducking.Release < 0 {
+		return fmt.Errorf("ducking.release darf nicht negativ sein, got: %d", ducking.Release)
+	}
+	return nil
+}
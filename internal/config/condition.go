@@ -0,0 +1,50 @@
+// Package config verwaltet die Konfiguration für MidiDaemon.
+// Diese Datei enthält das Schema für Mapping-Bedingungen ("when").
+
+package config
+
+import (
+	"github.com/Xcruser/MidiDaemon/internal/expr"
+)
+
+// ConditionSchema gibt die in Bedingungen verfügbaren Bezeichner und deren Typen zurück
+func ConditionSchema(variables map[string]interface{}) expr.Schema {
+	schema := expr.Schema{
+		// Event
+		"value":      expr.TypeNumber,
+		"note":       expr.TypeNumber,
+		"velocity":   expr.TypeNumber,
+		"controller": expr.TypeNumber,
+		"program":    expr.TypeNumber,
+		"channel":    expr.TypeNumber,
+		"type":       expr.TypeString,
+		"port":       expr.TypeString,
+
+		// Zustand
+		"mapping": expr.TypeString,
+		"layer":   expr.TypeString,
+		"avg":     expr.TypeNumber, // gleitender Mittelwert der letzten Event-Werte des Mappings
+
+		// Zeit
+		"time":    expr.TypeString, // "HH:MM"
+		"hour":    expr.TypeNumber,
+		"weekday": expr.TypeString, // "mon" bis "sun"
+
+		// Audio
+		"audio.source":      expr.TypeString, // Name der aktuellen Standard-Audioquelle
+		"audio.type":        expr.TypeString, // Typ der aktuellen Standard-Audioquelle
+		"audio.volume":      expr.TypeNumber, // Systemlautstärke in Prozent
+		"audio.muted":       expr.TypeBool,   // Systemlautstärke stummgeschaltet
+		"audio.input":       expr.TypeString, // Name des aktuellen Standard-Eingabegeräts (Mikrofon)
+		"audio.input.muted": expr.TypeBool,   // Standard-Eingabegerät stummgeschaltet
+		"audio.ducking":     expr.TypeBool,   // Ducking eingeschaltet
+		"audio.ducked":      expr.TypeBool,   // Andere Anwendungen sind gerade abgesenkt
+	}
+
+	// Benutzervariablen
+	for name := range variables {
+		schema["vars."+name] = expr.TypeAny
+	}
+
+	return schema
+}
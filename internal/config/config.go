@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/Xcruser/MidiDaemon/internal/expr"
 )
 
 // Config repräsentiert die Hauptkonfiguration von MidiDaemon
@@ -17,8 +19,23 @@ type Config struct {
 	// Mappings zwischen MIDI-Events und Aktionen
 	Mappings []Mapping `json:"mappings"`
 
+	// Shift-Layer und Mapping-Bänke
+	Layers LayerConfig `json:"layers"`
+
+	// Benutzervariablen mit Startwerten (in Templates über {{.Vars.name}} verfügbar)
+	Variables map[string]interface{} `json:"variables,omitempty"`
+
+	// Audio-Backends für Lautstärke und Audioquellen
+	Audio AudioConfig `json:"audio"`
+
+	// Simulierte Tastatur- und Mauseingaben
+	Input InputConfig `json:"input"`
+
 	// Allgemeine Einstellungen
 	General GeneralConfig `json:"general"`
+
+	// Absoluter Pfad der geladenen Datei (leer bei erzeugten Konfigurationen)
+	path string
 }
 
 // MIDIConfig enthält MIDI-spezifische Einstellungen
@@ -62,16 +79,99 @@ type Mapping struct {
 	// MIDI-Event Definition
 	Event MIDIEvent `json:"event"`
 
+	// Zusammengesetzter Auslöser (Akkord oder Sequenz), ersetzt Event falls gesetzt
+	Trigger *Trigger `json:"trigger,omitempty"`
+
 	// Systemaktion die ausgeführt werden soll
 	Action Action `json:"action"`
 
+	// Makro aus mehreren Schritten, ersetzt Action falls gesetzt
+	Macro *Macro `json:"macro,omitempty"`
+
+	// Maximale Ausführungsdauer der Aktion bzw. des Makros in Millisekunden (0 = unbegrenzt)
+	Timeout int `json:"timeout,omitempty"`
+
+	// Abweichende Wiederholungs-Einstellungen für dieses Mapping
+	Retry *RetryConfig `json:"retry,omitempty"`
+
+	// Modus: "trigger" (Standard), "toggle" oder "momentary"
+	Mode string `json:"mode,omitempty"`
+
+	// Zweite Aktion: bei "toggle" jeder zweite Auslöser, bei "momentary" das Loslassen
+	OffAction *Action `json:"off_action,omitempty"`
+
 	// Aktiviert/Deaktiviert
 	Enabled bool `json:"enabled"`
+
+	// Layer, in denen das Mapping aktiv ist ("*" für alle, leer für den Standard-Layer)
+	Layers []string `json:"layers,omitempty"`
+
+	// Bedingung, unter der das Mapping auslöst (z.B. "between(time, \"09:00\", \"18:00\")")
+	When string `json:"when,omitempty"`
+
+	// Kompilierte Bedingung (wird in Load erzeugt)
+	condition *expr.Program
+}
+
+// Condition gibt die kompilierte Bedingung des Mappings zurück (nil falls keine gesetzt)
+func (m Mapping) Condition() *expr.Program {
+	return m.condition
+}
+
+// AllLayers kennzeichnet ein Mapping, das in allen Layern aktiv ist
+const AllLayers = "*"
+
+// LayerConfig enthält die Definition der Layer
+type LayerConfig struct {
+	// Name des Layers, der beim Start aktiv ist (Standard: erste Bank)
+	Default string `json:"default,omitempty"`
+
+	// Verfügbare Layer
+	Definitions []Layer `json:"definitions,omitempty"`
+
+	// Event, das zyklisch durch alle Bänke schaltet (optional)
+	Cycle *MIDIEvent `json:"cycle,omitempty"`
+}
+
+// Layer definiert einen Shift-Layer oder eine Mapping-Bank
+type Layer struct {
+	// Name des Layers
+	Name string `json:"name"`
+
+	// Shift-Pad: der Layer ist aktiv, solange diese Note gehalten wird
+	Shift *MIDIEvent `json:"shift,omitempty"`
+
+	// Program-Nummer, die diese Bank direkt auswählt (optional)
+	Program *int `json:"program,omitempty"`
+}
+
+// IsBank gibt zurück ob der Layer eine Bank (kein Shift-Layer) ist
+func (l Layer) IsBank() bool {
+	return l.Shift == nil
+}
+
+// InLayer gibt zurück ob das Mapping im angegebenen Layer aktiv ist
+func (m Mapping) InLayer(layer string, layers LayerConfig) bool {
+	// Ohne Layer-Definitionen sind alle Mappings aktiv
+	if len(layers.Definitions) == 0 {
+		return true
+	}
+
+	if len(m.Layers) == 0 {
+		return layer == layers.Default
+	}
+
+	for _, name := range m.Layers {
+		if name == AllLayers || name == layer {
+			return true
+		}
+	}
+	return false
 }
 
 // MIDIEvent definiert ein MIDI-Event
 type MIDIEvent struct {
-	// Typ des Events: "note_on", "note_off", "control_change", "program_change"
+	// Typ des Events: "note_on", "note_off", "control_change", "program_change", "pitch_bend"
 	Type string `json:"type"`
 
 	// MIDI-Note (0-127) für Note Events
@@ -90,13 +190,31 @@ type MIDIEvent struct {
 	Value int `json:"value,omitempty"`
 }
 
+// Trigger definiert einen Auslöser aus mehreren MIDI-Events
+type Trigger struct {
+	// Typ des Auslösers: "chord" (gleichzeitig gehaltene Noten) oder "sequence" (geordnete Events)
+	Type string `json:"type"`
+
+	// Beteiligte Events, bei Sequenzen in der erwarteten Reihenfolge
+	Events []MIDIEvent `json:"events"`
+
+	// Toleranzfenster für Akkorde in Millisekunden
+	Window int `json:"window,omitempty"`
+
+	// Maximaler Abstand zwischen zwei Sequenz-Schritten in Millisekunden
+	Timeout int `json:"timeout,omitempty"`
+}
+
 // Action definiert eine Systemaktion
 type Action struct {
-	// Typ der Aktion: "volume", "app_start", "key_combination", "audio_source"
+	// Typ der Aktion: "volume", "app_volume", "app_start", "command", "key_combination", "mouse", "audio_source", "audio_scene", "ducking", "variable"
 	Type string `json:"type"`
 
 	// Parameter für die Aktion (abhängig vom Typ)
 	Parameters map[string]interface{} `json:"parameters"`
+
+	// Überträgt den Event-Wert skaliert in einen Parameter (optional)
+	Value *ValueMapping `json:"value,omitempty"`
 }
 
 // GeneralConfig enthält allgemeine Einstellungen
@@ -109,6 +227,43 @@ type GeneralConfig struct {
 
 	// Verzögerung zwischen Aktionen in Millisekunden
 	ActionDelay int `json:"action_delay"`
+
+	// Wiederholung fehlgeschlagener Aktionen
+	Retry RetryConfig `json:"retry"`
+
+	// Abweichende Wiederholungs-Einstellungen je Aktionstyp
+	ActionRetry map[string]RetryConfig `json:"action_retry,omitempty"`
+
+	// Circuit-Breaker je Aktionstyp
+	Breaker BreakerConfig `json:"breaker"`
+
+	// Verlauf der ausgeführten Aktionen
+	History HistoryConfig `json:"history"`
+}
+
+// HistoryConfig enthält die Einstellungen für den Aktionsverlauf
+type HistoryConfig struct {
+	// Anzahl der Einträge im Speicher
+	Size int `json:"size"`
+
+	// Optionale JSONL-Datei, in die jeder Eintrag zusätzlich geschrieben wird
+	File string `json:"file,omitempty"`
+
+	// Maximale Größe der Datei in KB, bevor sie rotiert wird
+	MaxFileSize int `json:"max_file_size,omitempty"`
+
+	// Anzahl der aufbewahrten rotierten Dateien
+	MaxFiles int `json:"max_files,omitempty"`
+}
+
+// HistoryPath gibt den Pfad der Verlaufsdatei zurück. Relative Pfade beziehen sich wie bei ScenesPath auf
+// das Verzeichnis der Konfigurationsdatei, damit der Verlauf nicht vom Arbeitsverzeichnis des Daemons abhängt.
+func (c *Config) HistoryPath() string {
+	file := c.General.History.File
+	if file == "" || c.path == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(filepath.Dir(c.path), file)
 }
 
 // Load lädt die Konfiguration aus einer JSON-Datei
@@ -133,6 +288,8 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("fehler beim Parsen der Konfigurationsdatei: %w", err)
 	}
 
+	config.path = absPath
+
 	// Standardwerte setzen falls nicht definiert
 	setDefaults(&config)
 
@@ -144,6 +301,11 @@ func Load(configPath string) (*Config, error) {
 	return &config, nil
 }
 
+// Path gibt den Pfad zurück, aus dem die Konfiguration geladen wurde
+func (c *Config) Path() string {
+	return c.path
+}
+
 // setDefaults setzt Standardwerte für fehlende Konfigurationsoptionen
 func setDefaults(config *Config) {
 	// MIDI-Standardwerte
@@ -161,12 +323,59 @@ func setDefaults(config *Config) {
 	if config.General.ActionDelay == 0 {
 		config.General.ActionDelay = 100 // 100ms
 	}
+	setRetryDefaults(&config.General)
+	setAudioDefaults(&config.Audio)
+	if config.General.History.Size == 0 {
+		config.General.History.Size = 200
+	}
+	if config.General.History.MaxFileSize == 0 {
+		config.General.History.MaxFileSize = 1024 // 1 MB
+	}
+	if config.General.History.MaxFiles == 0 {
+		config.General.History.MaxFiles = 3
+	}
+
+	// Standard-Layer ist die erste Bank
+	if config.Layers.Default == "" {
+		for _, layer := range config.Layers.Definitions {
+			if layer.IsBank() {
+				config.Layers.Default = layer.Name
+				break
+			}
+		}
+	}
 
 	// Alle Mappings standardmäßig aktivieren
 	for i := range config.Mappings {
 		if !config.Mappings[i].Enabled {
 			config.Mappings[i].Enabled = true
 		}
+		if config.Mappings[i].Mode == "" {
+			config.Mappings[i].Mode = "trigger"
+		}
+
+		// Standardwerte für Wert-Zuordnungen
+		if value := config.Mappings[i].Action.Value; value != nil {
+			setValueDefaults(value)
+		}
+		if off := config.Mappings[i].OffAction; off != nil && off.Value != nil {
+			setValueDefaults(off.Value)
+		}
+
+		// Makro-Standardwerte
+		if macro := config.Mappings[i].Macro; macro != nil {
+			setMacroDefaults(macro)
+		}
+
+		// Trigger-Standardwerte
+		if trigger := config.Mappings[i].Trigger; trigger != nil {
+			if trigger.Window == 0 {
+				trigger.Window = 100 // 100ms
+			}
+			if trigger.Timeout == 0 {
+				trigger.Timeout = 1000 // 1 Sekunde
+			}
+		}
 	}
 }
 
@@ -177,11 +386,51 @@ func validate(config *Config) error {
 		return fmt.Errorf("ungültiger MIDI-Kanal: %d (muss zwischen -1 und 15 liegen)", config.MIDI.Channel)
 	}
 
+	// Wiederholungen und Circuit-Breaker validieren
+	if err := validateRetryConfig(&config.General); err != nil {
+		return fmt.Errorf("ungültige Wiederholungs-Konfiguration: %w", err)
+	}
+
+	// Audio-Einstellungen validieren
+	if err := validateAudio(&config.Audio); err != nil {
+		return fmt.Errorf("ungültige Audio-Konfiguration: %w", err)
+	}
+
+	// Eingabe-Einstellungen validieren
+	if err := validateInput(&config.Input); err != nil {
+		return fmt.Errorf("ungültige Eingabe-Konfiguration: %w", err)
+	}
+
+	// Verlauf validieren
+	if history := config.General.History; history.Size < 0 || history.MaxFileSize < 0 || history.MaxFiles < 0 {
+		return fmt.Errorf("ungültige Verlaufs-Konfiguration: werte dürfen nicht negativ sein")
+	}
+
+	// Layer validieren
+	if err := validateLayers(&config.Layers); err != nil {
+		return fmt.Errorf("ungültige Layer-Konfiguration: %w", err)
+	}
+
 	// Mappings validieren
 	for i, mapping := range config.Mappings {
 		if err := validateMapping(&mapping); err != nil {
 			return fmt.Errorf("ungültiges Mapping %d (%s): %w", i, mapping.Name, err)
 		}
+		if err := validateMappingLayers(&mapping, &config.Layers); err != nil {
+			return fmt.Errorf("ungültiges Mapping %d (%s): %w", i, mapping.Name, err)
+		}
+		if err := validateMappingTemplates(&mapping, config.Variables); err != nil {
+			return fmt.Errorf("ungültiges Mapping %d (%s): %w", i, mapping.Name, err)
+		}
+
+		// Bedingung kompilieren und typprüfen
+		if mapping.When != "" {
+			condition, err := expr.Compile(mapping.When, ConditionSchema(config.Variables))
+			if err != nil {
+				return fmt.Errorf("ungültiges Mapping %d (%s): ungültige Bedingung '%s': %w", i, mapping.Name, mapping.When, err)
+			}
+			config.Mappings[i].condition = condition
+		}
 	}
 
 	return nil
@@ -189,16 +438,64 @@ func validate(config *Config) error {
 
 // validateMapping überprüft ein einzelnes Mapping auf Gültigkeit
 func validateMapping(mapping *Mapping) error {
-	// Event validieren
-	if err := validateMIDIEvent(&mapping.Event); err != nil {
+	// Event bzw. Trigger validieren
+	if mapping.Trigger != nil {
+		if err := validateTrigger(mapping.Trigger); err != nil {
+			return fmt.Errorf("ungültiger Trigger: %w", err)
+		}
+	} else if err := validateMIDIEvent(&mapping.Event); err != nil {
 		return fmt.Errorf("ungültiges MIDI-Event: %w", err)
 	}
 
-	// Action validieren
-	if err := validateAction(&mapping.Action); err != nil {
+	// Action bzw. Makro validieren
+	if mapping.Macro != nil {
+		if mapping.Action.Type != "" {
+			return fmt.Errorf("action und macro können nicht gleichzeitig gesetzt sein")
+		}
+		if err := validateMacro(mapping.Macro); err != nil {
+			return fmt.Errorf("ungültiges Makro: %w", err)
+		}
+	} else if err := validateAction(&mapping.Action); err != nil {
 		return fmt.Errorf("ungültige Aktion: %w", err)
 	}
 
+	if mapping.Timeout < 0 {
+		return fmt.Errorf("ungültiges Timeout: %d (muss >= 0 sein)", mapping.Timeout)
+	}
+	if mapping.Retry != nil {
+		if err := validateRetry(*mapping.Retry); err != nil {
+			return fmt.Errorf("ungültige Wiederholungs-Einstellung: %w", err)
+		}
+	}
+
+	// Modus validieren
+	switch mapping.Mode {
+	case "", "trigger":
+		if mapping.OffAction != nil {
+			return fmt.Errorf("off_action ist nur bei den Modi toggle und momentary erlaubt")
+		}
+	case "toggle", "momentary":
+		if mapping.Macro != nil {
+			return fmt.Errorf("makros sind nur im Modus trigger erlaubt")
+		}
+		if mapping.OffAction == nil {
+			return fmt.Errorf("modus %s benötigt eine off_action", mapping.Mode)
+		}
+		if err := validateAction(mapping.OffAction); err != nil {
+			return fmt.Errorf("ungültige off_action: %w", err)
+		}
+		if mapping.Mode == "momentary" {
+			if mapping.Trigger != nil {
+				return fmt.Errorf("modus momentary ist mit Triggern nicht möglich")
+			}
+			if mapping.Event.Type != "note_on" && mapping.Event.Type != "control_change" {
+				return fmt.Errorf("modus momentary benötigt ein note_on- oder control_change-Event")
+			}
+		}
+	default:
+		return fmt.Errorf("ungültiger Modus: %s (erwartet: trigger, toggle, momentary)", mapping.Mode)
+	}
+
 	return nil
 }
 
@@ -223,6 +520,8 @@ func validateMIDIEvent(event *MIDIEvent) error {
 		if event.Program < 0 || event.Program > 127 {
 			return fmt.Errorf("ungültiges Program: %d (muss zwischen 0 und 127 liegen)", event.Program)
 		}
+	case "pitch_bend":
+		// Pitch-Bend hat keine weiteren Filter, der 14-Bit-Wert steht in Value
 	default:
 		return fmt.Errorf("ungültiger Event-Typ: %s", event.Type)
 	}
@@ -230,6 +529,159 @@ func validateMIDIEvent(event *MIDIEvent) error {
 	return nil
 }
 
+// validateLayers überprüft die Layer-Definitionen auf Gültigkeit
+func validateLayers(layers *LayerConfig) error {
+	if len(layers.Definitions) == 0 {
+		if layers.Default != "" || layers.Cycle != nil {
+			return fmt.Errorf("keine Layer definiert")
+		}
+		return nil
+	}
+
+	names := make(map[string]bool)
+	programs := make(map[int]string)
+	banks := 0
+	for i, layer := range layers.Definitions {
+		if layer.Name == "" || layer.Name == AllLayers {
+			return fmt.Errorf("layer %d hat einen ungültigen Namen: '%s'", i, layer.Name)
+		}
+		if names[layer.Name] {
+			return fmt.Errorf("layer '%s' ist mehrfach definiert", layer.Name)
+		}
+		names[layer.Name] = true
+
+		if layer.Shift != nil {
+			if layer.Shift.Type != "note_on" {
+				return fmt.Errorf("shift-Event von Layer '%s' muss vom Typ note_on sein", layer.Name)
+			}
+			if err := validateMIDIEvent(layer.Shift); err != nil {
+				return fmt.Errorf("ungültiges Shift-Event von Layer '%s': %w", layer.Name, err)
+			}
+			if layer.Program != nil {
+				return fmt.Errorf("shift-Layer '%s' kann nicht über Program Change gewählt werden", layer.Name)
+			}
+			continue
+		}
+
+		banks++
+		if layer.Program != nil {
+			if *layer.Program < 0 || *layer.Program > 127 {
+				return fmt.Errorf("ungültiges Program für Layer '%s': %d", layer.Name, *layer.Program)
+			}
+			if other, exists := programs[*layer.Program]; exists {
+				return fmt.Errorf("program %d ist bereits Layer '%s' zugeordnet", *layer.Program, other)
+			}
+			programs[*layer.Program] = layer.Name
+		}
+	}
+
+	if banks == 0 {
+		return fmt.Errorf("mindestens ein Layer muss eine Bank (ohne Shift) sein")
+	}
+	if !names[layers.Default] {
+		return fmt.Errorf("standard-Layer '%s' ist nicht definiert", layers.Default)
+	}
+	for _, layer := range layers.Definitions {
+		if layer.Name == layers.Default && !layer.IsBank() {
+			return fmt.Errorf("standard-Layer '%s' darf kein Shift-Layer sein", layers.Default)
+		}
+	}
+
+	if layers.Cycle != nil {
+		if err := validateMIDIEvent(layers.Cycle); err != nil {
+			return fmt.Errorf("ungültiges Cycle-Event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateMappingLayers überprüft die Layer-Zuordnung eines Mappings
+func validateMappingLayers(mapping *Mapping, layers *LayerConfig) error {
+	for _, name := range mapping.Layers {
+		if name == AllLayers {
+			continue
+		}
+		found := false
+		for _, layer := range layers.Definitions {
+			if layer.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unbekannter Layer: %s", name)
+		}
+	}
+	return nil
+}
+
+// validateMappingTemplates überprüft Templates und Variablenzugriffe der Aktionen eines Mappings
+func validateMappingTemplates(mapping *Mapping, variables map[string]interface{}) error {
+	actions := []*Action{&mapping.Action}
+	if mapping.OffAction != nil {
+		actions = append(actions, mapping.OffAction)
+	}
+	var steps []string
+	if mapping.Macro != nil {
+		actions = actions[:0]
+		for i := range mapping.Macro.Steps {
+			actions = append(actions, &mapping.Macro.Steps[i].Action)
+			steps = append(steps, mapping.Macro.Steps[i].Name)
+		}
+	}
+
+	for _, action := range actions {
+		if err := checkTemplates(action, variables, steps...); err != nil {
+			return fmt.Errorf("ungültiges Template in Aktion '%s': %w", action.Type, err)
+		}
+		if action.Type == "variable" {
+			name, _ := action.Parameters["name"].(string)
+			if _, declared := variables[name]; !declared {
+				return fmt.Errorf("variable '%s' ist nicht unter 'variables' deklariert", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateTrigger überprüft einen Akkord- oder Sequenz-Trigger auf Gültigkeit
+func validateTrigger(trigger *Trigger) error {
+	switch trigger.Type {
+	case "chord":
+		if len(trigger.Events) < 2 {
+			return fmt.Errorf("akkord benötigt mindestens 2 Noten")
+		}
+		for i, event := range trigger.Events {
+			if event.Type != "note_on" {
+				return fmt.Errorf("akkord-Event %d muss vom Typ note_on sein", i)
+			}
+		}
+	case "sequence":
+		if len(trigger.Events) < 2 {
+			return fmt.Errorf("sequenz benötigt mindestens 2 Events")
+		}
+	default:
+		return fmt.Errorf("ungültiger Trigger-Typ: %s (erwartet: chord, sequence)", trigger.Type)
+	}
+
+	for i := range trigger.Events {
+		if err := validateMIDIEvent(&trigger.Events[i]); err != nil {
+			return fmt.Errorf("event %d: %w", i, err)
+		}
+	}
+
+	if trigger.Window < 0 {
+		return fmt.Errorf("window darf nicht negativ sein")
+	}
+	if trigger.Timeout < 0 {
+		return fmt.Errorf("timeout darf nicht negativ sein")
+	}
+
+	return nil
+}
+
 // validateAction überprüft eine Aktion auf Gültigkeit
 func validateAction(action *Action) error {
 	switch action.Type {
@@ -238,6 +690,17 @@ func validateAction(action *Action) error {
 		if _, ok := action.Parameters["direction"]; !ok {
 			return fmt.Errorf("volume-Aktion benötigt 'direction' Parameter")
 		}
+	case "app_volume":
+		// App-Volume-Aktionen benötigen "direction" und eine Auswahl der Anwendung
+		if _, ok := action.Parameters["direction"]; !ok {
+			return fmt.Errorf("app_volume-Aktion benötigt 'direction' Parameter")
+		}
+		_, hasApp := action.Parameters["app"]
+		_, hasBinary := action.Parameters["binary"]
+		_, hasPID := action.Parameters["pid"]
+		if !hasApp && !hasBinary && !hasPID {
+			return fmt.Errorf("app_volume-Aktion benötigt 'app', 'binary' oder 'pid' Parameter")
+		}
 	case "app_start":
 		// App-Start-Aktionen benötigen einen "path" Parameter
 		if _, ok := action.Parameters["path"]; !ok {
@@ -248,15 +711,47 @@ func validateAction(action *Action) error {
 		if _, ok := action.Parameters["keys"]; !ok {
 			return fmt.Errorf("key_combination-Aktion benötigt 'keys' Parameter")
 		}
+	case "mouse":
+		// Mausaktionen benötigen einen "operation" Parameter
+		if _, ok := action.Parameters["operation"].(string); !ok {
+			return fmt.Errorf("mouse-Aktion benötigt 'operation' Parameter")
+		}
+	case "command":
+		// Befehle benötigen einen "command" Parameter
+		if _, ok := action.Parameters["command"].(string); !ok {
+			return fmt.Errorf("command-Aktion benötigt 'command' Parameter")
+		}
 	case "audio_source":
 		// Audio-Quelle benötigt "source" Parameter
 		if _, ok := action.Parameters["source"]; !ok {
 			return fmt.Errorf("audio_source-Aktion benötigt 'source' Parameter")
 		}
+	case "audio_scene":
+		// Audio-Szenen benötigen einen "scene" Parameter
+		if _, ok := action.Parameters["scene"].(string); !ok {
+			return fmt.Errorf("audio_scene-Aktion benötigt 'scene' Parameter")
+		}
+	case "ducking":
+		// Ducking-Aktionen benötigen einen "operation" Parameter
+		if _, ok := action.Parameters["operation"].(string); !ok {
+			return fmt.Errorf("ducking-Aktion benötigt 'operation' Parameter")
+		}
+	case "variable":
+		// Variablen-Aktionen benötigen einen "name" Parameter
+		if _, ok := action.Parameters["name"].(string); !ok {
+			return fmt.Errorf("variable-Aktion benötigt 'name' Parameter")
+		}
 	default:
 		return fmt.Errorf("ungültiger Aktion-Typ: %s", action.Type)
 	}
 
+	// Wert-Zuordnung validieren
+	if action.Value != nil {
+		if err := validateValueMapping(action.Value); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
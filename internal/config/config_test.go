@@ -2,6 +2,8 @@ package config
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -28,3 +30,202 @@ func TestSetDefaultsChannelZero(t *testing.T) {
 		t.Fatalf("expected channel 0, got %d", cfg.MIDI.Channel)
 	}
 }
+
+func TestMappingInLayer(t *testing.T) {
+	data := []byte(`{
+		"layers": {"definitions": [{"name": "base"}, {"name": "fx", "shift": {"type": "note_on", "note": 48}}]},
+		"mappings": []
+	}`)
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	setDefaults(&cfg)
+	if err := validate(&cfg); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if cfg.Layers.Default != "base" {
+		t.Fatalf("expected default layer base, got %q", cfg.Layers.Default)
+	}
+
+	plain := Mapping{}
+	if !plain.InLayer("base", cfg.Layers) || plain.InLayer("fx", cfg.Layers) {
+		t.Fatalf("mapping without layers must only be active in the default layer")
+	}
+	all := Mapping{Layers: []string{AllLayers}}
+	if !all.InLayer("fx", cfg.Layers) {
+		t.Fatalf("mapping with '*' must be active in every layer")
+	}
+
+	cfg.Mappings = []Mapping{{Name: "x", Layers: []string{"missing"}, Event: MIDIEvent{Type: "note_on"}, Action: Action{Type: "volume", Parameters: map[string]interface{}{"direction": "up"}}}}
+	if err := validate(&cfg); err == nil {
+		t.Fatalf("expected error for unknown layer")
+	}
+}
+
+func TestValueMappingScale(t *testing.T) {
+	value := &ValueMapping{Parameter: "volume"}
+	setValueDefaults(value)
+
+	if got := value.Scale(127); got != 100 {
+		t.Fatalf("expected 100 for full scale, got %v", got)
+	}
+	if got := value.Scale(64); got != 50 {
+		t.Fatalf("expected 50 for half scale, got %v", got)
+	}
+
+	value.Invert = true
+	if got := value.Scale(0); got != 100 {
+		t.Fatalf("expected inverted 100, got %v", got)
+	}
+
+	fine := &ValueMapping{Parameter: "volume", Min: 10, Max: 20, Bits: 14, Round: "none"}
+	if got := fine.Scale(16383); got != 20 {
+		t.Fatalf("expected 20 for 14-bit full scale, got %v", got)
+	}
+
+	action := Action{Type: "volume", Parameters: map[string]interface{}{"direction": "set"}, Value: value}
+	resolved := action.WithValue(127)
+	if resolved.Parameters["volume"] != 0 {
+		t.Fatalf("expected parameter volume=0, got %v", resolved.Parameters["volume"])
+	}
+	if _, exists := action.Parameters["volume"]; exists {
+		t.Fatalf("original parameters must not be modified")
+	}
+}
+
+func TestTemplatesCheckedAndRendered(t *testing.T) {
+	action := Action{
+		Type: "app_start",
+		Parameters: map[string]interface{}{
+			"path": "obs",
+			"args": []interface{}{"--scene", "{{.Vars.scene}}", "--note={{.Note}}"},
+		},
+	}
+	variables := map[string]interface{}{"scene": "Intro"}
+
+	if err := checkTemplates(&action, variables); err != nil {
+		t.Fatalf("expected valid templates, got %v", err)
+	}
+
+	rendered, err := action.Render(TemplateData{Note: 60, Vars: variables})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	args := rendered.Parameters["args"].([]interface{})
+	if args[1] != "Intro" || args[2] != "--note=60" {
+		t.Fatalf("unexpected rendered args: %v", args)
+	}
+
+	typo := Action{Type: "volume", Parameters: map[string]interface{}{"direction": "set", "volume": "{{.Vlaue}}"}}
+	if err := checkTemplates(&typo, variables); err == nil {
+		t.Fatalf("expected error for unknown field")
+	}
+
+	// Ergebnisse von Makro-Schritten sind nur für vorhandene Schritte erreichbar
+	step := Action{Type: "command", Parameters: map[string]interface{}{"command": "notify-send", "args": []interface{}{"{{.Steps.build.Output}}", "{{.Last.ExitCode}}"}}}
+	if err := checkTemplates(&step, variables, "build", "report"); err != nil {
+		t.Fatalf("expected valid step templates, got %v", err)
+	}
+	if err := checkTemplates(&step, variables, "test"); err == nil {
+		t.Fatalf("expected error for unknown step")
+	}
+
+	number := Action{Type: "volume", Parameters: map[string]interface{}{"volume": "{{scale .Value 0 100}}"}}
+	rendered, err = number.Render(TemplateData{Value: 127})
+	if err != nil || rendered.Parameters["volume"] != 100 {
+		t.Fatalf("expected numeric 100, got %v (%v)", rendered.Parameters["volume"], err)
+	}
+}
+
+func TestMacroValidation(t *testing.T) {
+	data := []byte(`{
+		"mappings": [{
+			"name": "Headset",
+			"event": {"type": "note_on", "note": 36},
+			"macro": {"steps": [
+				{"action": {"type": "audio_source", "parameters": {"source": "headset"}}, "on_failure": "fallback"},
+				{"delay": 200, "action": {"type": "volume", "parameters": {"direction": "set", "volume": 40}}, "continue_on_error": true},
+				{"name": "fallback", "action": {"type": "app_start", "parameters": {"path": "call-app"}}}
+			]}
+		}]
+	}`)
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	setDefaults(&cfg)
+	if err := validate(&cfg); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if name := cfg.Mappings[0].Macro.Steps[1].Name; name != "2" {
+		t.Fatalf("expected default step name 2, got %q", name)
+	}
+
+	cfg.Mappings[0].Macro.Steps[0].OnFailure = "missing"
+	if err := validate(&cfg); err == nil {
+		t.Fatalf("expected error for unknown jump target")
+	}
+
+	cfg.Mappings[0].Macro.Steps[0].OnFailure = ""
+	cfg.Mappings[0].Action = Action{Type: "volume", Parameters: map[string]interface{}{"direction": "up"}}
+	if err := validate(&cfg); err == nil {
+		t.Fatalf("expected error for action and macro on the same mapping")
+	}
+}
+
+func TestLoadActionTypes(t *testing.T) {
+	tests := []struct {
+		action string
+		valid  bool
+	}{
+		{`{"type": "audio_scene", "parameters": {"scene": "call", "operation": "save"}}`, true},
+		{`{"type": "audio_scene", "parameters": {"operation": "save"}}`, false},
+		{`{"type": "ducking", "parameters": {"operation": "toggle"}}`, true},
+		{`{"type": "ducking", "parameters": {}}`, false},
+		{`{"type": "mouse", "parameters": {"operation": "scroll", "encoder": "twos_complement"}, "value": {"parameter": "y", "max": 127}}`, true},
+		{`{"type": "mouse", "parameters": {"x": 10}}`, false},
+		{`{"type": "command", "parameters": {"command": "notify-send", "args": ["MidiDaemon"], "timeout": 5000}}`, true},
+		{`{"type": "command", "parameters": {"args": ["MidiDaemon"]}}`, false},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "config.json")
+		data := `{"mappings": [{"name": "m", "event": {"type": "note_on", "note": 36}, "action": ` + test.action + `}]}`
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		_, err := Load(path)
+		if test.valid && err != nil {
+			t.Fatalf("Load(%s): %v", test.action, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("Load(%s): expected error", test.action)
+		}
+	}
+}
+
+func TestHistoryPath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	data := `{"general": {"history": {"file": "logs/history.jsonl"}}, "mappings": []}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.HistoryPath(); got != filepath.Join(dir, "logs", "history.jsonl") {
+		t.Fatalf("relative path not resolved against config dir: %s", got)
+	}
+
+	cfg.General.History.File = "/var/log/history.jsonl"
+	if got := cfg.HistoryPath(); got != "/var/log/history.jsonl" {
+		t.Fatalf("absolute path changed: %s", got)
+	}
+	cfg.General.History.File = ""
+	if got := cfg.HistoryPath(); got != "" {
+		t.Fatalf("empty path changed: %s", got)
+	}
+}
@@ -0,0 +1,31 @@
+// Package config verwaltet die Konfiguration des MidiDaemon.
+// Diese Datei enthält die Einstellungen für simulierte Tastatur- und Mauseingaben.
+
+package config
+
+import "fmt"
+
+// InputConfig enthält die Einstellungen für simulierte Eingaben
+type InputConfig struct {
+	// Desktopgröße in Pixeln über alle Monitore; wird für absolute Mausbewegungen über uinput
+	// benötigt, da Wayland-Compositoren die Bildschirmgröße nicht preisgeben (X11 ermittelt sie selbst)
+	Desktop DesktopSize `json:"desktop"`
+}
+
+// DesktopSize ist die Größe des Desktops in Pixeln (0 = unbekannt)
+type DesktopSize struct {
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+// validateInput überprüft die Eingabe-Einstellungen auf Gültigkeit
+func validateInput(input *InputConfig) error {
+	desktop := input.Desktop
+	if desktop.Width < 0 || desktop.Height < 0 {
+		return fmt.Errorf("desktop darf nicht negativ sein, got: %dx%d", desktop.Width, desktop.Height)
+	}
+	if (desktop.Width == 0) != (desktop.Height == 0) {
+		return fmt.Errorf("desktop benötigt width und height, got: %dx%d", desktop.Width, desktop.Height)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Package config verwaltet die Konfiguration des MidiDaemon.
+// Diese Datei enthält Makros: mehrere Aktionen, die als Einheit ausgeführt werden.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MacroStop beendet ein Makro, wenn es als Sprungziel angegeben ist
+const MacroStop = "stop"
+
+// Macro ist eine geordnete Folge von Aktionen, die als eine Einheit ausgeführt wird
+type Macro struct {
+	// Schritte in Ausführungsreihenfolge
+	Steps []MacroStep `json:"steps"`
+}
+
+// MacroStep ist ein einzelner Schritt eines Makros
+type MacroStep struct {
+	// Name des Schritts (für Logging und als Sprungziel, Standard: Position ab 1)
+	Name string `json:"name,omitempty"`
+
+	// Auszuführende Aktion
+	Action Action `json:"action"`
+
+	// Wartezeit vor dem Schritt in Millisekunden
+	Delay int `json:"delay,omitempty"`
+
+	// Bei einem Fehler mit dem nächsten Schritt fortfahren statt abzubrechen
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+
+	// Nächster Schritt bei Erfolg bzw. Fehler (Name oder "stop")
+	OnSuccess string `json:"on_success,omitempty"`
+	OnFailure string `json:"on_failure,omitempty"`
+}
+
+// Index gibt die Position eines Schritts anhand seines Namens zurück
+func (m Macro) Index(name string) (int, bool) {
+	for i, step := range m.Steps {
+		if step.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// setMacroDefaults setzt Standardwerte für die Schritte eines Makros
+func setMacroDefaults(macro *Macro) {
+	for i := range macro.Steps {
+		step := &macro.Steps[i]
+		if step.Name == "" {
+			step.Name = strconv.Itoa(i + 1)
+		}
+		if step.Action.Value != nil {
+			setValueDefaults(step.Action.Value)
+		}
+	}
+}
+
+// validateMacro überprüft ein Makro auf Gültigkeit
+func validateMacro(macro *Macro) error {
+	if len(macro.Steps) == 0 {
+		return fmt.Errorf("makro benötigt mindestens einen Schritt")
+	}
+
+	names := make(map[string]bool)
+	for i, step := range macro.Steps {
+		if step.Name == MacroStop {
+			return fmt.Errorf("schritt %d: der Name '%s' ist reserviert", i+1, MacroStop)
+		}
+		if step.Name != "" {
+			if names[step.Name] {
+				return fmt.Errorf("schritt %d: doppelter Name '%s'", i+1, step.Name)
+			}
+			names[step.Name] = true
+		}
+		if step.Delay < 0 {
+			return fmt.Errorf("schritt %d: ungültige Verzögerung: %d", i+1, step.Delay)
+		}
+		if err := validateAction(&macro.Steps[i].Action); err != nil {
+			return fmt.Errorf("schritt %d: ungültige Aktion: %w", i+1, err)
+		}
+	}
+
+	// Sprungziele prüfen
+	for i, step := range macro.Steps {
+		for _, target := range []string{step.OnSuccess, step.OnFailure} {
+			if target != "" && target != MacroStop && !names[target] {
+				return fmt.Errorf("schritt %d: unbekanntes Sprungziel '%s'", i+1, target)
+			}
+		}
+	}
+
+	return nil
+}
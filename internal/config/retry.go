@@ -0,0 +1,139 @@
+// Package config verwaltet die Konfiguration des MidiDaemon.
+// Diese Datei enthält Wiederholungs- und Circuit-Breaker-Einstellungen für fehlschlagende Aktionen.
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetryConfig beschreibt, wie oft und in welchem Abstand eine fehlgeschlagene Aktion wiederholt wird.
+// Nicht gesetzte Felder (0) werden von der nächst allgemeineren Ebene übernommen.
+type RetryConfig struct {
+	// Anzahl der Versuche insgesamt (1 = keine Wiederholung)
+	Attempts int `json:"attempts,omitempty"`
+
+	// Wartezeit vor der ersten Wiederholung in Millisekunden
+	Backoff int `json:"backoff,omitempty"`
+
+	// Maximale Wartezeit zwischen zwei Versuchen in Millisekunden
+	MaxBackoff int `json:"max_backoff,omitempty"`
+
+	// Faktor, um den sich die Wartezeit nach jedem Versuch erhöht
+	Multiplier float64 `json:"multiplier,omitempty"`
+}
+
+// BreakerConfig beschreibt den Circuit-Breaker, der eine dauerhaft fehlschlagende Aktion vorübergehend sperrt
+type BreakerConfig struct {
+	// Aufeinanderfolgende Fehlschläge bis zum Sperren (-1 = deaktiviert)
+	Threshold int `json:"threshold,omitempty"`
+
+	// Sperrzeit in Millisekunden, danach wird ein Testversuch erlaubt
+	Cooldown int `json:"cooldown,omitempty"`
+}
+
+// Enabled prüft ob der Circuit-Breaker aktiv ist
+func (b BreakerConfig) Enabled() bool {
+	return b.Threshold > 0
+}
+
+// Delay gibt die Wartezeit vor der n-ten Wiederholung (ab 1) zurück
+func (r RetryConfig) Delay(retry int) time.Duration {
+	delay := float64(r.Backoff)
+	for i := 1; i < retry; i++ {
+		delay *= r.Multiplier
+		if r.MaxBackoff > 0 && delay >= float64(r.MaxBackoff) {
+			break
+		}
+	}
+	if r.MaxBackoff > 0 && delay > float64(r.MaxBackoff) {
+		delay = float64(r.MaxBackoff)
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// inherit füllt nicht gesetzte Felder mit den Werten einer allgemeineren Ebene
+func (r RetryConfig) inherit(base RetryConfig) RetryConfig {
+	if r.Attempts == 0 {
+		r.Attempts = base.Attempts
+	}
+	if r.Backoff == 0 {
+		r.Backoff = base.Backoff
+	}
+	if r.MaxBackoff == 0 {
+		r.MaxBackoff = base.MaxBackoff
+	}
+	if r.Multiplier == 0 {
+		r.Multiplier = base.Multiplier
+	}
+	return r
+}
+
+// RetryPolicy ermittelt die Wiederholungs-Einstellungen für eine Aktion eines Mappings
+// (Mapping vor Aktionstyp vor allgemeiner Einstellung)
+func (c *Config) RetryPolicy(mapping Mapping, actionType string) RetryConfig {
+	policy := c.General.Retry
+	if byType, ok := c.General.ActionRetry[actionType]; ok {
+		policy = byType.inherit(policy)
+	}
+	if mapping.Retry != nil {
+		policy = mapping.Retry.inherit(policy)
+	}
+	return policy
+}
+
+// setRetryDefaults setzt Standardwerte für Wiederholungen und Circuit-Breaker
+func setRetryDefaults(general *GeneralConfig) {
+	if general.Retry.Attempts == 0 {
+		general.Retry.Attempts = 1 // Keine Wiederholung
+	}
+	if general.Retry.Backoff == 0 {
+		general.Retry.Backoff = 200 // 200ms
+	}
+	if general.Retry.MaxBackoff == 0 {
+		general.Retry.MaxBackoff = 5000 // 5 Sekunden
+	}
+	if general.Retry.Multiplier == 0 {
+		general.Retry.Multiplier = 2
+	}
+	if general.Breaker.Threshold == 0 {
+		general.Breaker.Threshold = 5
+	}
+	if general.Breaker.Cooldown == 0 {
+		general.Breaker.Cooldown = 30000 // 30 Sekunden
+	}
+}
+
+// validateRetry überprüft Wiederholungs-Einstellungen auf Gültigkeit
+func validateRetry(retry RetryConfig) error {
+	if retry.Attempts < 0 || retry.Attempts > 10 {
+		return fmt.Errorf("ungültige Anzahl Versuche: %d (muss zwischen 1 und 10 liegen)", retry.Attempts)
+	}
+	if retry.Backoff < 0 || retry.MaxBackoff < 0 {
+		return fmt.Errorf("wartezeiten dürfen nicht negativ sein")
+	}
+	if retry.Multiplier != 0 && retry.Multiplier < 1 {
+		return fmt.Errorf("ungültiger Multiplikator: %v (muss >= 1 sein)", retry.Multiplier)
+	}
+	return nil
+}
+
+// validateRetryConfig überprüft alle Wiederholungs- und Circuit-Breaker-Einstellungen
+func validateRetryConfig(general *GeneralConfig) error {
+	if err := validateRetry(general.Retry); err != nil {
+		return fmt.Errorf("retry: %w", err)
+	}
+	for actionType, retry := range general.ActionRetry {
+		if err := validateRetry(retry); err != nil {
+			return fmt.Errorf("action_retry.%s: %w", actionType, err)
+		}
+	}
+	if general.Breaker.Threshold < -1 {
+		return fmt.Errorf("breaker: ungültiger Schwellwert: %d (-1 deaktiviert)", general.Breaker.Threshold)
+	}
+	if general.Breaker.Cooldown < 0 {
+		return fmt.Errorf("breaker: sperrzeit darf nicht negativ sein")
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+// Package config verwaltet die Konfiguration für MidiDaemon.
+// Diese Datei enthält die Templates für Aktionsparameter (z.B. "{{.Value}}").
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateData enthält die Variablen, die in Parameter-Templates verfügbar sind
+type TemplateData struct {
+	Mapping    string                 // Name des Mappings
+	Type       string                 // Event-Typ
+	Port       string                 // Name des MIDI-Ports
+	Channel    int                    // MIDI-Kanal (0-15)
+	Note       int                    // MIDI-Note
+	Velocity   int                    // Velocity
+	Controller int                    // Controller-Nummer
+	Program    int                    // Program-Nummer
+	Value      int                    // Live-Wert des Events (Controller-Wert, Velocity, Program, Pitch-Bend)
+	Layer      string                 // aktiver Layer
+	Vars       map[string]interface{} // Benutzervariablen
+	Steps      map[string]StepResult  // Ergebnisse der Makro-Schritte nach Name
+	Last       StepResult             // Ergebnis des vorherigen Makro-Schritts
+}
+
+// StepResult ist das Ergebnis eines ausgeführten Makro-Schritts
+type StepResult struct {
+	Output   string // Ausgabe der Aktion (bei Befehlen stdout)
+	Stderr   string // Fehlerausgabe eines Befehls
+	ExitCode int    // Exit-Code eines Befehls
+	State    string // Zustand nach der Aktion
+	Error    string // Fehlermeldung, leer bei Erfolg
+}
+
+// templateFuncs enthält die in Templates verfügbaren Hilfsfunktionen
+var templateFuncs = template.FuncMap{
+	// scale skaliert einen 7-Bit-Wert (0-127) gerundet in den Bereich min-max
+	"scale": func(value int, min, max float64) int {
+		ratio := math.Max(0, math.Min(1, float64(value)/127))
+		return int(math.Round(min + ratio*(max-min)))
+	},
+}
+
+// isTemplate prüft ob ein String Template-Syntax enthält
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// parseTemplate parst einen Template-String
+func parseTemplate(s string) (*template.Template, error) {
+	return template.New("parameter").Funcs(templateFuncs).Option("missingkey=error").Parse(s)
+}
+
+// renderString füllt einen einzelnen Template-String aus.
+// Besteht der String nur aus einem Template und ergibt eine Zahl, wird diese als Zahl zurückgegeben.
+func renderString(s string, data TemplateData) (interface{}, error) {
+	tmpl, err := parseTemplate(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	result := buf.String()
+
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "{{") && strings.HasSuffix(trimmed, "}}") && strings.Count(trimmed, "{{") == 1 {
+		if i, err := strconv.Atoi(result); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(result, 64); err == nil {
+			return f, nil
+		}
+	}
+	return result, nil
+}
+
+// NewStepResults legt leere Ergebnisse für die angegebenen Makro-Schritte an, damit Templates
+// auch auf noch nicht ausgeführte (oder übersprungene) Schritte zugreifen können
+func NewStepResults(steps ...string) map[string]StepResult {
+	results := make(map[string]StepResult, len(steps))
+	for _, name := range steps {
+		results[name] = StepResult{}
+	}
+	return results
+}
+
+// renderValue füllt Templates in einem beliebigen Parameterwert aus (auch in Listen und Maps)
+func renderValue(value interface{}, data TemplateData) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !isTemplate(v) {
+			return v, nil
+		}
+		return renderString(v, data)
+
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered, err := renderValue(item, data)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			result[i] = rendered
+		}
+		return result, nil
+
+	case []string:
+		result := make([]string, len(v))
+		for i, item := range v {
+			rendered, err := renderValue(item, data)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			result[i] = fmt.Sprint(rendered)
+		}
+		return result, nil
+
+	case map[string]interface{}:
+		return RenderParameters(v, data)
+
+	default:
+		return value, nil
+	}
+}
+
+// RenderParameters gibt eine Kopie der Parameter zurück, in der alle Templates ausgefüllt sind
+func RenderParameters(parameters map[string]interface{}, data TemplateData) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(parameters))
+	for key, value := range parameters {
+		rendered, err := renderValue(value, data)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s': %w", key, err)
+		}
+		result[key] = rendered
+	}
+	return result, nil
+}
+
+// Render gibt eine Kopie der Aktion zurück, in der alle Parameter-Templates ausgefüllt sind
+func (a Action) Render(data TemplateData) (Action, error) {
+	parameters, err := RenderParameters(a.Parameters, data)
+	if err != nil {
+		return a, err
+	}
+	a.Parameters = parameters
+	return a, nil
+}
+
+// HasTemplates prüft ob ein Parameter der Aktion erst bei der Ausführung ausgefüllt wird
+func (a Action) HasTemplates() bool {
+	for _, value := range a.Parameters {
+		if containsTemplate(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsTemplate prüft einen Parameterwert (auch in Listen und Maps) auf Template-Syntax
+func containsTemplate(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return isTemplate(v)
+	case []interface{}:
+		for _, item := range v {
+			if containsTemplate(item) {
+				return true
+			}
+		}
+	case []string:
+		for _, item := range v {
+			if isTemplate(item) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if containsTemplate(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkTemplates parst alle Templates einer Aktion und führt sie probeweise aus,
+// damit Tippfehler (z.B. "{{.Vlaue}}") bereits beim Laden auffallen. steps sind die
+// Namen der Makro-Schritte, deren Ergebnisse über .Steps erreichbar sind.
+func checkTemplates(action *Action, variables map[string]interface{}, steps ...string) error {
+	sample := TemplateData{Vars: variables, Steps: NewStepResults(steps...)}
+	if sample.Vars == nil {
+		sample.Vars = map[string]interface{}{}
+	}
+	_, err := RenderParameters(action.Parameters, sample)
+	return err
+}
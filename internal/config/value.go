@@ -0,0 +1,105 @@
+// Package config verwaltet die Konfiguration für MidiDaemon.
+// Diese Datei enthält die Skalierung von MIDI-Werten auf Aktionsparameter.
+
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// ValueMapping überträgt den Wert eines MIDI-Events skaliert in einen Aktionsparameter
+type ValueMapping struct {
+	// Name des Parameters, der befüllt wird (z.B. "volume")
+	Parameter string `json:"parameter"`
+
+	// Zielbereich (Standard: 0-100)
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+
+	// Auflösung des Eingangswerts in Bit: 7 (0-127) oder 14 (0-16383)
+	Bits int `json:"bits,omitempty"`
+
+	// Rundung: "nearest" (Standard), "floor", "ceil" oder "none"
+	Round string `json:"round,omitempty"`
+
+	// Wertebereich umkehren (Fader unten = Max)
+	Invert bool `json:"invert,omitempty"`
+}
+
+// Scale skaliert einen rohen MIDI-Wert in den Zielbereich
+func (v *ValueMapping) Scale(raw int) float64 {
+	bits := v.Bits
+	if bits == 0 {
+		bits = 7
+	}
+	inputMax := float64(int(1)<<uint(bits) - 1)
+
+	ratio := math.Max(0, math.Min(1, float64(raw)/inputMax))
+	if v.Invert {
+		ratio = 1 - ratio
+	}
+
+	result := v.Min + ratio*(v.Max-v.Min)
+	switch v.Round {
+	case "floor":
+		return math.Floor(result)
+	case "ceil":
+		return math.Ceil(result)
+	case "none":
+		return result
+	default:
+		return math.Round(result)
+	}
+}
+
+// WithValue gibt eine Kopie der Aktion zurück, in der der skalierte Wert als Parameter gesetzt ist
+func (a Action) WithValue(raw int) Action {
+	if a.Value == nil {
+		return a
+	}
+
+	parameters := make(map[string]interface{}, len(a.Parameters)+1)
+	for key, value := range a.Parameters {
+		parameters[key] = value
+	}
+
+	scaled := a.Value.Scale(raw)
+	if a.Value.Round == "none" {
+		parameters[a.Value.Parameter] = scaled
+	} else {
+		parameters[a.Value.Parameter] = int(scaled)
+	}
+
+	a.Parameters = parameters
+	return a
+}
+
+// validateValueMapping überprüft eine Wert-Zuordnung auf Gültigkeit
+func validateValueMapping(value *ValueMapping) error {
+	if value.Parameter == "" {
+		return fmt.Errorf("value benötigt 'parameter'")
+	}
+	if value.Bits != 0 && value.Bits != 7 && value.Bits != 14 {
+		return fmt.Errorf("ungültige Auflösung: %d Bit (erwartet: 7 oder 14)", value.Bits)
+	}
+	switch value.Round {
+	case "", "nearest", "floor", "ceil", "none":
+	default:
+		return fmt.Errorf("ungültige Rundung: %s (erwartet: nearest, floor, ceil, none)", value.Round)
+	}
+	return nil
+}
+
+// setValueDefaults setzt Standardwerte für eine Wert-Zuordnung
+func setValueDefaults(value *ValueMapping) {
+	if value.Min == 0 && value.Max == 0 {
+		value.Max = 100
+	}
+	if value.Bits == 0 {
+		value.Bits = 7
+	}
+	if value.Round == "" {
+		value.Round = "nearest"
+	}
+}
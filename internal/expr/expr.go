@@ -0,0 +1,172 @@
+// Package expr implementiert eine kleine, abgeschottete Ausdruckssprache für Mapping-Bedingungen.
+// Ausdrücke werden beim Laden der Konfiguration geparst und typgeprüft und können zur Laufzeit
+// nur auf die Bezeichner und Funktionen zugreifen, die hier bzw. im Schema definiert sind.
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type beschreibt den statischen Typ eines Ausdrucks
+type Type int
+
+const (
+	// TypeAny ist ein erst zur Laufzeit bekannter Typ (z.B. Benutzervariablen)
+	TypeAny Type = iota
+	TypeBool
+	TypeNumber
+	TypeString
+)
+
+// String gibt den Namen eines Typs zurück
+func (t Type) String() string {
+	switch t {
+	case TypeBool:
+		return "bool"
+	case TypeNumber:
+		return "number"
+	case TypeString:
+		return "string"
+	default:
+		return "any"
+	}
+}
+
+// Schema definiert die in einem Ausdruck erlaubten Bezeichner und deren Typen
+type Schema map[string]Type
+
+// Env liefert die Werte der Bezeichner zur Laufzeit
+type Env interface {
+	Lookup(name string) (interface{}, error)
+}
+
+// MapEnv ist eine einfache Env-Implementierung auf Basis einer Map
+type MapEnv map[string]interface{}
+
+// Lookup gibt den Wert eines Bezeichners zurück
+func (e MapEnv) Lookup(name string) (interface{}, error) {
+	value, ok := e[name]
+	if !ok {
+		return nil, fmt.Errorf("bezeichner '%s' ist nicht gesetzt", name)
+	}
+	return value, nil
+}
+
+// Error beschreibt einen Fehler in einem Ausdruck mit Position
+type Error struct {
+	Pos int
+	Msg string
+}
+
+// Error gibt die Fehlermeldung zurück
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos+1, e.Msg)
+}
+
+// errorf erstellt einen Ausdrucksfehler an einer Position
+func errorf(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Program ist ein geparster und typgeprüfter Ausdruck
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile parst und typprüft einen Ausdruck. Das Ergebnis muss ein Wahrheitswert sein.
+func Compile(source string, schema Schema) (*Program, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, schema: schema}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorf(tok.pos, "unerwartetes '%s'", tok.text)
+	}
+
+	if t := root.typ(); t != TypeBool && t != TypeAny {
+		return nil, errorf(0, "ausdruck muss bool ergeben, ergibt aber %s", t)
+	}
+
+	return &Program{source: source, root: root}, nil
+}
+
+// Eval wertet den Ausdruck aus
+func (p *Program) Eval(env Env) (bool, error) {
+	value, err := p.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return truthy(value), nil
+}
+
+// String gibt den Quelltext des Ausdrucks zurück
+func (p *Program) String() string {
+	return p.source
+}
+
+// Identifiers gibt alle im Ausdruck verwendeten Bezeichner zurück
+func (p *Program) Identifiers() []string {
+	seen := make(map[string]bool)
+	var names []string
+	walk(p.root, func(n node) {
+		if id, ok := n.(*identNode); ok && !seen[id.name] {
+			seen[id.name] = true
+			names = append(names, id.name)
+		}
+	})
+	return names
+}
+
+// normalize wandelt Laufzeitwerte in die intern verwendeten Typen um
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// truthy bestimmt den Wahrheitswert eines Laufzeitwerts
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != "" && !strings.EqualFold(v, "false")
+	default:
+		return true
+	}
+}
+
+// typeOf gibt den Typ eines Laufzeitwerts zurück
+func typeOf(value interface{}) Type {
+	switch value.(type) {
+	case bool:
+		return TypeBool
+	case float64:
+		return TypeNumber
+	case string:
+		return TypeString
+	default:
+		return TypeAny
+	}
+}
@@ -0,0 +1,71 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+var testSchema = Schema{
+	"value":     TypeNumber,
+	"time":      TypeString,
+	"vars.mode": TypeAny,
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := []string{
+		"velo > 10",
+		"value > 'laut'",
+		"time + 1 > 2",
+		"value",
+		"between(value, 1)",
+		"(value > 1",
+	}
+
+	for _, source := range cases {
+		_, err := Compile(source, testSchema)
+		var exprErr *Error
+		if !errors.As(err, &exprErr) {
+			t.Fatalf("%q: Ausdrucksfehler erwartet, erhalten: %v", source, err)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	cases := []struct {
+		source string
+		env    MapEnv
+		want   bool
+	}{
+		{"value > 64 and value <= 127", MapEnv{"value": 100}, true},
+		{"not (value % 2 == 0)", MapEnv{"value": 3}, true},
+		{"between(time, '22:00', '06:00')", MapEnv{"time": "23:30"}, true},
+		{"between(time, '22:00', '06:00')", MapEnv{"time": "12:00"}, false},
+		{"vars.mode", MapEnv{"vars.mode": true}, true},
+		{"vars.mode == 'gaming'", MapEnv{"vars.mode": "gaming"}, true},
+		{"defined(vars.mode)", MapEnv{"vars.mode": nil}, false},
+	}
+
+	for _, c := range cases {
+		program, err := Compile(c.source, testSchema)
+		if err != nil {
+			t.Fatalf("%q: Kompilieren fehlgeschlagen: %v", c.source, err)
+		}
+		got, err := program.Eval(c.env)
+		if err != nil {
+			t.Fatalf("%q: Auswertung fehlgeschlagen: %v", c.source, err)
+		}
+		if got != c.want {
+			t.Fatalf("%q: erwartet %v, erhalten %v", c.source, c.want, got)
+		}
+	}
+}
+
+func TestEvalRuntimeError(t *testing.T) {
+	program, err := Compile("100 / value > 1", testSchema)
+	if err != nil {
+		t.Fatalf("Kompilieren fehlgeschlagen: %v", err)
+	}
+	if _, err := program.Eval(MapEnv{"value": 0}); err == nil {
+		t.Fatalf("Fehler bei Division durch 0 erwartet")
+	}
+}
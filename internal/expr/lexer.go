@@ -0,0 +1,127 @@
+// Package expr implementiert eine kleine, abgeschottete Ausdruckssprache für Mapping-Bedingungen.
+// Diese Datei enthält den Lexer.
+
+package expr
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenKind beschreibt die Art eines Tokens
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+// token ist ein einzelnes lexikalisches Element
+type token struct {
+	kind   tokenKind
+	text   string
+	number float64
+	pos    int
+}
+
+// operators enthält alle Operatoren, längere zuerst
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "%"}
+
+// keywordOperators sind Operatoren, die als Wort geschrieben werden können
+var keywordOperators = map[string]string{
+	"and": "&&",
+	"or":  "||",
+	"not": "!",
+}
+
+// lex zerlegt einen Ausdruck in Tokens
+func lex(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+
+		case r == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, errorf(start, "nicht abgeschlossener String")
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			number, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, errorf(start, "ungültige Zahl '%s'", text)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, number: number, pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			if op, ok := keywordOperators[text]; ok {
+				tokens = append(tokens, token{kind: tokOp, text: op, pos: start})
+			} else {
+				tokens = append(tokens, token{kind: tokIdent, text: text, pos: start})
+			}
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, errorf(i, "unerwartetes Zeichen '%c'", r)
+			}
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, text: "Ende", pos: len(runes)})
+	return tokens, nil
+}
@@ -0,0 +1,381 @@
+// Package expr implementiert eine kleine, abgeschottete Ausdruckssprache für Mapping-Bedingungen.
+// Diese Datei enthält die Knoten des Syntaxbaums, ihre Auswertung und die eingebauten Funktionen.
+
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// node ist ein Knoten im Syntaxbaum
+type node interface {
+	typ() Type
+	eval(env Env) (interface{}, error)
+}
+
+// literalNode ist ein konstanter Wert
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) typ() Type                     { return typeOf(n.value) }
+func (n *literalNode) eval(Env) (interface{}, error) { return n.value, nil }
+
+// identNode ist ein Bezeichner, dessen Wert aus der Env stammt
+type identNode struct {
+	name string
+	t    Type
+	pos  int
+}
+
+func (n *identNode) typ() Type { return n.t }
+
+func (n *identNode) eval(env Env) (interface{}, error) {
+	value, err := env.Lookup(n.name)
+	if err != nil {
+		return nil, errorf(n.pos, "%v", err)
+	}
+	value = normalize(value)
+	if n.t != TypeAny && value != nil && typeOf(value) != n.t {
+		return nil, errorf(n.pos, "'%s' sollte %s sein, ist aber %s", n.name, n.t, typeOf(value))
+	}
+	return value, nil
+}
+
+// notNode negiert einen Wahrheitswert
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) typ() Type { return TypeBool }
+
+func (n *notNode) eval(env Env) (interface{}, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(value), nil
+}
+
+// logicNode verknüpft zwei Wahrheitswerte mit Kurzschlussauswertung
+type logicNode struct {
+	op          string
+	left, right node
+}
+
+// newLogicNode erstellt einen typgeprüften Logik-Knoten
+func newLogicNode(tok token, left, right node) (node, error) {
+	for _, operand := range []node{left, right} {
+		if t := operand.typ(); t != TypeBool && t != TypeAny {
+			return nil, errorf(tok.pos, "'%s' erwartet bool, nicht %s", tok.text, t)
+		}
+	}
+	return &logicNode{op: tok.text, left: left, right: right}, nil
+}
+
+func (n *logicNode) typ() Type { return TypeBool }
+
+func (n *logicNode) eval(env Env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" && !truthy(left) {
+		return false, nil
+	}
+	if n.op == "||" && truthy(left) {
+		return true, nil
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(right), nil
+}
+
+// compareNode vergleicht zwei Werte
+type compareNode struct {
+	op          string
+	left, right node
+	pos         int
+}
+
+// newCompareNode erstellt einen typgeprüften Vergleichs-Knoten
+func newCompareNode(tok token, left, right node) (node, error) {
+	lt, rt := left.typ(), right.typ()
+	if !compatible(lt, rt) {
+		return nil, errorf(tok.pos, "'%s' kann %s nicht mit %s vergleichen", tok.text, lt, rt)
+	}
+	if tok.text != "==" && tok.text != "!=" && (lt == TypeBool || rt == TypeBool) {
+		return nil, errorf(tok.pos, "'%s' ist für bool nicht definiert", tok.text)
+	}
+	return &compareNode{op: tok.text, left: left, right: right, pos: tok.pos}, nil
+}
+
+func (n *compareNode) typ() Type { return TypeBool }
+
+func (n *compareNode) eval(env Env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	}
+
+	cmp, err := compareValues(left, right)
+	if err != nil {
+		return nil, errorf(n.pos, "%v", err)
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// compareValues vergleicht zwei Zahlen oder zwei Strings
+func compareValues(left, right interface{}) (int, error) {
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	}
+	return 0, fmt.Errorf("kann %v (%s) nicht mit %v (%s) vergleichen", left, typeOf(left), right, typeOf(right))
+}
+
+// arithNode berechnet eine arithmetische Operation bzw. verkettet Strings
+type arithNode struct {
+	op          string
+	left, right node
+	t           Type
+	pos         int
+}
+
+// newArithNode erstellt einen typgeprüften Arithmetik-Knoten
+func newArithNode(tok token, left, right node) (node, error) {
+	lt, rt := left.typ(), right.typ()
+
+	// "+" verkettet auch Strings
+	if tok.text == "+" && (lt == TypeString || rt == TypeString) {
+		if !compatible(lt, rt) {
+			return nil, errorf(tok.pos, "'+' kann %s nicht mit %s verknüpfen", lt, rt)
+		}
+		return &arithNode{op: tok.text, left: left, right: right, t: TypeString, pos: tok.pos}, nil
+	}
+
+	for _, t := range []Type{lt, rt} {
+		if t != TypeNumber && t != TypeAny {
+			return nil, errorf(tok.pos, "'%s' erwartet number, nicht %s", tok.text, t)
+		}
+	}
+
+	t := TypeNumber
+	if tok.text == "+" && (lt == TypeAny || rt == TypeAny) {
+		t = TypeAny
+	}
+	return &arithNode{op: tok.text, left: left, right: right, t: t, pos: tok.pos}, nil
+}
+
+func (n *arithNode) typ() Type { return n.t }
+
+func (n *arithNode) eval(env Env) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "+" {
+		if l, ok := left.(string); ok {
+			return l + fmt.Sprint(right), nil
+		}
+		if r, ok := right.(string); ok {
+			return fmt.Sprint(left) + r, nil
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, errorf(n.pos, "'%s' erwartet Zahlen, erhalten: %v und %v", n.op, left, right)
+	}
+
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, errorf(n.pos, "division durch 0")
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, errorf(n.pos, "modulo durch 0")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+// callNode ruft eine eingebaute Funktion auf
+type callNode struct {
+	name string
+	fn   function
+	args []node
+	pos  int
+}
+
+func (n *callNode) typ() Type { return n.fn.result }
+
+func (n *callNode) eval(env Env) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if n.fn.args[i] != TypeAny && typeOf(value) != n.fn.args[i] {
+			return nil, errorf(n.pos, "argument %d von %s muss %s sein, ist aber %v", i+1, n.name, n.fn.args[i], value)
+		}
+		args[i] = value
+	}
+
+	result, err := n.fn.call(args)
+	if err != nil {
+		return nil, errorf(n.pos, "%s: %v", n.name, err)
+	}
+	return result, nil
+}
+
+// function beschreibt eine eingebaute Funktion
+type function struct {
+	args      []Type
+	result    Type
+	sameTypes bool // alle Argumente müssen denselben Typ haben
+	call      func(args []interface{}) (interface{}, error)
+}
+
+// functions enthält alle in Ausdrücken erlaubten Funktionen
+var functions = map[string]function{
+	// between(x, von, bis) prüft ob x im Bereich liegt; ist von > bis, wird über die Grenze gezählt (z.B. 22:00-06:00)
+	"between": {
+		args:      []Type{TypeAny, TypeAny, TypeAny},
+		result:    TypeBool,
+		sameTypes: true,
+		call: func(args []interface{}) (interface{}, error) {
+			lo, err := compareValues(args[1], args[2])
+			if err != nil {
+				return nil, err
+			}
+			afterStart, err := compareValues(args[0], args[1])
+			if err != nil {
+				return nil, err
+			}
+			beforeEnd, err := compareValues(args[0], args[2])
+			if err != nil {
+				return nil, err
+			}
+			if lo <= 0 {
+				return afterStart >= 0 && beforeEnd <= 0, nil
+			}
+			return afterStart >= 0 || beforeEnd <= 0, nil
+		},
+	},
+	"defined": {
+		args:   []Type{TypeAny},
+		result: TypeBool,
+		call: func(args []interface{}) (interface{}, error) {
+			return args[0] != nil, nil
+		},
+	},
+	"contains": {
+		args:   []Type{TypeString, TypeString},
+		result: TypeBool,
+		call: func(args []interface{}) (interface{}, error) {
+			return strings.Contains(args[0].(string), args[1].(string)), nil
+		},
+	},
+	"lower": {
+		args:   []Type{TypeString},
+		result: TypeString,
+		call: func(args []interface{}) (interface{}, error) {
+			return strings.ToLower(args[0].(string)), nil
+		},
+	},
+	"abs": {
+		args:   []Type{TypeNumber},
+		result: TypeNumber,
+		call: func(args []interface{}) (interface{}, error) {
+			return math.Abs(args[0].(float64)), nil
+		},
+	},
+	"min": {
+		args:   []Type{TypeNumber, TypeNumber},
+		result: TypeNumber,
+		call: func(args []interface{}) (interface{}, error) {
+			return math.Min(args[0].(float64), args[1].(float64)), nil
+		},
+	},
+	"max": {
+		args:   []Type{TypeNumber, TypeNumber},
+		result: TypeNumber,
+		call: func(args []interface{}) (interface{}, error) {
+			return math.Max(args[0].(float64), args[1].(float64)), nil
+		},
+	},
+}
+
+// walk besucht alle Knoten eines Syntaxbaums
+func walk(n node, visit func(node)) {
+	visit(n)
+	switch v := n.(type) {
+	case *notNode:
+		walk(v.operand, visit)
+	case *logicNode:
+		walk(v.left, visit)
+		walk(v.right, visit)
+	case *compareNode:
+		walk(v.left, visit)
+		walk(v.right, visit)
+	case *arithNode:
+		walk(v.left, visit)
+		walk(v.right, visit)
+	case *callNode:
+		for _, arg := range v.args {
+			walk(arg, visit)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Package expr implementiert eine kleine, abgeschottete Ausdruckssprache für Mapping-Bedingungen.
+// Diese Datei enthält den Parser mit Typprüfung.
+
+package expr
+
+// parser erzeugt aus Tokens einen typgeprüften Syntaxbaum
+type parser struct {
+	tokens []token
+	pos    int
+	schema Schema
+}
+
+// peek gibt das aktuelle Token zurück
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next gibt das aktuelle Token zurück und rückt vor
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// acceptOp rückt vor, falls das aktuelle Token einer der Operatoren ist
+func (p *parser) acceptOp(ops ...string) (token, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp {
+		return tok, false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return tok, true
+		}
+	}
+	return tok, false
+}
+
+// parseExpr parst einen vollständigen Ausdruck
+func (p *parser) parseExpr() (node, error) {
+	return p.parseOr()
+}
+
+// parseOr parst Oder-Verknüpfungen
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.acceptOp("||")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newLogicNode(tok, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseAnd parst Und-Verknüpfungen
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.acceptOp("&&")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newLogicNode(tok, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseNot parst Negationen
+func (p *parser) parseNot() (node, error) {
+	if tok, ok := p.acceptOp("!"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if t := operand.typ(); t != TypeBool && t != TypeAny {
+			return nil, errorf(tok.pos, "'!' erwartet bool, nicht %s", t)
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parst Vergleiche
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	tok, ok := p.acceptOp("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return newCompareNode(tok, left, right)
+}
+
+// parseAdditive parst Addition und Subtraktion
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.acceptOp("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newArithNode(tok, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseMultiplicative parst Multiplikation, Division und Modulo
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.acceptOp("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newArithNode(tok, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseUnary parst ein negatives Vorzeichen
+func (p *parser) parseUnary() (node, error) {
+	if tok, ok := p.acceptOp("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return newArithNode(tok, &literalNode{value: 0.0}, operand)
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parst Literale, Bezeichner, Funktionsaufrufe und Klammern
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokNumber:
+		return &literalNode{value: tok.number}, nil
+
+	case tokString:
+		return &literalNode{value: tok.text}, nil
+
+	case tokLParen:
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, errorf(closing.pos, "')' erwartet, '%s' gefunden", closing.text)
+		}
+		return inner, nil
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		}
+
+		if p.peek().kind == tokLParen {
+			return p.parseCall(tok)
+		}
+
+		t, ok := p.schema[tok.text]
+		if !ok {
+			return nil, errorf(tok.pos, "unbekannter Bezeichner '%s'", tok.text)
+		}
+		return &identNode{name: tok.text, t: t, pos: tok.pos}, nil
+
+	default:
+		return nil, errorf(tok.pos, "unerwartetes '%s'", tok.text)
+	}
+}
+
+// parseCall parst einen Funktionsaufruf
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, errorf(name.pos, "unbekannte Funktion '%s'", name.text)
+	}
+	p.next() // "("
+
+	var args []node
+	if p.peek().kind != tokRParen {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if closing := p.next(); closing.kind != tokRParen {
+		return nil, errorf(closing.pos, "')' erwartet, '%s' gefunden", closing.text)
+	}
+
+	if len(args) != len(fn.args) {
+		return nil, errorf(name.pos, "%s erwartet %d Argumente, %d angegeben", name.text, len(fn.args), len(args))
+	}
+	for i, arg := range args {
+		if !compatible(fn.args[i], arg.typ()) {
+			return nil, errorf(name.pos, "argument %d von %s muss %s sein, nicht %s", i+1, name.text, fn.args[i], arg.typ())
+		}
+	}
+	if fn.sameTypes && !sameTypes(args) {
+		return nil, errorf(name.pos, "argumente von %s müssen denselben Typ haben", name.text)
+	}
+
+	return &callNode{name: name.text, fn: fn, args: args, pos: name.pos}, nil
+}
+
+// compatible prüft ob ein Typ an einer Stelle mit erwartetem Typ verwendet werden darf
+func compatible(expected, actual Type) bool {
+	return expected == TypeAny || actual == TypeAny || expected == actual
+}
+
+// sameTypes prüft ob alle statisch bekannten Typen übereinstimmen
+func sameTypes(args []node) bool {
+	t := TypeAny
+	for _, arg := range args {
+		if arg.typ() == TypeAny {
+			continue
+		}
+		if t == TypeAny {
+			t = arg.typ()
+		} else if arg.typ() != t {
+			return false
+		}
+	}
+	return true
+}
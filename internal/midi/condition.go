@@ -0,0 +1,171 @@
+// Package midi verwaltet MIDI-Eingaben und leitet sie an die entsprechenden Aktionen weiter.
+// Diese Datei enthält die Auswertung von Mapping-Bedingungen ("when").
+
+package midi
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/actions"
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+// averageWindow ist die Anzahl der Werte für den gleitenden Mittelwert ("avg")
+const averageWindow = 16
+
+// rollingAverages speichert die letzten Event-Werte je Mapping
+type rollingAverages struct {
+	values map[string][]int
+	mutex  sync.Mutex
+}
+
+// newRollingAverages erstellt einen neuen Speicher für gleitende Mittelwerte
+func newRollingAverages() *rollingAverages {
+	return &rollingAverages{
+		values: make(map[string][]int),
+	}
+}
+
+// add gibt den Mittelwert der bisherigen Werte zurück und nimmt anschließend den neuen Wert auf
+func (r *rollingAverages) add(name string, value int) float64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	history := r.values[name]
+	average := float64(value)
+	if len(history) > 0 {
+		sum := 0
+		for _, v := range history {
+			sum += v
+		}
+		average = float64(sum) / float64(len(history))
+	}
+
+	history = append(history, value)
+	if len(history) > averageWindow {
+		history = history[len(history)-averageWindow:]
+	}
+	r.values[name] = history
+
+	return average
+}
+
+// conditionEnv stellt die Bezeichner für die Auswertung einer Bedingung bereit
+type conditionEnv struct {
+	handler *Handler
+	mapping config.Mapping
+	event   MIDIEvent
+	average float64
+	now     time.Time
+}
+
+// Lookup gibt den Wert eines Bezeichners zurück
+func (e *conditionEnv) Lookup(name string) (interface{}, error) {
+	switch name {
+	case "value":
+		return eventValue(e.event), nil
+	case "note":
+		return e.event.Note, nil
+	case "velocity":
+		return e.event.Velocity, nil
+	case "controller":
+		return e.event.Controller, nil
+	case "program":
+		return e.event.Program, nil
+	case "channel":
+		return e.event.Channel, nil
+	case "type":
+		return e.event.Type, nil
+	case "port":
+		return e.event.Port, nil
+	case "mapping":
+		return e.mapping.Name, nil
+	case "layer":
+		return e.handler.layers.active(), nil
+	case "avg":
+		return e.average, nil
+	case "time":
+		return e.now.Format("15:04"), nil
+	case "hour":
+		return e.now.Hour(), nil
+	case "weekday":
+		return strings.ToLower(e.now.Weekday().String()[:3]), nil
+	case "audio.source", "audio.type":
+		source, err := e.handler.actionMgr.CurrentAudioSource(actions.AudioPlayback)
+		if err != nil {
+			return nil, fmt.Errorf("aktuelle Audioquelle nicht verfügbar: %w", err)
+		}
+		if name == "audio.type" {
+			return source.Type, nil
+		}
+		return source.Name, nil
+	case "audio.input", "audio.input.muted":
+		source, err := e.handler.actionMgr.CurrentAudioSource(actions.AudioCapture)
+		if err != nil {
+			return nil, fmt.Errorf("aktuelles Eingabegerät nicht verfügbar: %w", err)
+		}
+		if name == "audio.input.muted" {
+			return source.IsMuted, nil
+		}
+		return source.Name, nil
+	case "audio.ducking", "audio.ducked":
+		state, err := e.handler.actionMgr.CurrentDucking()
+		if err != nil {
+			return nil, fmt.Errorf("ducking nicht verfügbar: %w", err)
+		}
+		if name == "audio.ducked" {
+			return state.Active, nil
+		}
+		return state.Enabled, nil
+	case "audio.volume", "audio.muted":
+		state, err := e.handler.actionMgr.CurrentVolume()
+		if err != nil {
+			return nil, fmt.Errorf("systemlautstärke nicht verfügbar: %w", err)
+		}
+		if name == "audio.muted" {
+			return state.Muted, nil
+		}
+		return state.Volume, nil
+	}
+
+	if strings.HasPrefix(name, "vars.") {
+		value, _ := e.handler.actionMgr.Variables().Get(strings.TrimPrefix(name, "vars."))
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("unbekannter Bezeichner '%s'", name)
+}
+
+// conditionMet wertet die Bedingung eines Mappings aus; Fehler gelten als nicht erfüllt
+func (h *Handler) conditionMet(mapping config.Mapping, event MIDIEvent) bool {
+	condition := mapping.Condition()
+	if condition == nil {
+		return true
+	}
+
+	env := &conditionEnv{
+		handler: h,
+		mapping: mapping,
+		event:   event,
+		average: h.averages.add(mapping.Name, eventValue(event)),
+		now:     time.Now(),
+	}
+
+	met, err := condition.Eval(env)
+	if err != nil {
+		h.logger.Warn("Fehler beim Auswerten der Bedingung",
+			"mapping", mapping.Name,
+			"when", condition.String(),
+			"error", err,
+		)
+		return false
+	}
+
+	if !met {
+		h.logger.Debug("Bedingung nicht erfüllt", "mapping", mapping.Name, "when", condition.String())
+	}
+	return met
+}
@@ -559,7 +559,7 @@ func (dm *DiscoveryManager) getKeyboardMappings(controller *ControllerInfo) []Su
 				Type: "volume",
 				Parameters: map[string]interface{}{
 					"direction": "set",
-					"volume":    "{{value}}",
+					"volume":    "{{scale .Value 0 100}}",
 				},
 				Description: "Lautstärke auf Controller-Wert setzen",
 			},
@@ -642,7 +642,7 @@ func (dm *DiscoveryManager) getKnobMappings(controller *ControllerInfo) []Sugges
 				Type: "volume",
 				Parameters: map[string]interface{}{
 					"direction": "set",
-					"volume":    "{{value}}",
+					"volume":    "{{scale .Value 0 100}}",
 				},
 				Description: "Lautstärke auf Controller-Wert setzen",
 			},
@@ -666,7 +666,7 @@ func (dm *DiscoveryManager) getSliderMappings(controller *ControllerInfo) []Sugg
 				Type: "volume",
 				Parameters: map[string]interface{}{
 					"direction": "set",
-					"volume":    "{{value}}",
+					"volume":    "{{scale .Value 0 100}}",
 				},
 				Description: "Lautstärke auf Controller-Wert setzen",
 			},
@@ -690,7 +690,7 @@ func (dm *DiscoveryManager) getMixerMappings(controller *ControllerInfo) []Sugge
 				Type: "volume",
 				Parameters: map[string]interface{}{
 					"direction": "set",
-					"volume":    "{{value}}",
+					"volume":    "{{scale .Value 0 100}}",
 				},
 				Description: "System-Lautstärke auf Fader-Wert setzen",
 			},
@@ -732,7 +732,7 @@ func (dm *DiscoveryManager) getDJMappings(controller *ControllerInfo) []Suggeste
 				Type: "volume",
 				Parameters: map[string]interface{}{
 					"direction": "set",
-					"volume":    "{{value}}",
+					"volume":    "{{scale .Value 0 100}}",
 				},
 				Description: "System-Lautstärke auf Fader-Wert setzen",
 			},
@@ -4,6 +4,7 @@ package midi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -13,28 +14,39 @@ import (
 	"github.com/Xcruser/MidiDaemon/pkg/utils"
 )
 
+// volumeWatchRetry ist die Wartezeit, bevor die Lautstärke-Überwachung erneut verbunden wird
+const volumeWatchRetry = 10 * time.Second
+
 // Handler verwaltet MIDI-Eingaben und leitet sie an Aktionen weiter
 type Handler struct {
-	config     *config.Config
-	logger     utils.Logger
-	actionMgr  *actions.Manager
-	port       MIDIPort
-	eventChan  chan MIDIEvent
-	done       chan struct{}
-	mutex      sync.RWMutex
-	isRunning  bool
+	config    *config.Config
+	logger    utils.Logger
+	actionMgr *actions.Manager
+	port      MIDIPort
+	portName  string
+	triggers  *triggerTracker
+	layers    *layerState
+	states    *mappingStates
+	averages  *rollingAverages
+	ctx       context.Context // Lebensdauer laufender Aktionen und Makros
+	ducking   sync.WaitGroup  // läuft, bis das Ducking abgesenkte Streams wiederhergestellt hat
+	eventChan chan MIDIEvent
+	done      chan struct{}
+	mutex     sync.RWMutex
+	isRunning bool
 }
 
 // MIDIEvent repräsentiert ein empfangenes MIDI-Event
 type MIDIEvent struct {
-	Type      string // "note_on", "note_off", "control_change", "program_change"
-	Channel   int    // MIDI-Kanal (0-15)
-	Note      int    // MIDI-Note (0-127)
-	Controller int   // Controller-Nummer (0-127)
-	Program   int    // Program-Nummer (0-127)
-	Velocity  int    // Velocity (0-127)
-	Value     int    // Controller-Wert (0-127)
-	Timestamp time.Time
+	Type       string // "note_on", "note_off", "control_change", "program_change", "pitch_bend"
+	Port       string // Name des Ports, über den das Event empfangen wurde
+	Channel    int    // MIDI-Kanal (0-15)
+	Note       int    // MIDI-Note (0-127)
+	Controller int    // Controller-Nummer (0-127)
+	Program    int    // Program-Nummer (0-127)
+	Velocity   int    // Velocity (0-127)
+	Value      int    // Controller-Wert (0-127) bzw. Pitch-Bend-Wert (0-16383)
+	Timestamp  time.Time
 }
 
 // MIDIPort definiert die Schnittstelle für MIDI-Ports
@@ -52,6 +64,10 @@ func NewHandler(cfg *config.Config, logger utils.Logger) (*Handler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("fehler beim Erstellen des Action-Managers: %w", err)
 	}
+	if err := actionMgr.ValidateMappings(cfg.Mappings); err != nil {
+		actionMgr.Close()
+		return nil, err
+	}
 
 	// Plattformspezifischen MIDI-Port erstellen
 	port, err := newMIDIPort()
@@ -64,6 +80,11 @@ func NewHandler(cfg *config.Config, logger utils.Logger) (*Handler, error) {
 		logger:    logger,
 		actionMgr: actionMgr,
 		port:      port,
+		triggers:  newTriggerTracker(),
+		layers:    newLayerState(cfg.Layers),
+		states:    newMappingStates(),
+		averages:  newRollingAverages(),
+		ctx:       context.Background(),
 		eventChan: make(chan MIDIEvent, 100),
 		done:      make(chan struct{}),
 	}
@@ -79,6 +100,7 @@ func (h *Handler) Start(ctx context.Context) error {
 		return fmt.Errorf("handler läuft bereits")
 	}
 	h.isRunning = true
+	h.ctx = ctx
 	h.mutex.Unlock()
 
 	h.logger.Info("MIDI-Handler wird gestartet")
@@ -102,7 +124,11 @@ func (h *Handler) Start(ctx context.Context) error {
 		return fmt.Errorf("fehler beim Öffnen des MIDI-Ports '%s': %w", portName, err)
 	}
 
+	h.portName = portName
 	h.logger.Info("MIDI-Port geöffnet", "port", portName)
+	if layer := h.layers.active(); layer != "" {
+		h.logger.Info("Aktiver Layer", "layer", layer)
+	}
 
 	// Event-Stream starten
 	eventStream, err := h.port.ReadEvents()
@@ -113,13 +139,51 @@ func (h *Handler) Start(ctx context.Context) error {
 	// Event-Verarbeitung in separater Goroutine
 	go h.processEvents(ctx, eventStream)
 
+	// Änderungen der Systemlautstärke beobachten (soweit die Plattform das unterstützt)
+	go h.watchVolume(ctx)
+
+	// Andere Anwendungen bei aktivem Mikrofon absenken (soweit das Audio-Backend das unterstützt)
+	h.ducking.Add(1)
+	go func() {
+		defer h.ducking.Done()
+		if err := h.actionMgr.RunDucking(ctx); err != nil {
+			h.logger.Debug("Ducking nicht verfügbar", "error", err)
+		}
+	}()
+
 	// Auf Context-Cancellation warten
 	<-ctx.Done()
 	h.logger.Info("MIDI-Handler wird beendet")
 
+	// Abgesenkte Lautstärken anderer Anwendungen vor dem Beenden wiederherstellen
+	h.ducking.Wait()
+
 	return nil
 }
 
+// watchVolume beobachtet die Systemlautstärke und verbindet sich nach Abbrüchen erneut
+func (h *Handler) watchVolume(ctx context.Context) {
+	for {
+		err := h.actionMgr.WatchVolume(ctx, func(volume int, muted bool) {
+			h.logger.Debug("Systemlautstärke geändert", "volume", volume, "muted", muted)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, actions.ErrAudioBackendChanged) {
+			// Sofort mit dem neuen Backend weiter beobachten
+			continue
+		}
+		h.logger.Debug("Lautstärke-Überwachung nicht verfügbar", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(volumeWatchRetry):
+		}
+	}
+}
+
 // Close beendet den MIDI-Handler
 func (h *Handler) Close() error {
 	h.mutex.Lock()
@@ -138,6 +202,11 @@ func (h *Handler) Close() error {
 		}
 	}
 
+	// Verlauf schließen
+	if err := h.actionMgr.Close(); err != nil {
+		h.logger.Error("Fehler beim Schließen des Action-Managers", "error", err)
+	}
+
 	// Channels schließen
 	close(h.done)
 	close(h.eventChan)
@@ -155,8 +224,15 @@ func (h *Handler) processEvents(ctx context.Context, eventStream <-chan MIDIEven
 				h.logger.Info("MIDI-Event-Stream wurde geschlossen")
 				return
 			}
+			if event.Port == "" {
+				event.Port = h.portName
+			}
 			h.handleEvent(event)
 
+		case <-h.triggers.wake:
+			// Abgelaufene Akkord-Fenster und Sequenzen im selben Ablauf wie Live-Events nachholen
+			h.replayEvents(h.triggers.due(time.Now()))
+
 		case <-ctx.Done():
 			h.logger.Info("Event-Verarbeitung wird beendet")
 			return
@@ -170,13 +246,24 @@ func (h *Handler) processEvents(ctx context.Context, eventStream <-chan MIDIEven
 
 // handleEvent verarbeitet ein einzelnes MIDI-Event
 func (h *Handler) handleEvent(event MIDIEvent) {
+	cfg := h.currentConfig()
+
 	// Kanal-Filterung
-	if h.config.MIDI.Channel != -1 && event.Channel != h.config.MIDI.Channel {
+	if cfg.MIDI.Channel != -1 && event.Channel != cfg.MIDI.Channel {
 		return
 	}
 
+	// Note-On mit Velocity 0 ist laut MIDI-Standard ein Note-Off
+	if event.Type == "note_on" && event.Velocity == 0 {
+		event.Type = "note_off"
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
 	h.logger.Debug("MIDI-Event empfangen",
 		"type", event.Type,
+		"port", event.Port,
 		"channel", event.Channel,
 		"note", event.Note,
 		"controller", event.Controller,
@@ -184,36 +271,235 @@ func (h *Handler) handleEvent(event MIDIEvent) {
 		"value", event.Value,
 	)
 
-	// Passende Mappings finden und ausführen
-	for _, mapping := range h.config.Mappings {
-		if !mapping.Enabled {
+	// Shift-Pads und Bankwechsel auswerten
+	if changed, consumed := h.layers.handle(event); consumed {
+		if changed {
+			status := h.layers.status()
+			h.logger.Info("Layer gewechselt", "layer", status.Active, "bank", status.Bank, "shift", status.Shift)
+		}
+		// Momentary-Mappings unabhängig vom aktiven Layer loslassen
+		h.releaseMomentary(event, cfg.Mappings)
+		return
+	}
+
+	// Akkord- und Sequenz-Trigger auswerten
+	mappings := h.activeMappings(cfg)
+	result := h.triggers.process(event, mappings)
+
+	// Zurückgehaltene Events (abgebrochene Sequenz, losgelassene Akkord-Note) vor diesem Event nachholen
+	h.replayEvents(result.replay)
+	if result.queued {
+		return
+	}
+
+	// Momentary-Mappings unabhängig vom aktiven Layer loslassen
+	h.releaseMomentary(event, cfg.Mappings)
+
+	for _, mapping := range result.fired {
+		if !h.conditionMet(mapping, event) {
 			continue
 		}
+		h.logger.Info("Trigger ausgelöst", "name", mapping.Name, "trigger", mapping.Trigger.Type)
+		h.runMapping(mapping, event)
+	}
+
+	// Teil einer Sequenz oder eines Akkords: keine Einzel-Mappings auslösen
+	if result.consumed {
+		return
+	}
+
+	// Mögliche Akkord-Note: Einzel-Mappings bis zum Ablauf des Toleranzfensters bzw. bis zum Loslassen zurückhalten
+	if result.hold > 0 {
+		h.triggers.hold(event, result.hold)
+		return
+	}
+
+	h.dispatchEvent(event, mappings)
+}
 
-		if h.matchesMapping(event, mapping.Event) {
+// replayEvents führt zurückgehaltene Events nachträglich als Einzel-Mappings aus
+func (h *Handler) replayEvents(events []MIDIEvent) {
+	if len(events) == 0 {
+		return
+	}
+	cfg := h.currentConfig()
+	for _, event := range events {
+		h.releaseMomentary(event, cfg.Mappings)
+		h.dispatchEvent(event, h.activeMappings(cfg))
+	}
+}
+
+// currentConfig gibt die aktuell gültige Konfiguration zurück
+func (h *Handler) currentConfig() *config.Config {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.config
+}
+
+// activeMappings gibt alle Mappings des aktiven Layers zurück
+func (h *Handler) activeMappings(cfg *config.Config) []config.Mapping {
+	layer := h.layers.active()
+	mappings := make([]config.Mapping, 0, len(cfg.Mappings))
+	for _, mapping := range cfg.Mappings {
+		if mapping.InLayer(layer, cfg.Layers) {
+			mappings = append(mappings, mapping)
+		}
+	}
+	return mappings
+}
+
+// dispatchEvent führt alle Einzel-Mappings aus, die zu einem MIDI-Event passen
+func (h *Handler) dispatchEvent(event MIDIEvent, mappings []config.Mapping) {
+	for _, mapping := range mappings {
+		if !mapping.Enabled || mapping.Trigger != nil {
+			continue
+		}
+
+		// Loslassen wurde bereits in releaseMomentary behandelt
+		if mapping.Mode == "momentary" && isRelease(event, mapping.Event) {
+			continue
+		}
+
+		if h.matchesMapping(event, mapping.Event) && h.conditionMet(mapping, event) {
 			h.logger.Info("Mapping gefunden", "name", mapping.Name)
-			
-			// Aktion in separater Goroutine ausführen
-			go func(m config.Mapping) {
-				if err := h.actionMgr.Execute(m.Action); err != nil {
-					h.logger.Error("Fehler beim Ausführen der Aktion",
-						"mapping", m.Name,
-						"action", m.Action.Type,
-						"error", err,
-					)
-				}
-			}(mapping)
-
-			// Verzögerung zwischen Aktionen
-			if h.config.General.ActionDelay > 0 {
-				time.Sleep(time.Duration(h.config.General.ActionDelay) * time.Millisecond)
+			h.runMapping(mapping, event)
+		}
+	}
+}
+
+// runMapping führt ein Mapping abhängig von seinem Modus aus
+func (h *Handler) runMapping(mapping config.Mapping, event MIDIEvent) {
+	switch mapping.Mode {
+	case "toggle":
+		if h.states.toggle(mapping.Name) {
+			h.executeMapping(mapping, mapping.Action, event)
+		} else {
+			h.executeMapping(mapping, *mapping.OffAction, event)
+		}
+
+	case "momentary":
+		if !h.states.set(mapping.Name, true) {
+			h.executeMapping(mapping, mapping.Action, event)
+		}
+
+	default:
+		if mapping.Macro != nil {
+			h.executeMacro(mapping, event)
+			return
+		}
+		h.executeMapping(mapping, mapping.Action, event)
+	}
+}
+
+// releaseMomentary führt die Off-Aktion gehaltener Momentary-Mappings beim Loslassen aus
+func (h *Handler) releaseMomentary(event MIDIEvent, mappings []config.Mapping) {
+	for _, mapping := range mappings {
+		if !mapping.Enabled || mapping.Mode != "momentary" || !isRelease(event, mapping.Event) {
+			continue
+		}
+		if h.states.set(mapping.Name, false) {
+			h.logger.Info("Mapping losgelassen", "name", mapping.Name)
+			h.executeMapping(mapping, *mapping.OffAction, event)
+		}
+	}
+}
+
+// isRelease prüft ob ein Event das Loslassen einer Note bzw. eines Controller-Buttons ist
+func isRelease(event MIDIEvent, mappingEvent config.MIDIEvent) bool {
+	switch mappingEvent.Type {
+	case "note_on":
+		return event.Type == "note_off" && event.Note == mappingEvent.Note
+	case "control_change":
+		return event.Type == "control_change" && event.Controller == mappingEvent.Controller && event.Value == 0
+	}
+	return false
+}
+
+// executeMapping führt eine Aktion eines Mappings aus
+func (h *Handler) executeMapping(mapping config.Mapping, action config.Action, event MIDIEvent) {
+	ctx := h.context()
+	data := h.templateData(mapping, event)
+
+	// Aktion in separater Goroutine ausführen
+	go func() {
+		if _, err := h.actionMgr.ExecuteMapping(ctx, mapping, action, data); err != nil {
+			// Gesperrte Aktionen nicht bei jedem Event erneut als Fehler melden
+			if errors.Is(err, actions.ErrCircuitOpen) {
+				h.logger.Debug("Aktion übersprungen", "mapping", mapping.Name, "error", err)
+				return
 			}
+			h.logger.Error("Fehler beim Ausführen der Aktion",
+				"mapping", mapping.Name,
+				"action", action.Type,
+				"error", err,
+			)
 		}
+	}()
+
+	// Verzögerung zwischen Aktionen
+	if delay := h.currentConfig().General.ActionDelay; delay > 0 {
+		time.Sleep(time.Duration(delay) * time.Millisecond)
+	}
+}
+
+// executeMacro startet das Makro eines Mappings
+func (h *Handler) executeMacro(mapping config.Mapping, event MIDIEvent) {
+	ctx := h.context()
+	data := h.templateData(mapping, event)
+
+	go func() {
+		if err := h.actionMgr.ExecuteMacro(ctx, mapping, data); err != nil {
+			h.logger.Error("Fehler beim Ausführen des Makros",
+				"mapping", mapping.Name,
+				"error", err,
+			)
+		}
+	}()
+}
+
+// context gibt den Context zurück, an den laufende Aktionen gebunden sind
+func (h *Handler) context() context.Context {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.ctx
+}
+
+// templateData stellt die Variablen für Parameter-Templates zusammen
+func (h *Handler) templateData(mapping config.Mapping, event MIDIEvent) config.TemplateData {
+	return config.TemplateData{
+		Mapping:    mapping.Name,
+		Type:       event.Type,
+		Port:       event.Port,
+		Channel:    event.Channel,
+		Note:       event.Note,
+		Velocity:   event.Velocity,
+		Controller: event.Controller,
+		Program:    event.Program,
+		Value:      eventValue(event),
+		Layer:      h.layers.active(),
+		Vars:       h.actionMgr.Variables().Snapshot(),
+	}
+}
+
+// eventValue gibt den kontinuierlichen Wert eines Events zurück
+func eventValue(event MIDIEvent) int {
+	switch event.Type {
+	case "note_on", "note_off":
+		return event.Velocity
+	case "program_change":
+		return event.Program
+	default:
+		return event.Value
 	}
 }
 
 // matchesMapping überprüft ob ein MIDI-Event zu einem Mapping passt
 func (h *Handler) matchesMapping(event MIDIEvent, mappingEvent config.MIDIEvent) bool {
+	return matchesEvent(event, mappingEvent)
+}
+
+// matchesEvent überprüft ob ein MIDI-Event zu einer Event-Definition passt
+func matchesEvent(event MIDIEvent, mappingEvent config.MIDIEvent) bool {
 	// Event-Typ überprüfen
 	if event.Type != mappingEvent.Type {
 		return false
@@ -255,9 +541,88 @@ func (h *Handler) GetPortNames() ([]string, error) {
 	return h.port.GetPortNames()
 }
 
+// ActiveLayer gibt den aktuell aktiven Layer zurück
+func (h *Handler) ActiveLayer() string {
+	return h.layers.active()
+}
+
+// LayerStatus gibt den aktuellen Layer-Zustand zurück
+func (h *Handler) LayerStatus() LayerStatus {
+	return h.layers.status()
+}
+
+// SelectLayer wählt eine Bank als aktiven Layer aus
+func (h *Handler) SelectLayer(name string) error {
+	if err := h.layers.selectBank(name); err != nil {
+		return err
+	}
+	h.logger.Info("Layer gewechselt", "layer", h.layers.active(), "bank", name)
+	return nil
+}
+
+// MappingState gibt den Zustand eines Toggle- oder Momentary-Mappings zurück
+func (h *Handler) MappingState(name string) (on bool, exists bool) {
+	return h.states.get(name)
+}
+
+// MappingStates gibt die Zustände aller zustandsbehafteten Mappings zurück
+func (h *Handler) MappingStates() map[string]bool {
+	return h.states.snapshot()
+}
+
+// SetStateListener registriert einen Listener für Zustandsänderungen (z.B. für LED-Feedback)
+func (h *Handler) SetStateListener(listener StateListener) {
+	h.states.setListener(listener)
+}
+
+// Reload übernimmt eine neu geladene Konfiguration, ohne den MIDI-Port neu zu öffnen.
+// Zustände von Toggle- und Momentary-Mappings sowie die gewählte Bank bleiben erhalten.
+// Ist eine Aktion ungültig, bleibt die bisherige Konfiguration aktiv.
+func (h *Handler) Reload(cfg *config.Config) error {
+	if err := h.actionMgr.ValidateMappings(cfg.Mappings); err != nil {
+		return err
+	}
+
+	h.mutex.Lock()
+	h.config = cfg
+	h.mutex.Unlock()
+
+	h.actionMgr.Reload(cfg)
+	h.layers.reconfigure(cfg.Layers)
+	h.states.retain(cfg.Mappings)
+
+	h.logger.Info("Konfiguration neu geladen", "mappings", len(cfg.Mappings), "layer", h.layers.active())
+	return nil
+}
+
+// History gibt die zuletzt ausgeführten Aktionen zurück
+func (h *Handler) History(filter actions.HistoryFilter) []actions.HistoryEntry {
+	return h.actionMgr.History().Entries(filter)
+}
+
+// BreakerStates gibt den Zustand der Circuit-Breaker je Mapping bzw. Makro-Schritt zurück
+func (h *Handler) BreakerStates() map[string]actions.BreakerStatus {
+	return h.actionMgr.BreakerStates()
+}
+
+// VolumeState gibt Lautstärke und Stummschaltung des Systems zurück
+func (h *Handler) VolumeState() (actions.VolumeState, error) {
+	return h.actionMgr.CurrentVolume()
+}
+
+// SetVariable setzt eine deklarierte Benutzervariable
+func (h *Handler) SetVariable(name string, value interface{}) error {
+	return h.actionMgr.Variables().Set(name, value)
+}
+
+// Variables gibt die aktuellen Werte aller Benutzervariablen zurück
+func (h *Handler) Variables() map[string]interface{} {
+	return h.actionMgr.Variables().Snapshot()
+}
+
 // IsRunning gibt zurück ob der Handler läuft
 func (h *Handler) IsRunning() bool {
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
 	return h.isRunning
-} 
\ No newline at end of file
+}
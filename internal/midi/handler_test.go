@@ -0,0 +1,280 @@
+package midi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+	"github.com/Xcruser/MidiDaemon/pkg/utils"
+)
+
+// handlerTestConfig schaltet über Variable-Aktionen, deren Werte sich ohne Audio- oder Eingabe-Backend prüfen lassen
+const handlerTestConfig = `{
+	"general": {"action_delay": 1},
+	"variables": {"light": false, "talk": false, "pad": ""},
+	"layers": {
+		"definitions": [
+			{"name": "a"},
+			{"name": "b"},
+			{"name": "fx", "shift": {"type": "note_on", "note": 40}}
+		],
+		"cycle": {"type": "control_change", "controller": 20}
+	},
+	"mappings": [
+		{
+			"name": "Light", "enabled": true, "layers": ["*"], "mode": "toggle",
+			"event": {"type": "note_on", "note": 36},
+			"action": {"type": "variable", "parameters": {"name": "light", "value": true}},
+			"off_action": {"type": "variable", "parameters": {"name": "light", "value": false}}
+		},
+		{
+			"name": "Talk", "enabled": true, "layers": ["a"], "mode": "momentary",
+			"event": {"type": "note_on", "note": 37},
+			"action": {"type": "variable", "parameters": {"name": "talk", "value": true}},
+			"off_action": {"type": "variable", "parameters": {"name": "talk", "value": false}}
+		},
+		{"name": "PadA", "enabled": true, "layers": ["a"], "event": {"type": "note_on", "note": 38}, "action": {"type": "variable", "parameters": {"name": "pad", "value": "a"}}},
+		{"name": "PadB", "enabled": true, "layers": ["b"], "event": {"type": "note_on", "note": 38}, "action": {"type": "variable", "parameters": {"name": "pad", "value": "b"}}},
+		{"name": "PadFx", "enabled": true, "layers": ["fx"], "event": {"type": "note_on", "note": 38}, "action": {"type": "variable", "parameters": {"name": "pad", "value": "fx"}}}
+	]
+}`
+
+// loadTestConfig schreibt eine Konfiguration in eine temporäre Datei und lädt sie
+func loadTestConfig(t *testing.T, data string) *config.Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return cfg
+}
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	h, err := NewHandler(loadTestConfig(t, handlerTestConfig), utils.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	t.Cleanup(func() { h.actionMgr.Close() })
+	return h
+}
+
+// waitForVariable wartet, bis die asynchron ausgeführte Aktion eine Variable gesetzt hat
+func waitForVariable(t *testing.T, h *Handler, name string, want interface{}) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		got := h.Variables()[name]
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("variable %s: expected %v, got %v", name, want, got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func press(h *Handler, note int) {
+	h.handleEvent(MIDIEvent{Type: "note_on", Note: note, Velocity: 100})
+}
+
+func release(h *Handler, note int) {
+	h.handleEvent(MIDIEvent{Type: "note_off", Note: note})
+}
+
+func TestHandlerToggle(t *testing.T) {
+	h := newTestHandler(t)
+
+	press(h, 36)
+	release(h, 36)
+	waitForVariable(t, h, "light", true)
+	if on, _ := h.MappingState("Light"); !on {
+		t.Fatalf("expected toggle to be on")
+	}
+
+	// Das Loslassen schaltet nicht um, erst der nächste Anschlag
+	press(h, 36)
+	waitForVariable(t, h, "light", false)
+	if on, exists := h.MappingState("Light"); on || !exists {
+		t.Fatalf("expected toggle to be off, got on=%v exists=%v", on, exists)
+	}
+}
+
+func TestHandlerMomentary(t *testing.T) {
+	h := newTestHandler(t)
+
+	press(h, 37)
+	waitForVariable(t, h, "talk", true)
+	if on, _ := h.MappingState("Talk"); !on {
+		t.Fatalf("expected momentary to be held")
+	}
+	release(h, 37)
+	waitForVariable(t, h, "talk", false)
+
+	// Loslassen nach einem Bankwechsel erreicht das Mapping trotzdem
+	press(h, 37)
+	waitForVariable(t, h, "talk", true)
+	h.handleEvent(MIDIEvent{Type: "control_change", Controller: 20, Value: 127})
+	if layer := h.ActiveLayer(); layer != "b" {
+		t.Fatalf("expected bank b, got %s", layer)
+	}
+	release(h, 37)
+	waitForVariable(t, h, "talk", false)
+	if on, _ := h.MappingState("Talk"); on {
+		t.Fatalf("expected momentary to be released")
+	}
+}
+
+func TestHandlerStatePersistsAcrossReload(t *testing.T) {
+	h := newTestHandler(t)
+
+	var changes []string
+	h.SetStateListener(func(mapping string, on bool) {
+		if on {
+			changes = append(changes, "+"+mapping)
+		} else {
+			changes = append(changes, "-"+mapping)
+		}
+	})
+
+	press(h, 36)
+	waitForVariable(t, h, "light", true)
+	h.handleEvent(MIDIEvent{Type: "control_change", Controller: 20, Value: 127})
+
+	// Neuladen behält Toggle-Zustände und die gewählte Bank
+	if err := h.Reload(loadTestConfig(t, handlerTestConfig)); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if on, _ := h.MappingState("Light"); !on {
+		t.Fatalf("toggle state lost on reload")
+	}
+	if layer := h.ActiveLayer(); layer != "b" {
+		t.Fatalf("bank lost on reload, got %s", layer)
+	}
+	press(h, 36)
+	waitForVariable(t, h, "light", false)
+
+	// Ist das Mapping nicht mehr zustandsbehaftet, wird sein Zustand verworfen
+	if err := h.Reload(loadTestConfig(t, `{
+		"variables": {"light": false},
+		"mappings": [{"name": "Light", "enabled": true, "event": {"type": "note_on", "note": 36},
+			"action": {"type": "variable", "parameters": {"name": "light", "value": true}}}]
+	}`)); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, exists := h.MappingState("Light"); exists {
+		t.Fatalf("expected state of stateless mapping to be dropped")
+	}
+	if len(changes) != 2 || changes[0] != "+Light" || changes[1] != "-Light" {
+		t.Fatalf("unexpected state notifications: %v", changes)
+	}
+}
+
+func TestHandlerLayers(t *testing.T) {
+	h := newTestHandler(t)
+
+	press(h, 38)
+	waitForVariable(t, h, "pad", "a")
+
+	// Bankwechsel über den Cycle-Controller
+	h.handleEvent(MIDIEvent{Type: "control_change", Controller: 20, Value: 127})
+	press(h, 38)
+	waitForVariable(t, h, "pad", "b")
+
+	// Shift-Layer gilt nur, solange das Shift-Pad gehalten wird
+	press(h, 40)
+	if status := h.LayerStatus(); status.Active != "fx" || status.Bank != "b" {
+		t.Fatalf("unexpected layer status while shifted: %+v", status)
+	}
+	press(h, 38)
+	waitForVariable(t, h, "pad", "fx")
+	release(h, 40)
+	press(h, 38)
+	waitForVariable(t, h, "pad", "b")
+
+	// Direkte Auswahl und zyklischer Wechsel zurück zur ersten Bank
+	if err := h.SelectLayer("fx"); err == nil {
+		t.Fatalf("expected error when selecting a shift layer as bank")
+	}
+	h.handleEvent(MIDIEvent{Type: "control_change", Controller: 20, Value: 127})
+	if layer := h.ActiveLayer(); layer != "a" {
+		t.Fatalf("expected cycle to wrap around to a, got %s", layer)
+	}
+}
+
+func TestHandlerValidatesActions(t *testing.T) {
+	invalid := `{
+		"mappings": [{"name": "Copy", "enabled": true, "event": {"type": "note_on", "note": 36},
+			"action": {"type": "key_combination", "parameters": {"keys": ["ctrl", "unbekannt"]}}}]
+	}`
+
+	// Unbekannte Tasten fallen schon beim Start auf
+	if _, err := NewHandler(loadTestConfig(t, invalid), utils.NewNullLogger()); err == nil {
+		t.Fatalf("expected invalid key to be rejected on start")
+	}
+
+	// Ein Neuladen mit ungültiger Aktion behält die bisherige Konfiguration
+	h := newTestHandler(t)
+	if err := h.Reload(loadTestConfig(t, invalid)); err == nil {
+		t.Fatalf("expected invalid key to be rejected on reload")
+	}
+	press(h, 36)
+	waitForVariable(t, h, "light", true)
+
+	// Templates werden erst bei der Ausführung geprüft
+	templated := `{
+		"mappings": [{"name": "Type", "enabled": true, "event": {"type": "control_change", "controller": 1},
+			"action": {"type": "key_combination", "parameters": {"keys": "{{.Value}}"}}}]
+	}`
+	if err := h.Reload(loadTestConfig(t, templated)); err != nil {
+		t.Fatalf("Reload with template: %v", err)
+	}
+
+	// Geräte werden nicht beim Laden nachgeschlagen, neu deklarierte Variablen sind sofort gültig
+	runtime := `{
+		"variables": {"mode": ""},
+		"mappings": [
+			{"name": "Headset", "enabled": true, "event": {"type": "note_on", "note": 36},
+				"action": {"type": "audio_source", "parameters": {"source": "abgestecktes-headset", "type": "switch"}}},
+			{"name": "Mode", "enabled": true, "event": {"type": "note_on", "note": 37},
+				"action": {"type": "variable", "parameters": {"name": "mode", "value": "live"}}}
+		]
+	}`
+	if err := h.Reload(loadTestConfig(t, runtime)); err != nil {
+		t.Fatalf("Reload with unplugged device and new variable: %v", err)
+	}
+}
+
+func TestHandlerReplaysThroughEventLoop(t *testing.T) {
+	h, err := NewHandler(loadTestConfig(t, `{
+		"variables": {"pad": ""},
+		"mappings": [
+			{"name": "Panic", "enabled": true, "trigger": {"type": "chord", "window": 30,
+				"events": [{"type": "note_on", "note": 36}, {"type": "note_on", "note": 37}]},
+				"action": {"type": "variable", "parameters": {"name": "pad", "value": "chord"}}},
+			{"name": "Pad", "enabled": true, "event": {"type": "note_on", "note": 36},
+				"action": {"type": "variable", "parameters": {"name": "pad", "value": "single"}}}
+		]
+	}`), utils.NewNullLogger())
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	t.Cleanup(func() { h.actionMgr.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan MIDIEvent)
+	go h.processEvents(ctx, events)
+
+	// Nach Ablauf des Akkord-Fensters löst die Event-Schleife das Einzel-Mapping aus
+	events <- MIDIEvent{Type: "note_on", Note: 36, Velocity: 100}
+	waitForVariable(t, h, "pad", "single")
+}
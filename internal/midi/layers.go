@@ -0,0 +1,163 @@
+// Package midi verwaltet MIDI-Eingaben und leitet sie an die entsprechenden Aktionen weiter.
+// Diese Datei enthält die Verwaltung von Shift-Layern und Mapping-Bänken.
+
+package midi
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+// LayerStatus beschreibt den aktuellen Layer-Zustand
+type LayerStatus struct {
+	Active string   `json:"active"` // effektiv aktiver Layer
+	Bank   string   `json:"bank"`   // ausgewählte Bank
+	Shift  []string `json:"shift"`  // gehaltene Shift-Layer (zuletzt gedrückter zuletzt)
+}
+
+// layerState verwaltet die ausgewählte Bank und gehaltene Shift-Layer
+type layerState struct {
+	config config.LayerConfig
+	bank   string
+	shift  []string
+	mutex  sync.RWMutex
+}
+
+// newLayerState erstellt einen neuen Layer-Zustand mit dem Standard-Layer als Bank
+func newLayerState(cfg config.LayerConfig) *layerState {
+	return &layerState{
+		config: cfg,
+		bank:   cfg.Default,
+	}
+}
+
+// active gibt den effektiv aktiven Layer zurück
+func (s *layerState) active() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.activeLocked()
+}
+
+// activeLocked gibt den aktiven Layer zurück (Lock muss gehalten werden)
+func (s *layerState) activeLocked() string {
+	if len(s.shift) > 0 {
+		return s.shift[len(s.shift)-1]
+	}
+	return s.bank
+}
+
+// status gibt eine Kopie des aktuellen Zustands zurück
+func (s *layerState) status() LayerStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return LayerStatus{
+		Active: s.activeLocked(),
+		Bank:   s.bank,
+		Shift:  append([]string(nil), s.shift...),
+	}
+}
+
+// selectBank wählt eine Bank aus
+func (s *layerState) selectBank(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, layer := range s.config.Definitions {
+		if layer.Name == name {
+			if !layer.IsBank() {
+				return fmt.Errorf("layer '%s' ist ein Shift-Layer und keine Bank", name)
+			}
+			s.bank = name
+			return nil
+		}
+	}
+	return fmt.Errorf("layer '%s' nicht gefunden", name)
+}
+
+// handle verarbeitet Layer-Steuerevents und gibt zurück ob das Event verbraucht wurde
+func (s *layerState) handle(event MIDIEvent) (changed bool, consumed bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.config.Definitions) == 0 {
+		return false, false
+	}
+
+	before := s.activeLocked()
+
+	for _, layer := range s.config.Definitions {
+		switch {
+		case layer.Shift != nil && event.Type == "note_on" && matchesEvent(event, *layer.Shift):
+			s.releaseShift(layer.Name)
+			s.shift = append(s.shift, layer.Name)
+			consumed = true
+
+		case layer.Shift != nil && event.Note == layer.Shift.Note && event.Type == "note_off":
+			s.releaseShift(layer.Name)
+			consumed = true
+
+		case layer.Program != nil && event.Type == "program_change" && event.Program == *layer.Program:
+			s.bank = layer.Name
+			consumed = true
+		}
+	}
+
+	if !consumed && s.config.Cycle != nil && matchesEvent(event, *s.config.Cycle) {
+		s.bank = s.nextBank()
+		consumed = true
+	}
+
+	return s.activeLocked() != before, consumed
+}
+
+// reconfigure übernimmt neue Layer-Definitionen und behält die gewählte Bank, falls sie noch existiert
+func (s *layerState) reconfigure(cfg config.LayerConfig) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	bank, shift := s.bank, s.shift
+	s.config = cfg
+	s.bank = cfg.Default
+	s.shift = nil
+
+	for _, layer := range cfg.Definitions {
+		if layer.Name == bank && layer.IsBank() {
+			s.bank = bank
+		}
+	}
+	for _, name := range shift {
+		for _, layer := range cfg.Definitions {
+			if layer.Name == name && !layer.IsBank() {
+				s.shift = append(s.shift, name)
+			}
+		}
+	}
+}
+
+// releaseShift entfernt einen Shift-Layer aus der Liste der gehaltenen Layer
+func (s *layerState) releaseShift(name string) {
+	for i, held := range s.shift {
+		if held == name {
+			s.shift = append(s.shift[:i], s.shift[i+1:]...)
+			return
+		}
+	}
+}
+
+// nextBank gibt die auf die aktuelle Bank folgende Bank zurück
+func (s *layerState) nextBank() string {
+	var banks []string
+	current := 0
+	for _, layer := range s.config.Definitions {
+		if !layer.IsBank() {
+			continue
+		}
+		if layer.Name == s.bank {
+			current = len(banks)
+		}
+		banks = append(banks, layer.Name)
+	}
+	return banks[(current+1)%len(banks)]
+}
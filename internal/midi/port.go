@@ -26,6 +26,52 @@ func newMIDIPort() (MIDIPort, error) {
 	return NewMIDIPort()
 }
 
+// decodeMessage wandelt eine rohe MIDI-Channel-Voice-Nachricht, wie sie ALSA bzw. die Windows
+// MIDI-API liefern, in ein MIDIEvent um. Andere Nachrichten (System, Aftertouch) werden ignoriert.
+func decodeMessage(data []byte, timestamp time.Time) (MIDIEvent, bool) {
+	if len(data) < 2 || data[0] < 0x80 {
+		return MIDIEvent{}, false
+	}
+	event := MIDIEvent{Channel: int(data[0] & 0x0f), Timestamp: timestamp}
+
+	switch data[0] & 0xf0 {
+	case 0x80, 0x90:
+		if len(data) < 3 {
+			return MIDIEvent{}, false
+		}
+		event.Type = "note_off"
+		if data[0]&0xf0 == 0x90 {
+			event.Type = "note_on"
+		}
+		event.Note = int(data[1] & 0x7f)
+		event.Velocity = int(data[2] & 0x7f)
+
+	case 0xb0:
+		if len(data) < 3 {
+			return MIDIEvent{}, false
+		}
+		event.Type = "control_change"
+		event.Controller = int(data[1] & 0x7f)
+		event.Value = int(data[2] & 0x7f)
+
+	case 0xc0:
+		event.Type = "program_change"
+		event.Program = int(data[1] & 0x7f)
+
+	case 0xe0:
+		// 14-Bit-Wert aus LSB und MSB, 8192 = Mittelstellung
+		if len(data) < 3 {
+			return MIDIEvent{}, false
+		}
+		event.Type = "pitch_bend"
+		event.Value = int(data[1]&0x7f) | int(data[2]&0x7f)<<7
+
+	default:
+		return MIDIEvent{}, false
+	}
+	return event, true
+}
+
 // Windows-spezifische Implementierung
 type windowsMIDIPort struct {
 	portName string
@@ -192,3 +238,10 @@ func (p *mockMIDIPort) SendMockEvent(event MIDIEvent) {
 		p.eventChan <- event
 	}
 }
+
+// SendMockMessage dekodiert eine rohe MIDI-Nachricht und sendet sie als Test-Event (nur für Mock-Implementierung)
+func (p *mockMIDIPort) SendMockMessage(data []byte) {
+	if event, ok := decodeMessage(data, time.Now()); ok {
+		p.SendMockEvent(event)
+	}
+}
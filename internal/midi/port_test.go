@@ -0,0 +1,35 @@
+package midi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeMessage(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		data []byte
+		want MIDIEvent
+	}{
+		{[]byte{0x91, 60, 100}, MIDIEvent{Type: "note_on", Channel: 1, Note: 60, Velocity: 100}},
+		{[]byte{0x80, 60, 0}, MIDIEvent{Type: "note_off", Note: 60}},
+		{[]byte{0xb2, 7, 127}, MIDIEvent{Type: "control_change", Channel: 2, Controller: 7, Value: 127}},
+		{[]byte{0xc0, 5}, MIDIEvent{Type: "program_change", Program: 5}},
+		{[]byte{0xe3, 0x00, 0x40}, MIDIEvent{Type: "pitch_bend", Channel: 3, Value: 8192}},
+		{[]byte{0xe0, 0x7f, 0x7f}, MIDIEvent{Type: "pitch_bend", Value: 16383}},
+		{[]byte{0xe0, 0x01, 0x00}, MIDIEvent{Type: "pitch_bend", Value: 1}},
+	}
+	for _, test := range tests {
+		test.want.Timestamp = now
+		got, ok := decodeMessage(test.data, now)
+		if !ok || got != test.want {
+			t.Fatalf("decodeMessage(% x): expected %+v, got %+v (%v)", test.data, test.want, got, ok)
+		}
+	}
+
+	for _, data := range [][]byte{{0xe0, 0x00}, {0xf8}, {0x40, 0x10}, {0xa0, 60, 10}} {
+		if event, ok := decodeMessage(data, now); ok {
+			t.Fatalf("decodeMessage(% x): expected message to be ignored, got %+v", data, event)
+		}
+	}
+}
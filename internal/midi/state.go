@@ -0,0 +1,100 @@
+// Package midi verwaltet MIDI-Eingaben und leitet sie an die entsprechenden Aktionen weiter.
+// Diese Datei enthält den Zustand von Toggle- und Momentary-Mappings.
+
+package midi
+
+import (
+	"sync"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+// StateListener wird aufgerufen, wenn sich der Zustand eines Mappings ändert (z.B. für LED-Feedback)
+type StateListener func(mapping string, on bool)
+
+// mappingStates speichert den Ein/Aus-Zustand zustandsbehafteter Mappings nach Namen
+type mappingStates struct {
+	states   map[string]bool
+	listener StateListener
+	mutex    sync.RWMutex
+}
+
+// newMappingStates erstellt einen neuen Zustandsspeicher
+func newMappingStates() *mappingStates {
+	return &mappingStates{
+		states: make(map[string]bool),
+	}
+}
+
+// get gibt den Zustand eines Mappings zurück
+func (s *mappingStates) get(name string) (on bool, exists bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	on, exists = s.states[name]
+	return on, exists
+}
+
+// set setzt den Zustand eines Mappings und gibt den vorherigen Zustand zurück
+func (s *mappingStates) set(name string, on bool) bool {
+	s.mutex.Lock()
+	previous := s.states[name]
+	s.states[name] = on
+	listener := s.listener
+	s.mutex.Unlock()
+
+	if listener != nil && previous != on {
+		listener(name, on)
+	}
+	return previous
+}
+
+// toggle schaltet den Zustand eines Mappings um und gibt den neuen Zustand zurück
+func (s *mappingStates) toggle(name string) bool {
+	s.mutex.Lock()
+	on := !s.states[name]
+	s.states[name] = on
+	listener := s.listener
+	s.mutex.Unlock()
+
+	if listener != nil {
+		listener(name, on)
+	}
+	return on
+}
+
+// snapshot gibt eine Kopie aller Zustände zurück
+func (s *mappingStates) snapshot() map[string]bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	states := make(map[string]bool, len(s.states))
+	for name, on := range s.states {
+		states[name] = on
+	}
+	return states
+}
+
+// retain verwirft Zustände von Mappings, die nach einem Reload nicht mehr zustandsbehaftet sind
+func (s *mappingStates) retain(mappings []config.Mapping) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keep := make(map[string]bool)
+	for _, mapping := range mappings {
+		if mapping.Mode == "toggle" || mapping.Mode == "momentary" {
+			keep[mapping.Name] = true
+		}
+	}
+	for name := range s.states {
+		if !keep[name] {
+			delete(s.states, name)
+		}
+	}
+}
+
+// setListener setzt den Listener für Zustandsänderungen
+func (s *mappingStates) setListener(listener StateListener) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listener = listener
+}
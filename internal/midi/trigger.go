@@ -0,0 +1,387 @@
+// Package midi verwaltet MIDI-Eingaben und leitet sie an die entsprechenden Aktionen weiter.
+// Diese Datei enthält die Auswertung von Akkord- und Sequenz-Triggern.
+
+package midi
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+// triggerTracker verfolgt pro Port gehaltene Noten sowie den Fortschritt von Akkorden und Sequenzen
+type triggerTracker struct {
+	ports map[string]*portState
+	mutex sync.Mutex
+
+	// wake meldet der Event-Schleife, dass ein Toleranzfenster oder eine Sequenz abgelaufen ist.
+	// Die fälligen Events holt sie mit due ab, damit sie in der Reihenfolge der Live-Events laufen.
+	wake chan struct{}
+}
+
+// portState enthält den Notenzustand eines einzelnen MIDI-Ports
+type portState struct {
+	held      map[int]heldNote             // gehaltene Noten nach Notennummer
+	chords    map[string]bool              // Akkorde, die seit dem letzten Loslassen ausgelöst wurden
+	sequences map[string]*sequenceProgress // Fortschritt laufender Sequenzen
+	pending   map[int]*pendingNote         // zurückgehaltene Einzel-Mappings von Akkord-Noten
+
+	// Events, die eine laufende Sequenz verbraucht hat; sie werden nachgeholt, falls die Sequenz
+	// abbricht oder abläuft, und verworfen, wenn sie auslöst
+	deferred  []MIDIEvent
+	expiry    *time.Timer
+	expiresAt time.Time
+}
+
+// pendingNote ist eine Akkord-Note, deren Einzel-Mappings bis zum Ablauf des Toleranzfensters warten
+type pendingNote struct {
+	event    MIDIEvent
+	timer    *time.Timer
+	deadline time.Time
+}
+
+// heldNote beschreibt eine gedrückte Note
+type heldNote struct {
+	velocity int
+	since    time.Time
+}
+
+// sequenceProgress beschreibt den Fortschritt einer Sequenz
+type sequenceProgress struct {
+	step int
+	last time.Time
+}
+
+// triggerResult ist das Ergebnis der Trigger-Auswertung für ein Event
+type triggerResult struct {
+	// Mappings, deren Akkord oder Sequenz vollständig ist
+	fired []config.Mapping
+
+	// Event ist Teil einer laufenden Sequenz und darf keine Einzel-Mappings auslösen
+	consumed bool
+
+	// Einzel-Mappings sollen um diese Dauer verzögert werden, da ein Akkord entstehen kann
+	hold time.Duration
+
+	// Zurückgehaltene Events, deren Einzel-Mappings vor diesem Event auszuführen sind
+	replay []MIDIEvent
+
+	// Loslassen einer Note, deren Drücken eine laufende Sequenz zurückhält; es wird mit ihm nachgeholt
+	queued bool
+}
+
+// newTriggerTracker erstellt einen neuen Trigger-Tracker
+func newTriggerTracker() *triggerTracker {
+	return &triggerTracker{
+		ports: make(map[string]*portState),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// port gibt den Zustand eines Ports zurück und legt ihn bei Bedarf an
+func (t *triggerTracker) port(name string) *portState {
+	state, exists := t.ports[name]
+	if !exists {
+		state = &portState{
+			held:      make(map[int]heldNote),
+			chords:    make(map[string]bool),
+			sequences: make(map[string]*sequenceProgress),
+			pending:   make(map[int]*pendingNote),
+		}
+		t.ports[name] = state
+	}
+	return state
+}
+
+// process aktualisiert den Notenzustand und wertet alle Trigger-Mappings für ein Event aus
+func (t *triggerTracker) process(event MIDIEvent, mappings []config.Mapping) triggerResult {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	state := t.port(event.Port)
+	var result triggerResult
+
+	// Notenzustand aktualisieren
+	switch event.Type {
+	case "note_on":
+		state.held[event.Note] = heldNote{velocity: event.Velocity, since: event.Timestamp}
+	case "note_off":
+		delete(state.held, event.Note)
+
+		// Loslassen vor Ablauf des Toleranzfensters: das Drücken zuerst ausführen, damit
+		// Toggle- und Momentary-Mappings in der richtigen Reihenfolge schalten
+		if pending, ok := state.pending[event.Note]; ok {
+			pending.timer.Stop()
+			delete(state.pending, event.Note)
+			result.replay = append(result.replay, pending.event)
+		}
+	}
+
+	var sequence triggerResult
+	for _, mapping := range mappings {
+		if !mapping.Enabled || mapping.Trigger == nil {
+			continue
+		}
+
+		switch mapping.Trigger.Type {
+		case "chord":
+			t.processChord(state, event, mapping, &result)
+		case "sequence":
+			t.processSequence(state, event, mapping, &sequence)
+		}
+	}
+	result.fired = append(result.fired, sequence.fired...)
+	result.consumed = result.consumed || sequence.consumed
+
+	t.deferSequenceEvents(state, event, mappings, sequence, &result)
+	return result
+}
+
+// deferSequenceEvents hält von Sequenzen verbrauchte Events zurück, bis feststeht, ob die Sequenz
+// auslöst. Bricht sie ab oder läuft sie ab, werden die Events als Einzel-Mappings nachgeholt.
+func (t *triggerTracker) deferSequenceEvents(state *portState, event MIDIEvent, mappings []config.Mapping, sequence triggerResult, result *triggerResult) {
+	switch {
+	case len(sequence.fired) > 0:
+		// Ausgelöste Sequenz: ihre Events ersetzen die Einzel-Mappings
+		state.deferred = nil
+		stopTimer(&state.expiry)
+
+	case sequence.consumed:
+		state.deferred = append(state.deferred, event)
+		t.armExpiry(state, event, mappings)
+
+	case len(state.deferred) == 0:
+
+	case !sequencesRunning(state):
+		// Keine Sequenz läuft mehr: zurückgehaltene Events vor diesem Event nachholen
+		result.replay = append(result.replay, state.deferred...)
+		state.deferred = nil
+		stopTimer(&state.expiry)
+
+	case event.Type == "note_off" && deferredNote(state.deferred, event.Note):
+		// Das Loslassen folgt seinem zurückgehaltenen Drücken
+		state.deferred = append(state.deferred, event)
+		result.queued = true
+	}
+}
+
+// armExpiry startet den Timer, der die Event-Schleife weckt, sobald alle laufenden Sequenzen abgelaufen sind
+func (t *triggerTracker) armExpiry(state *portState, event MIDIEvent, mappings []config.Mapping) {
+	var delay time.Duration
+	for _, mapping := range mappings {
+		progress, ok := state.sequences[mapping.Name]
+		if !ok || progress.step == 0 || mapping.Trigger == nil {
+			continue
+		}
+		timeout := time.Duration(mapping.Trigger.Timeout) * time.Millisecond
+		if remaining := progress.last.Add(timeout).Sub(event.Timestamp); remaining > delay {
+			delay = remaining
+		}
+	}
+
+	stopTimer(&state.expiry)
+	delay += time.Millisecond
+	state.expiresAt = time.Now().Add(delay)
+	state.expiry = time.AfterFunc(delay, t.wakeup)
+}
+
+// wakeup weckt die Event-Schleife, ohne zu blockieren; ein ausstehendes Wecken genügt für alle Timer
+func (t *triggerTracker) wakeup() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// due gibt die Events zurück, deren Toleranzfenster bzw. Sequenz bis now abgelaufen ist, und entfernt
+// sie aus dem Zustand. Sie wird von der Event-Schleife aufgerufen, damit ein Loslassen, das vorher
+// eintrifft, die zurückgehaltene Note selbst nachholt und nie vor ihr ausgeführt wird.
+func (t *triggerTracker) due(now time.Time) []MIDIEvent {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var events []MIDIEvent
+	for _, state := range t.ports {
+		var held []MIDIEvent
+		for note, pending := range state.pending {
+			if !now.Before(pending.deadline) {
+				delete(state.pending, note)
+				held = append(held, pending.event)
+			}
+		}
+		sort.Slice(held, func(i, j int) bool { return held[i].Timestamp.Before(held[j].Timestamp) })
+		events = append(events, held...)
+		if state.expiry != nil && !now.Before(state.expiresAt) {
+			state.expiry = nil
+			for _, progress := range state.sequences {
+				progress.step = 0
+			}
+			events = append(events, state.deferred...)
+			state.deferred = nil
+		}
+	}
+	return events
+}
+
+// processChord wertet einen Akkord-Trigger aus
+func (t *triggerTracker) processChord(state *portState, event MIDIEvent, mapping config.Mapping, result *triggerResult) {
+	trigger := mapping.Trigger
+
+	if !chordContains(trigger, event.Note) || (event.Type != "note_on" && event.Type != "note_off") {
+		return
+	}
+
+	// Loslassen einer Akkord-Note gibt den Akkord wieder frei
+	if event.Type == "note_off" {
+		delete(state.chords, mapping.Name)
+		return
+	}
+
+	if state.chords[mapping.Name] {
+		return
+	}
+
+	window := time.Duration(trigger.Window) * time.Millisecond
+	var first, last time.Time
+	complete := true
+	for _, note := range trigger.Events {
+		held, ok := state.held[note.Note]
+		if !ok || (note.Velocity > 0 && held.velocity < note.Velocity) {
+			complete = false
+			break
+		}
+		if first.IsZero() || held.since.Before(first) {
+			first = held.since
+		}
+		if held.since.After(last) {
+			last = held.since
+		}
+	}
+
+	if !complete || last.Sub(first) > window {
+		// Akkord noch unvollständig: Einzel-Mappings dieser Note zurückhalten
+		if window > result.hold {
+			result.hold = window
+		}
+		return
+	}
+
+	state.chords[mapping.Name] = true
+	result.fired = append(result.fired, mapping)
+	result.consumed = true
+
+	// Zurückgehaltene Einzel-Mappings der Akkord-Noten verwerfen
+	for _, note := range trigger.Events {
+		if pending, ok := state.pending[note.Note]; ok {
+			pending.timer.Stop()
+			delete(state.pending, note.Note)
+		}
+	}
+}
+
+// processSequence wertet einen Sequenz-Trigger aus
+func (t *triggerTracker) processSequence(state *portState, event MIDIEvent, mapping config.Mapping, result *triggerResult) {
+	trigger := mapping.Trigger
+
+	// Events, die in der Sequenz nicht vorkommen (z.B. Note-Off), unterbrechen sie nicht
+	if !sequenceUses(trigger, event.Type) {
+		return
+	}
+
+	progress, exists := state.sequences[mapping.Name]
+	if !exists {
+		progress = &sequenceProgress{}
+		state.sequences[mapping.Name] = progress
+	}
+
+	// Zeitüberschreitung verwirft die angefangene Sequenz
+	timeout := time.Duration(trigger.Timeout) * time.Millisecond
+	if progress.step > 0 && event.Timestamp.Sub(progress.last) > timeout {
+		progress.step = 0
+	}
+
+	if !matchesEvent(event, trigger.Events[progress.step]) {
+		// Falsches Event: Sequenz verwerfen und ggf. neu beginnen
+		progress.step = 0
+		if !matchesEvent(event, trigger.Events[0]) {
+			return
+		}
+	}
+
+	progress.step++
+	progress.last = event.Timestamp
+	result.consumed = true
+
+	if progress.step == len(trigger.Events) {
+		progress.step = 0
+		result.fired = append(result.fired, mapping)
+	}
+}
+
+// hold hält die Einzel-Mappings einer Akkord-Note zurück, bis das Toleranzfenster abgelaufen ist
+// oder die Note losgelassen wird
+func (t *triggerTracker) hold(event MIDIEvent, delay time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	state := t.port(event.Port)
+	if pending, ok := state.pending[event.Note]; ok {
+		pending.timer.Stop()
+	}
+
+	state.pending[event.Note] = &pendingNote{
+		event:    event,
+		timer:    time.AfterFunc(delay, t.wakeup),
+		deadline: time.Now().Add(delay),
+	}
+}
+
+// sequencesRunning prüft ob auf einem Port eine Sequenz begonnen, aber noch nicht beendet ist
+func sequencesRunning(state *portState) bool {
+	for _, progress := range state.sequences {
+		if progress.step > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// deferredNote prüft ob das Drücken einer Note unter den zurückgehaltenen Events ist
+func deferredNote(events []MIDIEvent, note int) bool {
+	for _, event := range events {
+		if event.Type == "note_on" && event.Note == note {
+			return true
+		}
+	}
+	return false
+}
+
+// stopTimer hält einen Timer an und setzt ihn zurück
+func stopTimer(timer **time.Timer) {
+	if *timer != nil {
+		(*timer).Stop()
+		*timer = nil
+	}
+}
+
+// chordContains prüft ob eine Note Teil eines Akkords ist
+func chordContains(trigger *config.Trigger, note int) bool {
+	for _, event := range trigger.Events {
+		if event.Note == note {
+			return true
+		}
+	}
+	return false
+}
+
+// sequenceUses prüft ob ein Event-Typ in einer Sequenz vorkommt
+func sequenceUses(trigger *config.Trigger, eventType string) bool {
+	for _, event := range trigger.Events {
+		if event.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package midi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Xcruser/MidiDaemon/internal/config"
+)
+
+func noteOn(note int, at time.Time) MIDIEvent {
+	return MIDIEvent{Type: "note_on", Port: "test", Note: note, Velocity: 100, Timestamp: at}
+}
+
+func TestSequenceRejectedPartialIsConsumed(t *testing.T) {
+	mappings := []config.Mapping{{
+		Name:    "Riff",
+		Enabled: true,
+		Trigger: &config.Trigger{
+			Type:    "sequence",
+			Timeout: 500,
+			Events: []config.MIDIEvent{
+				{Type: "note_on", Note: 60},
+				{Type: "note_on", Note: 62},
+				{Type: "note_on", Note: 64},
+			},
+		},
+	}}
+
+	tracker := newTriggerTracker()
+	start := time.Now()
+
+	if res := tracker.process(noteOn(60, start), mappings); !res.consumed || len(res.fired) != 0 {
+		t.Fatalf("first step: expected consumed without firing, got %+v", res)
+	}
+	if res := tracker.process(noteOn(62, start.Add(100*time.Millisecond)), mappings); !res.consumed {
+		t.Fatalf("second step: expected consumed, got %+v", res)
+	}
+
+	// Falsche Note bricht die Sequenz ab und wird normal verarbeitet; die zurückgehaltenen Noten
+	// werden vorher für Einzel-Mappings nachgeholt
+	res := tracker.process(noteOn(65, start.Add(200*time.Millisecond)), mappings)
+	if res.consumed || len(res.fired) != 0 {
+		t.Fatalf("mismatch: expected unconsumed event, got %+v", res)
+	}
+	if len(res.replay) != 2 || res.replay[0].Note != 60 || res.replay[1].Note != 62 {
+		t.Fatalf("expected notes 60 and 62 to be replayed, got %+v", res.replay)
+	}
+
+	// Nach dem Abbruch löst das dritte Event die Sequenz nicht aus
+	if res := tracker.process(noteOn(64, start.Add(300*time.Millisecond)), mappings); res.consumed || len(res.fired) != 0 {
+		t.Fatalf("after reject: expected no firing, got %+v", res)
+	}
+}
+
+func TestSequenceFiresWithinTimeout(t *testing.T) {
+	mappings := []config.Mapping{{
+		Name:    "Riff",
+		Enabled: true,
+		Trigger: &config.Trigger{
+			Type:    "sequence",
+			Timeout: 500,
+			Events: []config.MIDIEvent{
+				{Type: "note_on", Note: 60},
+				{Type: "note_on", Note: 62},
+			},
+		},
+	}}
+
+	tracker := newTriggerTracker()
+	start := time.Now()
+
+	tracker.process(noteOn(60, start), mappings)
+	// Note-Off zwischen den Schritten unterbricht die Sequenz nicht
+	tracker.process(MIDIEvent{Type: "note_off", Port: "test", Note: 60, Timestamp: start.Add(50 * time.Millisecond)}, mappings)
+	res := tracker.process(noteOn(62, start.Add(400*time.Millisecond)), mappings)
+	if len(res.fired) != 1 {
+		t.Fatalf("expected sequence to fire, got %+v", res)
+	}
+
+	tracker.process(noteOn(60, start.Add(time.Second)), mappings)
+	res = tracker.process(noteOn(62, start.Add(2*time.Second)), mappings)
+	if len(res.fired) != 0 {
+		t.Fatalf("expected timeout to reject sequence, got %+v", res)
+	}
+}
+
+func TestChordWindow(t *testing.T) {
+	mappings := []config.Mapping{{
+		Name:    "Panic",
+		Enabled: true,
+		Trigger: &config.Trigger{
+			Type:   "chord",
+			Window: 100,
+			Events: []config.MIDIEvent{
+				{Type: "note_on", Note: 36},
+				{Type: "note_on", Note: 37},
+				{Type: "note_on", Note: 38},
+			},
+		},
+	}}
+
+	tracker := newTriggerTracker()
+	start := time.Now()
+
+	if res := tracker.process(noteOn(36, start), mappings); res.hold == 0 || len(res.fired) != 0 {
+		t.Fatalf("expected first chord note to be held back, got %+v", res)
+	}
+	tracker.process(noteOn(37, start.Add(20*time.Millisecond)), mappings)
+	res := tracker.process(noteOn(38, start.Add(40*time.Millisecond)), mappings)
+	if len(res.fired) != 1 || !res.consumed {
+		t.Fatalf("expected chord to fire, got %+v", res)
+	}
+
+	// Erneutes Anschlagen ohne Loslassen löst nicht erneut aus
+	res = tracker.process(noteOn(38, start.Add(60*time.Millisecond)), mappings)
+	if len(res.fired) != 0 {
+		t.Fatalf("expected chord not to fire twice, got %+v", res)
+	}
+}
+
+func TestSequenceReplaysOnTimeout(t *testing.T) {
+	mappings := []config.Mapping{{
+		Name:    "Riff",
+		Enabled: true,
+		Trigger: &config.Trigger{
+			Type:    "sequence",
+			Timeout: 20,
+			Events: []config.MIDIEvent{
+				{Type: "note_on", Note: 60},
+				{Type: "note_on", Note: 62},
+			},
+		},
+	}}
+
+	tracker := newTriggerTracker()
+	start := time.Now()
+
+	tracker.process(noteOn(60, start), mappings)
+	// Das Loslassen einer zurückgehaltenen Note wird hinter ihr Drücken eingereiht
+	res := tracker.process(MIDIEvent{Type: "note_off", Port: "test", Note: 60, Timestamp: start.Add(5 * time.Millisecond)}, mappings)
+	if !res.queued {
+		t.Fatalf("expected release to be queued, got %+v", res)
+	}
+
+	events := waitDue(t, tracker)
+	if len(events) != 2 || events[0].Type != "note_on" || events[1].Type != "note_off" {
+		t.Fatalf("expected press and release to be replayed in order, got %+v", events)
+	}
+
+	// Eine ausgelöste Sequenz holt nichts nach
+	start = time.Now()
+	tracker.process(noteOn(60, start), mappings)
+	if res := tracker.process(noteOn(62, start.Add(5*time.Millisecond)), mappings); len(res.fired) != 1 || len(res.replay) != 0 {
+		t.Fatalf("expected sequence to fire without replay, got %+v", res)
+	}
+	expectNothingDue(t, tracker)
+}
+
+func TestChordReleaseFlushesHold(t *testing.T) {
+	mappings := []config.Mapping{{
+		Name:    "Panic",
+		Enabled: true,
+		Trigger: &config.Trigger{
+			Type:   "chord",
+			Window: 1000,
+			Events: []config.MIDIEvent{
+				{Type: "note_on", Note: 36},
+				{Type: "note_on", Note: 37},
+			},
+		},
+	}}
+
+	tracker := newTriggerTracker()
+	start := time.Now()
+
+	res := tracker.process(noteOn(36, start), mappings)
+	if res.hold == 0 {
+		t.Fatalf("expected chord note to be held back, got %+v", res)
+	}
+	tracker.hold(noteOn(36, start), res.hold)
+
+	// Kurzes Antippen: das Loslassen holt das Drücken sofort und vor sich selbst nach
+	res = tracker.process(MIDIEvent{Type: "note_off", Port: "test", Note: 36, Timestamp: start.Add(30 * time.Millisecond)}, mappings)
+	if len(res.replay) != 1 || res.replay[0].Type != "note_on" || res.replay[0].Note != 36 || res.queued {
+		t.Fatalf("expected held press to be replayed before the release, got %+v", res)
+	}
+	expectNothingDue(t, tracker)
+
+	// Ist das Fenster abgelaufen, aber noch nicht von der Event-Schleife abgeholt, holt das Loslassen
+	// die Note trotzdem selbst vor sich nach
+	tracker.hold(noteOn(36, start), time.Millisecond)
+	<-tracker.wake
+	res = tracker.process(MIDIEvent{Type: "note_off", Port: "test", Note: 36, Timestamp: time.Now()}, mappings)
+	if len(res.replay) != 1 || res.replay[0].Type != "note_on" {
+		t.Fatalf("expected expired press to be replayed before the release, got %+v", res)
+	}
+	if events := tracker.due(time.Now()); len(events) != 0 {
+		t.Fatalf("press replayed twice: %+v", events)
+	}
+}
+
+// waitDue wartet, bis ein Timer die Event-Schleife weckt, und gibt die fälligen Events zurück
+func waitDue(t *testing.T, tracker *triggerTracker) []MIDIEvent {
+	t.Helper()
+	select {
+	case <-tracker.wake:
+		return tracker.due(time.Now())
+	case <-time.After(time.Second):
+		t.Fatalf("expected timer to wake the event loop")
+		return nil
+	}
+}
+
+// expectNothingDue prüft, dass kein abgebrochener Timer noch Events nachholt
+func expectNothingDue(t *testing.T, tracker *triggerTracker) {
+	t.Helper()
+	time.Sleep(50 * time.Millisecond)
+	if events := tracker.due(time.Now()); len(events) != 0 {
+		t.Fatalf("unexpected replay: %+v", events)
+	}
+}
@@ -0,0 +1,388 @@
+// Package pulse implementiert einen minimalen Client für das native PulseAudio-Protokoll.
+// Der Client spricht über den Unix-Socket mit PulseAudio bzw. PipeWire (pipewire-pulse)
+// und unterstützt Geräte-Abfragen, Lautstärke, Stummschaltung und Änderungs-Abonnements.
+package pulse
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Protokollkonstanten
+const (
+	protocolVersion = 32
+	versionMask     = 0x0000FFFF
+	commandChannel  = 0xFFFFFFFF
+	headerSize      = 20
+	cookieSize      = 256
+
+	// InvalidIndex kennzeichnet einen nicht gesetzten Index
+	InvalidIndex = 0xFFFFFFFF
+)
+
+// Befehle des nativen Protokolls
+const (
+	commandError                = 0
+	commandReply                = 2
+	commandAuth                 = 8
+	commandSetClientName        = 9
+	commandGetServerInfo        = 20
+	commandGetSinkInfo          = 21
+	commandGetSinkInfoList      = 22
+	commandGetSourceInfo        = 23
+	commandGetSourceInfoList    = 24
+	commandGetSinkInputInfoList = 30
+	commandSubscribe            = 35
+	commandSetSinkVolume        = 36
+	commandSetSinkInputVolume   = 37
+	commandSetSourceVolume      = 38
+	commandSetSinkMute          = 39
+	commandSetSourceMute        = 40
+	commandSetDefaultSink       = 44
+	commandSetDefaultSource     = 45
+	commandSubscribeEvent       = 66
+	commandMoveSinkInput        = 67
+	commandSetSinkInputMute     = 69
+)
+
+// ErrClosed wird zurückgegeben, wenn die Verbindung geschlossen wurde
+var ErrClosed = errors.New("pulseaudio-verbindung geschlossen")
+
+// errorNames enthält die Texte der PulseAudio-Fehlercodes
+var errorNames = map[uint32]string{
+	1:  "zugriff verweigert",
+	2:  "unbekannter Befehl",
+	3:  "ungültiges Argument",
+	4:  "existiert bereits",
+	5:  "nicht gefunden",
+	6:  "verbindung abgelehnt",
+	7:  "protokollfehler",
+	8:  "zeitüberschreitung",
+	9:  "kein Authentifizierungsschlüssel",
+	10: "interner Fehler",
+	11: "verbindung beendet",
+	12: "entität beendet",
+	13: "ungültiger Server",
+	15: "ungültiger Zustand",
+	17: "inkompatible Protokollversion",
+	19: "nicht unterstützt",
+}
+
+// Error ist ein vom Server gemeldeter Fehler
+type Error struct {
+	Code uint32
+}
+
+// Error gibt die Fehlermeldung zurück
+func (e *Error) Error() string {
+	if name, ok := errorNames[e.Code]; ok {
+		return fmt.Sprintf("pulseaudio-fehler %d: %s", e.Code, name)
+	}
+	return fmt.Sprintf("pulseaudio-fehler %d", e.Code)
+}
+
+// IsNotFound prüft ob ein Fehler "nicht gefunden" bedeutet
+func IsNotFound(err error) bool {
+	var pulseErr *Error
+	return errors.As(err, &pulseErr) && pulseErr.Code == 5
+}
+
+// reply ist eine Antwort des Servers auf eine Anfrage
+type reply struct {
+	command uint32
+	body    *tagReader
+}
+
+// Client ist eine Verbindung zu einem PulseAudio-kompatiblen Server
+type Client struct {
+	conn    net.Conn
+	version uint32
+
+	writeMutex sync.Mutex
+
+	mutex       sync.Mutex
+	nextTag     uint32
+	pending     map[uint32]chan reply
+	subscribers []chan Event
+	err         error
+	done        chan struct{}
+}
+
+// SocketPath ermittelt den Pfad des Server-Sockets (PULSE_SERVER, PULSE_RUNTIME_PATH, XDG_RUNTIME_DIR)
+func SocketPath() string {
+	if server := os.Getenv("PULSE_SERVER"); server != "" {
+		for _, entry := range strings.Fields(server) {
+			// Optionales "{machine-id}"-Präfix entfernen
+			if strings.HasPrefix(entry, "{") {
+				if end := strings.Index(entry, "}"); end >= 0 {
+					entry = entry[end+1:]
+				}
+			}
+			if strings.HasPrefix(entry, "unix:") {
+				return strings.TrimPrefix(entry, "unix:")
+			}
+			if strings.HasPrefix(entry, "/") {
+				return entry
+			}
+		}
+	}
+	if dir := os.Getenv("PULSE_RUNTIME_PATH"); dir != "" {
+		return filepath.Join(dir, "native")
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "pulse", "native")
+	}
+	return fmt.Sprintf("/run/user/%d/pulse/native", os.Getuid())
+}
+
+// Dial verbindet sich mit dem Server unter path, authentifiziert sich und setzt den Client-Namen
+func Dial(ctx context.Context, path, clientName string) (*Client, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Verbinden mit %s: %w", path, err)
+	}
+
+	c := &Client{
+		conn:    conn,
+		version: protocolVersion,
+		pending: make(map[uint32]chan reply),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+
+	// Authentifizieren (PipeWire ignoriert das Cookie)
+	body, err := c.request(ctx, commandAuth, func(w *tagWriter) {
+		w.u32(protocolVersion)
+		w.arbitrary(loadCookie())
+	})
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("fehler bei der Authentifizierung: %w", err)
+	}
+	serverVersion := body.u32() & versionMask
+	if body.err != nil {
+		c.Close()
+		return nil, body.err
+	}
+	if serverVersion < 13 {
+		c.Close()
+		return nil, fmt.Errorf("protokollversion %d des Servers wird nicht unterstützt", serverVersion)
+	}
+	if serverVersion < c.version {
+		c.version = serverVersion
+	}
+
+	// Client-Namen setzen
+	_, err = c.request(ctx, commandSetClientName, func(w *tagWriter) {
+		w.proplist(map[string]string{
+			"application.name":           clientName,
+			"application.process.id":     fmt.Sprint(os.Getpid()),
+			"application.process.binary": filepath.Base(os.Args[0]),
+		})
+	})
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("fehler beim Setzen des Client-Namens: %w", err)
+	}
+
+	return c, nil
+}
+
+// loadCookie liest das Authentifizierungs-Cookie; fehlt es, wird ein leeres Cookie gesendet
+func loadCookie() []byte {
+	var paths []string
+	if path := os.Getenv("PULSE_COOKIE"); path != "" {
+		paths = append(paths, path)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "pulse", "cookie"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".pulse-cookie"))
+	}
+
+	for _, path := range paths {
+		if data, err := os.ReadFile(path); err == nil && len(data) >= cookieSize {
+			return data[:cookieSize]
+		}
+	}
+	return make([]byte, cookieSize)
+}
+
+// Version gibt die ausgehandelte Protokollversion zurück
+func (c *Client) Version() uint32 {
+	return c.version
+}
+
+// Err gibt den Grund zurück, aus dem die Verbindung beendet wurde (nil solange sie besteht)
+func (c *Client) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.err
+}
+
+// Close schließt die Verbindung
+func (c *Client) Close() error {
+	err := c.conn.Close()
+	<-c.done
+	return err
+}
+
+// request sendet einen Befehl und wartet auf die Antwort
+func (c *Client) request(ctx context.Context, command uint32, args func(w *tagWriter)) (*tagReader, error) {
+	ch := make(chan reply, 1)
+
+	c.mutex.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mutex.Unlock()
+		return nil, err
+	}
+	tag := c.nextTag
+	c.nextTag++
+	c.pending[tag] = ch
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, tag)
+		c.mutex.Unlock()
+	}()
+
+	w := &tagWriter{}
+	w.u32(command)
+	w.u32(tag)
+	if args != nil {
+		args(w)
+	}
+	if err := c.writePacket(ctx, w.buf); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, c.Err()
+	case r := <-ch:
+		if r.command == commandError {
+			code := r.body.u32()
+			if r.body.err != nil {
+				return nil, r.body.err
+			}
+			return nil, &Error{Code: code}
+		}
+		if r.command != commandReply {
+			return nil, fmt.Errorf("unerwartete Antwort %d auf Befehl %d", r.command, command)
+		}
+		return r.body, nil
+	}
+}
+
+// writePacket sendet ein Paket auf dem Befehlskanal
+func (c *Client) writePacket(ctx context.Context, payload []byte) error {
+	packet := make([]byte, headerSize, headerSize+len(payload))
+	binary.BigEndian.PutUint32(packet[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(packet[4:], commandChannel)
+	packet = append(packet, payload...)
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Time{}
+	}
+	c.conn.SetWriteDeadline(deadline)
+
+	if _, err := c.conn.Write(packet); err != nil {
+		return fmt.Errorf("fehler beim Senden: %w", err)
+	}
+	return nil
+}
+
+// readLoop liest Pakete und verteilt Antworten und Ereignisse, bis die Verbindung endet
+func (c *Client) readLoop() {
+	var err error
+	for {
+		var payload []byte
+		if payload, err = readPacket(c.conn); err != nil {
+			break
+		}
+		if payload == nil {
+			continue
+		}
+
+		body := &tagReader{data: payload}
+		command := body.u32()
+		tag := body.u32()
+		if body.err != nil {
+			err = body.err
+			break
+		}
+
+		switch command {
+		case commandReply, commandError:
+			c.mutex.Lock()
+			ch, ok := c.pending[tag]
+			c.mutex.Unlock()
+			if ok {
+				ch <- reply{command: command, body: body}
+			}
+
+		case commandSubscribeEvent:
+			event := Event{Type: body.u32(), Index: body.u32()}
+			if body.err == nil {
+				c.publish(event)
+			}
+		}
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		err = ErrClosed
+	}
+
+	c.mutex.Lock()
+	c.err = err
+	subscribers := c.subscribers
+	c.subscribers = nil
+	c.mutex.Unlock()
+
+	for _, ch := range subscribers {
+		close(ch)
+	}
+	close(c.done)
+}
+
+// readPacket liest ein Paket; Pakete auf Datenkanälen werden verworfen (nil)
+func readPacket(r io.Reader) ([]byte, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:])
+	channel := binary.BigEndian.Uint32(header[4:])
+	if length > maxPacketBytes {
+		return nil, fmt.Errorf("paket zu groß: %d Bytes", length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	if channel != commandChannel {
+		return nil, nil
+	}
+	return payload, nil
+}
@@ -0,0 +1,434 @@
+package pulse
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer spricht genug vom nativen Protokoll, um einen Sink zu simulieren
+type fakeServer struct {
+	t        *testing.T
+	listener net.Listener
+	path     string
+
+	mutex      sync.Mutex
+	volume     []uint32
+	muted      bool
+	conns      []net.Conn
+	subscribed map[net.Conn]bool
+	inputs     []*SinkInput
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	path := filepath.Join(t.TempDir(), "native")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeServer{
+		t:          t,
+		listener:   listener,
+		path:       path,
+		volume:     []uint32{VolumeNorm / 2, VolumeNorm / 4},
+		subscribed: make(map[net.Conn]bool),
+		inputs: []*SinkInput{
+			{Index: 7, Name: "Spotify", Volume: []uint32{VolumeNorm, VolumeNorm}, Properties: map[string]string{
+				"application.name": "Spotify", "application.process.binary": "spotify", "application.process.id": "4242",
+			}},
+			{Index: 9, Name: "Playback", Volume: []uint32{VolumeNorm / 2}, Corked: true, Properties: map[string]string{
+				"application.name": "Firefox", "application.process.binary": "firefox", "application.process.id": "1337",
+			}},
+		},
+	}
+	go s.accept()
+	t.Cleanup(func() {
+		listener.Close()
+		s.mutex.Lock()
+		for _, conn := range s.conns {
+			conn.Close()
+		}
+		s.mutex.Unlock()
+	})
+	return s
+}
+
+func (s *fakeServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.conns = append(s.conns, conn)
+		s.mutex.Unlock()
+		go s.serve(conn)
+	}
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	for {
+		payload, err := readPacket(conn)
+		if err != nil {
+			return
+		}
+		r := &tagReader{data: payload}
+		command := r.u32()
+		tag := r.u32()
+
+		w := &tagWriter{}
+		w.u32(commandReply)
+		w.u32(tag)
+
+		changed := false
+		switch command {
+		case commandAuth:
+			w.u32(protocolVersion)
+		case commandSetClientName:
+			r.proplist()
+			w.u32(1)
+		case commandGetServerInfo:
+			w.str("pulseaudio")
+			w.str("16.1")
+			w.str("user")
+			w.str("host")
+			w.sampleSpec(3, 2, 48000)
+			w.str("test_sink")
+			w.str("test_source")
+			w.u32(0)
+			w.channelMap([]uint8{1, 2})
+		case commandGetSinkInfo, commandGetSinkInfoList:
+			if command == commandGetSinkInfo {
+				r.u32()
+				if name := r.str(); name != "test_sink" {
+					w = &tagWriter{}
+					w.u32(commandError)
+					w.u32(tag)
+					w.u32(5)
+					break
+				}
+			}
+			s.writeSink(w)
+		case commandSetSinkVolume:
+			r.u32()
+			r.str()
+			volume := r.cvolume()
+			s.mutex.Lock()
+			s.volume = volume
+			s.mutex.Unlock()
+			changed = true
+		case commandSetSinkMute:
+			r.u32()
+			r.str()
+			muted := r.boolean()
+			s.mutex.Lock()
+			s.muted = muted
+			s.mutex.Unlock()
+			changed = true
+		case commandGetSinkInputInfoList:
+			s.writeSinkInputs(w)
+		case commandSetSinkInputVolume, commandSetSinkInputMute:
+			index := r.u32()
+			var volume []uint32
+			var muted bool
+			if command == commandSetSinkInputVolume {
+				volume = r.cvolume()
+			} else {
+				muted = r.boolean()
+			}
+			if !s.updateInput(index, volume, muted) {
+				w = &tagWriter{}
+				w.u32(commandError)
+				w.u32(tag)
+				w.u32(5)
+			}
+		case commandMoveSinkInput:
+			index := r.u32()
+			r.u32()
+			if !s.moveInput(index, r.str()) {
+				w = &tagWriter{}
+				w.u32(commandError)
+				w.u32(tag)
+				w.u32(5)
+			}
+		case commandSubscribe:
+			r.u32()
+			s.mutex.Lock()
+			s.subscribed[conn] = true
+			s.mutex.Unlock()
+		default:
+			w = &tagWriter{}
+			w.u32(commandError)
+			w.u32(tag)
+			w.u32(2)
+		}
+		if r.err != nil {
+			s.t.Errorf("fake server: %v", r.err)
+			return
+		}
+		writePacketTo(conn, w)
+
+		if changed {
+			s.broadcast(FacilitySink | EventChange)
+		}
+	}
+}
+
+// broadcast sendet ein Änderungsereignis an alle abonnierten Verbindungen
+func (s *fakeServer) broadcast(eventType uint32) {
+	w := &tagWriter{}
+	w.u32(commandSubscribeEvent)
+	w.u32(commandChannel)
+	w.u32(eventType)
+	w.u32(0)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.subscribed {
+		writePacketTo(conn, w)
+	}
+}
+
+// writePacketTo sendet ein Paket auf dem Befehlskanal (net.Conn serialisiert gleichzeitige Writes)
+func writePacketTo(conn net.Conn, w *tagWriter) {
+	header := make([]byte, headerSize)
+	binary.BigEndian.PutUint32(header[0:], uint32(len(w.buf)))
+	binary.BigEndian.PutUint32(header[4:], commandChannel)
+	conn.Write(append(header, w.buf...))
+}
+
+// writeSink kodiert den simulierten Sink im Layout von Protokollversion 32
+func (s *fakeServer) writeSink(w *tagWriter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	w.u32(0)
+	w.str("test_sink")
+	w.str("Test Sink")
+	w.sampleSpec(3, 2, 48000)
+	w.channelMap([]uint8{1, 2})
+	w.u32(InvalidIndex)
+	w.cvolume(s.volume)
+	w.boolean(s.muted)
+	w.u32(1)
+	w.str("test_sink.monitor")
+	w.usec(0)
+	w.str("module-null-sink.c")
+	w.u32(0)
+	w.proplist(map[string]string{"device.description": "Test Sink"})
+	w.usec(0)
+	w.volume(VolumeNorm)
+	w.u32(0)
+	w.u32(65537)
+	w.u32(InvalidIndex)
+	w.u32(1)
+	w.str("analog-output")
+	w.str("Analog Output")
+	w.u32(100)
+	w.u32(0)
+	w.str("analog-output")
+	w.u8(1)
+	w.buf = append(w.buf, tagFormatInfo)
+	w.u8(1)
+	w.proplist(nil)
+}
+
+// updateInput ändert Lautstärke (volume != nil) oder Stummschaltung eines Streams
+func (s *fakeServer) updateInput(index uint32, volume []uint32, muted bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, input := range s.inputs {
+		if input.Index == index {
+			if volume != nil {
+				input.Volume = volume
+			} else {
+				input.Muted = muted
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// moveInput verschiebt einen Stream; neben test_sink (0) kennt der Server nur headset_sink (1)
+func (s *fakeServer) moveInput(index uint32, sinkName string) bool {
+	sinks := map[string]uint32{"test_sink": 0, "headset_sink": 1}
+	sink, ok := sinks[sinkName]
+	if !ok {
+		return false
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, input := range s.inputs {
+		if input.Index == index {
+			input.Sink = sink
+			return true
+		}
+	}
+	return false
+}
+
+// writeSinkInputs kodiert die simulierten Streams im Layout von Protokollversion 32
+func (s *fakeServer) writeSinkInputs(w *tagWriter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, input := range s.inputs {
+		w.u32(input.Index)
+		w.str(input.Name)
+		w.u32(InvalidIndex)
+		w.u32(input.Index + 100)
+		w.u32(input.Sink)
+		w.sampleSpec(3, uint8(len(input.Volume)), 48000)
+		w.channelMap(make([]uint8, len(input.Volume)))
+		w.cvolume(input.Volume)
+		w.usec(0)
+		w.usec(0)
+		w.str("")
+		w.str("protocol-native.c")
+		w.boolean(input.Muted)
+		w.proplist(input.Properties)
+		w.boolean(input.Corked)
+		w.boolean(true)
+		w.boolean(true)
+		w.buf = append(w.buf, tagFormatInfo)
+		w.u8(1)
+		w.proplist(nil)
+	}
+}
+
+func dialFake(t *testing.T, s *fakeServer) *Client {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	client, err := Dial(ctx, s.path, "test")
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestSinkVolumeAndMute(t *testing.T) {
+	server := newFakeServer(t)
+	client := dialFake(t, server)
+	ctx := context.Background()
+
+	sink, err := client.Sink(ctx, "")
+	if err != nil {
+		t.Fatalf("Sink: %v", err)
+	}
+	if sink.Name != "test_sink" || sink.Percent() != 38 || sink.Muted {
+		t.Fatalf("unexpected sink: %+v (percent %d)", sink, sink.Percent())
+	}
+	if len(sink.Ports) != 1 || sink.ActivePort != "analog-output" || sink.Properties["device.description"] != "Test Sink" {
+		t.Fatalf("unexpected ports/properties: %+v", sink)
+	}
+
+	// Balance bleibt erhalten: der lautere Kanal landet auf dem Zielwert
+	volume := ScaleVolume(sink.Volume, 80)
+	if err := client.SetSinkVolume(ctx, sink.Name, volume); err != nil {
+		t.Fatalf("SetSinkVolume: %v", err)
+	}
+	if err := client.SetSinkMute(ctx, sink.Name, true); err != nil {
+		t.Fatalf("SetSinkMute: %v", err)
+	}
+
+	sink, err = client.Sink(ctx, "test_sink")
+	if err != nil {
+		t.Fatalf("Sink: %v", err)
+	}
+	if Percent(sink.Volume[:1]) != 80 || Percent(sink.Volume[1:]) != 40 || !sink.Muted {
+		t.Fatalf("volume/mute not applied: %v muted=%v", sink.Volume, sink.Muted)
+	}
+
+	sinks, err := client.Sinks(ctx)
+	if err != nil || len(sinks) != 1 {
+		t.Fatalf("Sinks: %v %v", sinks, err)
+	}
+
+	if _, err := client.Sink(ctx, "missing"); !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	server := newFakeServer(t)
+	watcher := dialFake(t, server)
+	client := dialFake(t, server)
+	ctx := context.Background()
+
+	events, err := watcher.Subscribe(ctx, SubscribeSink)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if err := client.SetSinkMute(ctx, "test_sink", true); err != nil {
+		t.Fatalf("SetSinkMute: %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Facility() != FacilitySink || event.Kind() != EventChange {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no event received")
+	}
+
+	watcher.Close()
+	if _, ok := <-events; ok {
+		t.Fatalf("event channel not closed")
+	}
+	if watcher.Err() == nil {
+		t.Fatalf("expected Err after Close")
+	}
+}
+
+func TestSinkInputs(t *testing.T) {
+	server := newFakeServer(t)
+	client := dialFake(t, server)
+	ctx := context.Background()
+
+	inputs, err := client.SinkInputs(ctx)
+	if err != nil || len(inputs) != 2 {
+		t.Fatalf("SinkInputs: %v %v", inputs, err)
+	}
+	spotify := inputs[0]
+	if spotify.Application() != "Spotify" || spotify.Binary() != "spotify" || spotify.PID() != 4242 || spotify.Percent() != 100 {
+		t.Fatalf("unexpected stream: %+v", spotify)
+	}
+	if !inputs[1].Corked || inputs[1].Percent() != 50 {
+		t.Fatalf("unexpected stream: %+v", inputs[1])
+	}
+
+	if err := client.SetSinkInputVolume(ctx, 7, ScaleVolume(spotify.Volume, 30)); err != nil {
+		t.Fatalf("SetSinkInputVolume: %v", err)
+	}
+	if err := client.SetSinkInputMute(ctx, 9, true); err != nil {
+		t.Fatalf("SetSinkInputMute: %v", err)
+	}
+	inputs, _ = client.SinkInputs(ctx)
+	if inputs[0].Percent() != 30 || !inputs[1].Muted {
+		t.Fatalf("stream changes not applied: %+v %+v", inputs[0], inputs[1])
+	}
+
+	// Ein inzwischen beendeter Stream meldet "nicht gefunden"
+	if err := client.SetSinkInputMute(ctx, 99, true); !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	if err := client.MoveSinkInput(ctx, 7, "headset_sink"); err != nil {
+		t.Fatalf("MoveSinkInput: %v", err)
+	}
+	inputs, _ = client.SinkInputs(ctx)
+	if inputs[0].Sink != 1 || inputs[1].Sink != 0 {
+		t.Fatalf("stream not moved: %+v %+v", inputs[0], inputs[1])
+	}
+	if err := client.MoveSinkInput(ctx, 7, "missing_sink"); !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
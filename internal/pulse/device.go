@@ -0,0 +1,395 @@
+// Package pulse implementiert einen minimalen Client für das native PulseAudio-Protokoll.
+// Diese Datei enthält die Abfragen und Befehle für Sinks und Sources.
+
+package pulse
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// VolumeNorm entspricht 100% Lautstärke
+const VolumeNorm = 0x10000
+
+// Facilities und Ereignistypen für Abonnements
+const (
+	SubscribeSink      = 0x0001
+	SubscribeSource    = 0x0002
+	SubscribeSinkInput = 0x0004
+	SubscribeServer    = 0x0080
+	SubscribeCard      = 0x0200
+
+	FacilitySink      = 0x00
+	FacilitySource    = 0x01
+	FacilitySinkInput = 0x02
+	FacilityServer    = 0x07
+	FacilityCard      = 0x09
+
+	EventNew    = 0x00
+	EventChange = 0x10
+	EventRemove = 0x20
+
+	facilityMask  = 0x0F
+	eventTypeMask = 0x30
+)
+
+// Event ist eine Änderungsmeldung des Servers
+type Event struct {
+	Type  uint32
+	Index uint32
+}
+
+// Facility gibt die betroffene Objektart zurück (FacilitySink, FacilitySource, ...)
+func (e Event) Facility() uint32 {
+	return e.Type & facilityMask
+}
+
+// Kind gibt die Art der Änderung zurück (EventNew, EventChange, EventRemove)
+func (e Event) Kind() uint32 {
+	return e.Type & eventTypeMask
+}
+
+// ServerInfo enthält die Grunddaten des Servers
+type ServerInfo struct {
+	PackageName    string
+	PackageVersion string
+	DefaultSink    string
+	DefaultSource  string
+}
+
+// Verfügbarkeit eines Ports
+const (
+	PortAvailableUnknown = 0
+	PortAvailableNo      = 1
+	PortAvailableYes     = 2
+)
+
+// Port ist ein Anschluss eines Geräts
+type Port struct {
+	Name        string
+	Description string
+	Priority    uint32
+	Available   uint32 // PortAvailableUnknown, PortAvailableNo oder PortAvailableYes
+}
+
+// Device beschreibt einen Sink (Ausgabe) oder eine Source (Eingabe)
+type Device struct {
+	Index       uint32
+	Name        string
+	Description string
+	Volume      []uint32 // pro Kanal, VolumeNorm = 100%
+	Muted       bool
+	Monitor     uint32 // Sink: dessen Monitor-Source; Source: überwachter Sink oder InvalidIndex
+	BaseVolume  uint32
+	State       uint32
+	Card        uint32
+	Properties  map[string]string
+	Ports       []Port
+	ActivePort  string
+}
+
+// IsMonitor gibt zurück, ob eine Source nur einen Sink mitschneidet (kein echtes Eingabegerät)
+func (d *Device) IsMonitor() bool {
+	return d.Monitor != InvalidIndex || d.Properties["device.class"] == "monitor"
+}
+
+// Percent gibt die mittlere Lautstärke des Geräts in Prozent zurück
+func (d *Device) Percent() int {
+	return Percent(d.Volume)
+}
+
+// Percent berechnet die mittlere Lautstärke mehrerer Kanäle in Prozent
+func Percent(channels []uint32) int {
+	if len(channels) == 0 {
+		return 0
+	}
+	var sum uint64
+	for _, v := range channels {
+		sum += uint64(v)
+	}
+	avg := float64(sum) / float64(len(channels))
+	return int(math.Round(avg * 100 / VolumeNorm))
+}
+
+// ScaleVolume setzt die mittlere Lautstärke auf percent und behält die Balance der Kanäle bei
+func ScaleVolume(channels []uint32, percent int) []uint32 {
+	if percent < 0 {
+		percent = 0
+	}
+	target := float64(percent) * VolumeNorm / 100
+
+	result := make([]uint32, len(channels))
+	var maxValue uint32
+	for _, v := range channels {
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+	for i, v := range channels {
+		if maxValue == 0 {
+			result[i] = uint32(math.Round(target))
+			continue
+		}
+		result[i] = uint32(math.Round(target * float64(v) / float64(maxValue)))
+	}
+	return result
+}
+
+// ServerInfo fragt die Grunddaten des Servers ab
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	r, err := c.request(ctx, commandGetServerInfo, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Abfragen der Server-Informationen: %w", err)
+	}
+
+	info := &ServerInfo{}
+	info.PackageName = r.str()
+	info.PackageVersion = r.str()
+	r.str() // Benutzer
+	r.str() // Host
+	r.sampleSpec()
+	info.DefaultSink = r.str()
+	info.DefaultSource = r.str()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return info, nil
+}
+
+// Sink fragt einen Sink ab; ein leerer Name bezeichnet den Standard-Sink
+func (c *Client) Sink(ctx context.Context, name string) (*Device, error) {
+	return c.device(ctx, commandGetSinkInfo, name, true)
+}
+
+// Source fragt eine Source ab; ein leerer Name bezeichnet die Standard-Source
+func (c *Client) Source(ctx context.Context, name string) (*Device, error) {
+	return c.device(ctx, commandGetSourceInfo, name, false)
+}
+
+// Sinks listet alle Sinks auf
+func (c *Client) Sinks(ctx context.Context) ([]*Device, error) {
+	return c.devices(ctx, commandGetSinkInfoList, true)
+}
+
+// Sources listet alle Sources auf (inklusive Monitor-Sources)
+func (c *Client) Sources(ctx context.Context) ([]*Device, error) {
+	return c.devices(ctx, commandGetSourceInfoList, false)
+}
+
+// SetSinkVolume setzt die Lautstärke eines Sinks pro Kanal
+func (c *Client) SetSinkVolume(ctx context.Context, name string, volume []uint32) error {
+	return c.setVolume(ctx, commandSetSinkVolume, name, volume)
+}
+
+// SetSourceVolume setzt die Lautstärke einer Source pro Kanal
+func (c *Client) SetSourceVolume(ctx context.Context, name string, volume []uint32) error {
+	return c.setVolume(ctx, commandSetSourceVolume, name, volume)
+}
+
+// SetSinkMute schaltet einen Sink stumm oder wieder laut
+func (c *Client) SetSinkMute(ctx context.Context, name string, muted bool) error {
+	return c.setMute(ctx, commandSetSinkMute, name, muted)
+}
+
+// SetSourceMute schaltet eine Source stumm oder wieder laut
+func (c *Client) SetSourceMute(ctx context.Context, name string, muted bool) error {
+	return c.setMute(ctx, commandSetSourceMute, name, muted)
+}
+
+// SetDefaultSink setzt den Standard-Sink
+func (c *Client) SetDefaultSink(ctx context.Context, name string) error {
+	return c.setDefault(ctx, commandSetDefaultSink, name)
+}
+
+// SetDefaultSource setzt die Standard-Source
+func (c *Client) SetDefaultSource(ctx context.Context, name string) error {
+	return c.setDefault(ctx, commandSetDefaultSource, name)
+}
+
+// Subscribe abonniert Änderungen der Objektarten in mask (SubscribeSink, ...).
+// Der Kanal wird geschlossen, wenn die Verbindung endet; Ereignisse werden verworfen, wenn er voll ist.
+func (c *Client) Subscribe(ctx context.Context, mask uint32) (<-chan Event, error) {
+	ch := make(chan Event, 32)
+
+	c.mutex.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mutex.Unlock()
+		return nil, err
+	}
+	c.subscribers = append(c.subscribers, ch)
+	c.mutex.Unlock()
+
+	if _, err := c.request(ctx, commandSubscribe, func(w *tagWriter) {
+		w.u32(mask)
+	}); err != nil {
+		c.unsubscribe(ch)
+		return nil, fmt.Errorf("fehler beim Abonnieren von Änderungen: %w", err)
+	}
+	return ch, nil
+}
+
+// unsubscribe entfernt einen Abonnenten wieder
+func (c *Client) unsubscribe(ch chan Event) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, sub := range c.subscribers {
+		if sub == ch {
+			c.subscribers = append(c.subscribers[:i], c.subscribers[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+// publish verteilt ein Ereignis an alle Abonnenten, ohne zu blockieren
+func (c *Client) publish(event Event) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, ch := range c.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// device fragt ein einzelnes Gerät über seinen Namen ab
+func (c *Client) device(ctx context.Context, command uint32, name string, sink bool) (*Device, error) {
+	if name == "" {
+		info, err := c.ServerInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		name = info.DefaultSink
+		if !sink {
+			name = info.DefaultSource
+		}
+		if name == "" {
+			return nil, fmt.Errorf("kein Standardgerät vorhanden")
+		}
+	}
+
+	r, err := c.request(ctx, command, func(w *tagWriter) {
+		w.u32(InvalidIndex)
+		w.str(name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Abfragen von Gerät '%s': %w", name, err)
+	}
+	return c.readDevice(r)
+}
+
+// devices fragt eine Geräteliste ab
+func (c *Client) devices(ctx context.Context, command uint32, sink bool) ([]*Device, error) {
+	r, err := c.request(ctx, command, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Abfragen der Geräteliste: %w", err)
+	}
+
+	var devices []*Device
+	for r.pos < len(r.data) {
+		device, err := c.readDevice(r)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// readDevice dekodiert Sink- bzw. Source-Informationen; beide haben dasselbe Layout
+func (c *Client) readDevice(r *tagReader) (*Device, error) {
+	d := &Device{}
+	d.Index = r.u32()
+	d.Name = r.str()
+	d.Description = r.str()
+	r.sampleSpec()
+	r.channelMap()
+	r.u32() // Owner-Modul
+	d.Volume = r.cvolume()
+	d.Muted = r.boolean()
+	d.Monitor = r.u32()
+	r.str()  // Name der Monitor-Source bzw. des überwachten Sinks
+	r.usec() // Latenz
+	r.str()  // Treiber
+	r.u32()  // Flags
+
+	if c.version >= 13 {
+		d.Properties = r.proplist()
+		r.usec() // konfigurierte Latenz
+	}
+	if c.version >= 15 {
+		d.BaseVolume = r.volume()
+		d.State = r.u32()
+		r.u32() // Anzahl Lautstärkestufen
+		d.Card = r.u32()
+	}
+	if c.version >= 16 {
+		n := r.u32()
+		for i := uint32(0); i < n && r.err == nil; i++ {
+			port := Port{
+				Name:        r.str(),
+				Description: r.str(),
+				Priority:    r.u32(),
+			}
+			if c.version >= 24 {
+				port.Available = r.u32()
+			}
+			d.Ports = append(d.Ports, port)
+		}
+		d.ActivePort = r.str()
+	}
+	if c.version >= 21 {
+		n := r.u8()
+		for i := uint8(0); i < n && r.err == nil; i++ {
+			r.formatInfo()
+		}
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return d, nil
+}
+
+// setVolume sendet einen Lautstärke-Befehl für ein Gerät
+func (c *Client) setVolume(ctx context.Context, command uint32, name string, volume []uint32) error {
+	if len(volume) == 0 {
+		return fmt.Errorf("keine Kanäle angegeben")
+	}
+	_, err := c.request(ctx, command, func(w *tagWriter) {
+		w.u32(InvalidIndex)
+		w.str(name)
+		w.cvolume(volume)
+	})
+	if err != nil {
+		return fmt.Errorf("fehler beim Setzen der Lautstärke von '%s': %w", name, err)
+	}
+	return nil
+}
+
+// setMute sendet einen Stummschalt-Befehl für ein Gerät
+func (c *Client) setMute(ctx context.Context, command uint32, name string, muted bool) error {
+	_, err := c.request(ctx, command, func(w *tagWriter) {
+		w.u32(InvalidIndex)
+		w.str(name)
+		w.boolean(muted)
+	})
+	if err != nil {
+		return fmt.Errorf("fehler beim Stummschalten von '%s': %w", name, err)
+	}
+	return nil
+}
+
+// setDefault setzt das Standardgerät
+func (c *Client) setDefault(ctx context.Context, command uint32, name string) error {
+	_, err := c.request(ctx, command, func(w *tagWriter) {
+		w.str(name)
+	})
+	if err != nil {
+		return fmt.Errorf("fehler beim Setzen des Standardgeräts '%s': %w", name, err)
+	}
+	return nil
+}
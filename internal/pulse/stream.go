@@ -0,0 +1,140 @@
+// Package pulse implementiert einen minimalen Client für das native PulseAudio-Protokoll.
+// Diese Datei enthält die Abfragen und Befehle für Wiedergabe-Streams (Sink-Inputs).
+
+package pulse
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// SinkInput ist ein Wiedergabe-Stream einer Anwendung
+type SinkInput struct {
+	Index      uint32
+	Name       string
+	Client     uint32
+	Sink       uint32
+	Volume     []uint32 // pro Kanal, VolumeNorm = 100%
+	Muted      bool
+	Corked     bool // pausiert
+	HasVolume  bool
+	Properties map[string]string
+}
+
+// Percent gibt die mittlere Lautstärke des Streams in Prozent zurück
+func (s *SinkInput) Percent() int {
+	return Percent(s.Volume)
+}
+
+// Application gibt den Anwendungsnamen zurück (application.name)
+func (s *SinkInput) Application() string {
+	return s.Properties["application.name"]
+}
+
+// Binary gibt den Programmnamen zurück (application.process.binary)
+func (s *SinkInput) Binary() string {
+	return s.Properties["application.process.binary"]
+}
+
+// PID gibt die Prozess-ID zurück (0 wenn unbekannt)
+func (s *SinkInput) PID() int {
+	pid, _ := strconv.Atoi(s.Properties["application.process.id"])
+	return pid
+}
+
+// SinkInputs listet alle Wiedergabe-Streams auf
+func (c *Client) SinkInputs(ctx context.Context) ([]*SinkInput, error) {
+	r, err := c.request(ctx, commandGetSinkInputInfoList, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Abfragen der Streams: %w", err)
+	}
+
+	var inputs []*SinkInput
+	for r.pos < len(r.data) {
+		input, err := c.readSinkInput(r)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+// SetSinkInputVolume setzt die Lautstärke eines Streams pro Kanal
+func (c *Client) SetSinkInputVolume(ctx context.Context, index uint32, volume []uint32) error {
+	if len(volume) == 0 {
+		return fmt.Errorf("keine Kanäle angegeben")
+	}
+	_, err := c.request(ctx, commandSetSinkInputVolume, func(w *tagWriter) {
+		w.u32(index)
+		w.cvolume(volume)
+	})
+	if err != nil {
+		return fmt.Errorf("fehler beim Setzen der Lautstärke von Stream %d: %w", index, err)
+	}
+	return nil
+}
+
+// SetSinkInputMute schaltet einen Stream stumm oder wieder laut
+func (c *Client) SetSinkInputMute(ctx context.Context, index uint32, muted bool) error {
+	_, err := c.request(ctx, commandSetSinkInputMute, func(w *tagWriter) {
+		w.u32(index)
+		w.boolean(muted)
+	})
+	if err != nil {
+		return fmt.Errorf("fehler beim Stummschalten von Stream %d: %w", index, err)
+	}
+	return nil
+}
+
+// MoveSinkInput verschiebt einen Stream auf einen anderen Sink
+func (c *Client) MoveSinkInput(ctx context.Context, index uint32, sinkName string) error {
+	_, err := c.request(ctx, commandMoveSinkInput, func(w *tagWriter) {
+		w.u32(index)
+		w.u32(InvalidIndex)
+		w.str(sinkName)
+	})
+	if err != nil {
+		return fmt.Errorf("fehler beim Verschieben von Stream %d auf '%s': %w", index, sinkName, err)
+	}
+	return nil
+}
+
+// readSinkInput dekodiert die Informationen eines Streams
+func (c *Client) readSinkInput(r *tagReader) (*SinkInput, error) {
+	s := &SinkInput{HasVolume: true}
+	s.Index = r.u32()
+	s.Name = r.str()
+	r.u32() // Owner-Modul
+	s.Client = r.u32()
+	s.Sink = r.u32()
+	r.sampleSpec()
+	r.channelMap()
+	s.Volume = r.cvolume()
+	r.usec() // Puffer-Latenz
+	r.usec() // Sink-Latenz
+	r.str()  // Resampler
+	r.str()  // Treiber
+	if c.version >= 11 {
+		s.Muted = r.boolean()
+	}
+	if c.version >= 13 {
+		s.Properties = r.proplist()
+	}
+	if c.version >= 19 {
+		s.Corked = r.boolean()
+	}
+	if c.version >= 20 {
+		s.HasVolume = r.boolean()
+		r.boolean() // Lautstärke änderbar
+	}
+	if c.version >= 21 {
+		r.formatInfo()
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return s, nil
+}
@@ -0,0 +1,340 @@
+// Package pulse implementiert einen minimalen Client für das native PulseAudio-Protokoll.
+// Diese Datei enthält die Kodierung der Nutzdaten ("Tagstruct").
+
+package pulse
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Tags der Tagstruct-Werte
+const (
+	tagString     = 't'
+	tagStringNull = 'N'
+	tagU32        = 'L'
+	tagU8         = 'B'
+	tagU64        = 'R'
+	tagS64        = 'r'
+	tagSampleSpec = 'a'
+	tagArbitrary  = 'x'
+	tagBoolTrue   = '1'
+	tagBoolFalse  = '0'
+	tagTimeval    = 'T'
+	tagUsec       = 'U'
+	tagChannelMap = 'm'
+	tagCVolume    = 'v'
+	tagProplist   = 'P'
+	tagVolume     = 'V'
+	tagFormatInfo = 'f'
+)
+
+// maxPacketBytes begrenzt die Größe eines empfangenen Pakets
+const maxPacketBytes = 16 * 1024 * 1024
+
+// tagWriter kodiert Werte als Tagstruct
+type tagWriter struct {
+	buf []byte
+}
+
+func (w *tagWriter) u32(v uint32) {
+	w.buf = append(w.buf, tagU32)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+}
+
+func (w *tagWriter) u8(v uint8) {
+	w.buf = append(w.buf, tagU8, v)
+}
+
+func (w *tagWriter) u64(v uint64) {
+	w.buf = append(w.buf, tagU64)
+	w.buf = binary.BigEndian.AppendUint64(w.buf, v)
+}
+
+func (w *tagWriter) usec(v uint64) {
+	w.buf = append(w.buf, tagUsec)
+	w.buf = binary.BigEndian.AppendUint64(w.buf, v)
+}
+
+func (w *tagWriter) str(s string) {
+	w.buf = append(w.buf, tagString)
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, 0)
+}
+
+func (w *tagWriter) null() {
+	w.buf = append(w.buf, tagStringNull)
+}
+
+func (w *tagWriter) boolean(v bool) {
+	if v {
+		w.buf = append(w.buf, tagBoolTrue)
+	} else {
+		w.buf = append(w.buf, tagBoolFalse)
+	}
+}
+
+func (w *tagWriter) arbitrary(data []byte) {
+	w.buf = append(w.buf, tagArbitrary)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(len(data)))
+	w.buf = append(w.buf, data...)
+}
+
+func (w *tagWriter) volume(v uint32) {
+	w.buf = append(w.buf, tagVolume)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+}
+
+func (w *tagWriter) cvolume(channels []uint32) {
+	w.buf = append(w.buf, tagCVolume, uint8(len(channels)))
+	for _, v := range channels {
+		w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+	}
+}
+
+func (w *tagWriter) sampleSpec(format, channels uint8, rate uint32) {
+	w.buf = append(w.buf, tagSampleSpec, format, channels)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, rate)
+}
+
+func (w *tagWriter) channelMap(positions []uint8) {
+	w.buf = append(w.buf, tagChannelMap, uint8(len(positions)))
+	w.buf = append(w.buf, positions...)
+}
+
+// proplist kodiert eine Eigenschaftsliste; String-Werte werden wie bei PulseAudio mit Nullbyte abgelegt
+func (w *tagWriter) proplist(props map[string]string) {
+	w.buf = append(w.buf, tagProplist)
+	for key, value := range props {
+		data := append([]byte(value), 0)
+		w.str(key)
+		w.u32(uint32(len(data)))
+		w.arbitrary(data)
+	}
+	w.null()
+}
+
+// tagReader dekodiert eine Tagstruct. Der erste Fehler bleibt erhalten, alle weiteren Lesezugriffe liefern Nullwerte.
+type tagReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// fail merkt sich den ersten Dekodierfehler
+func (r *tagReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("ungültige Tagstruct an Position %d: %s", r.pos, fmt.Sprintf(format, args...))
+	}
+}
+
+// take gibt die nächsten n Bytes zurück
+func (r *tagReader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.fail("%d Bytes erwartet, nur %d vorhanden", n, len(r.data)-r.pos)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// expect liest ein Tag und prüft es
+func (r *tagReader) expect(tag byte) bool {
+	b := r.take(1)
+	if b == nil {
+		return false
+	}
+	if b[0] != tag {
+		r.pos--
+		r.fail("tag '%c' erwartet, '%c' gefunden", tag, b[0])
+		return false
+	}
+	return true
+}
+
+func (r *tagReader) u32() uint32 {
+	if !r.expect(tagU32) {
+		return 0
+	}
+	if b := r.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *tagReader) u8() uint8 {
+	if !r.expect(tagU8) {
+		return 0
+	}
+	if b := r.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *tagReader) u64() uint64 {
+	if !r.expect(tagU64) {
+		return 0
+	}
+	if b := r.take(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *tagReader) usec() uint64 {
+	if !r.expect(tagUsec) {
+		return 0
+	}
+	if b := r.take(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *tagReader) volume() uint32 {
+	if !r.expect(tagVolume) {
+		return 0
+	}
+	if b := r.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+// str liest einen String; ein Null-String wird als "" zurückgegeben
+func (r *tagReader) str() string {
+	s, _ := r.strOrNull()
+	return s
+}
+
+// strOrNull liest einen String und meldet, ob es ein Null-String war
+func (r *tagReader) strOrNull() (string, bool) {
+	b := r.take(1)
+	if b == nil {
+		return "", false
+	}
+	switch b[0] {
+	case tagStringNull:
+		return "", false
+	case tagString:
+		for i := r.pos; i < len(r.data); i++ {
+			if r.data[i] == 0 {
+				s := string(r.data[r.pos:i])
+				r.pos = i + 1
+				return s, true
+			}
+		}
+		r.fail("string ohne Nullbyte")
+	default:
+		r.pos--
+		r.fail("string erwartet, '%c' gefunden", b[0])
+	}
+	return "", false
+}
+
+func (r *tagReader) boolean() bool {
+	b := r.take(1)
+	if b == nil {
+		return false
+	}
+	switch b[0] {
+	case tagBoolTrue:
+		return true
+	case tagBoolFalse:
+		return false
+	}
+	r.pos--
+	r.fail("boolean erwartet, '%c' gefunden", b[0])
+	return false
+}
+
+func (r *tagReader) arbitrary() []byte {
+	if !r.expect(tagArbitrary) {
+		return nil
+	}
+	b := r.take(4)
+	if b == nil {
+		return nil
+	}
+	return r.take(int(binary.BigEndian.Uint32(b)))
+}
+
+func (r *tagReader) cvolume() []uint32 {
+	if !r.expect(tagCVolume) {
+		return nil
+	}
+	n := r.take(1)
+	if n == nil {
+		return nil
+	}
+	channels := make([]uint32, n[0])
+	for i := range channels {
+		b := r.take(4)
+		if b == nil {
+			return nil
+		}
+		channels[i] = binary.BigEndian.Uint32(b)
+	}
+	return channels
+}
+
+// sampleSpec liest eine Sample-Spezifikation (Format, Kanäle, Rate)
+func (r *tagReader) sampleSpec() (format, channels uint8, rate uint32) {
+	if !r.expect(tagSampleSpec) {
+		return 0, 0, 0
+	}
+	b := r.take(6)
+	if b == nil {
+		return 0, 0, 0
+	}
+	return b[0], b[1], binary.BigEndian.Uint32(b[2:])
+}
+
+func (r *tagReader) channelMap() []uint8 {
+	if !r.expect(tagChannelMap) {
+		return nil
+	}
+	n := r.take(1)
+	if n == nil {
+		return nil
+	}
+	return append([]uint8(nil), r.take(int(n[0]))...)
+}
+
+// proplist liest eine Eigenschaftsliste; abschließende Nullbytes der Werte werden entfernt
+func (r *tagReader) proplist() map[string]string {
+	if !r.expect(tagProplist) {
+		return nil
+	}
+	props := make(map[string]string)
+	for r.err == nil {
+		key, ok := r.strOrNull()
+		if !ok {
+			break
+		}
+		length := r.u32()
+		data := r.arbitrary()
+		if r.err == nil && uint32(len(data)) != length {
+			r.fail("länge der Eigenschaft '%s' stimmt nicht", key)
+		}
+		if len(data) > 0 && data[len(data)-1] == 0 {
+			data = data[:len(data)-1]
+		}
+		props[key] = string(data)
+	}
+	return props
+}
+
+// formatInfo überspringt eine Format-Beschreibung (Kodierung und Eigenschaften)
+func (r *tagReader) formatInfo() {
+	if !r.expect(tagFormatInfo) {
+		return
+	}
+	r.u8()
+	r.proplist()
+}
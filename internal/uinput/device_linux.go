@@ -0,0 +1,277 @@
+//go:build linux
+
+package uinput
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ioctl-Nummern aus linux/uinput.h
+const (
+	uiDevCreate  = 0x5501
+	uiDevDestroy = 0x5502
+	uiDevSetup   = 0x405c5503 // _IOW('U', 3, struct uinput_setup)
+	uiAbsSetup   = 0x401c5504 // _IOW('U', 4, struct uinput_abs_setup)
+	uiSetEvBit   = 0x40045564 // _IOW('U', 100, int)
+	uiSetKeyBit  = 0x40045565 // _IOW('U', 101, int)
+	uiSetRelBit  = 0x40045566 // _IOW('U', 102, int)
+	uiSetAbsBit  = 0x40045567 // _IOW('U', 103, int)
+	uiSetPropBit = 0x4004556e // _IOW('U', 110, int)
+
+	busVirtual = 0x06
+)
+
+// timevalSize ist die Größe des Zeitstempels in struct input_event auf dieser Architektur
+const timevalSize = int(unsafe.Sizeof(syscall.Timeval{}))
+
+// Device ist ein virtuelles Eingabegerät
+type Device struct {
+	file  *os.File
+	mutex sync.Mutex
+
+	// zuletzt gesendete absolute Position (nur bei absoluten Geräten)
+	lastX, lastY int32
+	positioned   bool
+}
+
+// Open legt ein virtuelles Gerät mit dem angegebenen Namen an, das alle Tasten aus keyRanges
+// sowie Zeigerbewegungen und Mausrad senden kann
+func Open(name string) (*Device, error) {
+	return open(name, setupRelative)
+}
+
+// OpenAbsolute legt ein virtuelles Zeigegerät mit absoluten Koordinaten an. Der Compositor bildet
+// den Bereich 0..width-1 bzw. 0..height-1 auf den gesamten Desktop ab, daher müssen width und
+// height der Desktopgröße in Pixeln entsprechen.
+func OpenAbsolute(name string, width, height int32) (*Device, error) {
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("ungültige Desktopgröße %dx%d", width, height)
+	}
+	return open(name, func(fd uintptr) error {
+		return setupAbsolute(fd, width, height)
+	})
+}
+
+// open öffnet /dev/uinput, meldet über capabilities die Fähigkeiten an und erzeugt das Gerät
+func open(name string, capabilities func(fd uintptr) error) (*Device, error) {
+	file, err := os.OpenFile(Path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		switch {
+		case errors.Is(err, os.ErrPermission):
+			return nil, ErrPermission
+		case errors.Is(err, os.ErrNotExist):
+			return nil, ErrUnavailable
+		}
+		return nil, fmt.Errorf("fehler beim Öffnen von %s: %w", Path, err)
+	}
+
+	if err := setup(file, name, capabilities); err != nil {
+		file.Close()
+		return nil, err
+	}
+	time.Sleep(settleDelay)
+	return &Device{file: file}, nil
+}
+
+// setup meldet die Fähigkeiten des Geräts an und erzeugt es
+func setup(file *os.File, name string, capabilities func(fd uintptr) error) error {
+	fd := file.Fd()
+	if err := capabilities(fd); err != nil {
+		return err
+	}
+
+	// struct uinput_setup: input_id (4 x u16), name[80], ff_effects_max (u32)
+	var config [92]byte
+	binary.LittleEndian.PutUint16(config[0:], busVirtual)
+	binary.LittleEndian.PutUint16(config[2:], 0x1209) // Vendor: pid.codes
+	binary.LittleEndian.PutUint16(config[4:], 0x0001)
+	binary.LittleEndian.PutUint16(config[6:], 1)
+	copy(config[8:87], name)
+	// Die Umwandlung des Zeigers muss direkt im Syscall-Aufruf stehen
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uiDevSetup, uintptr(unsafe.Pointer(&config[0]))); errno != 0 {
+		return fmt.Errorf("fehler beim Einrichten des Geräts: %w", errno)
+	}
+	if err := ioctl(fd, uiDevCreate, 0); err != nil {
+		return fmt.Errorf("fehler beim Anlegen des Geräts: %w", err)
+	}
+	return nil
+}
+
+// setupRelative meldet alle Tasten aus keyRanges, Zeigerbewegungen und das Mausrad an
+func setupRelative(fd uintptr) error {
+	if err := ioctl(fd, uiSetEvBit, evKey); err != nil {
+		return fmt.Errorf("fehler beim Anmelden der Tastenereignisse: %w", err)
+	}
+	for _, r := range keyRanges {
+		for code := r[0]; code <= r[1]; code++ {
+			if err := ioctl(fd, uiSetKeyBit, code); err != nil {
+				return fmt.Errorf("fehler beim Anmelden der Taste %d: %w", code, err)
+			}
+		}
+	}
+
+	if err := ioctl(fd, uiSetEvBit, evRel); err != nil {
+		return fmt.Errorf("fehler beim Anmelden der Zeigerereignisse: %w", err)
+	}
+	for _, axis := range relAxes {
+		if err := ioctl(fd, uiSetRelBit, axis); err != nil {
+			return fmt.Errorf("fehler beim Anmelden der Achse %d: %w", axis, err)
+		}
+	}
+	return nil
+}
+
+// setupAbsolute meldet die absoluten Achsen X und Y mit dem Bereich der Desktopgröße an. Die linke
+// Maustaste wird nur angemeldet, damit udev das Gerät als Maus (statt als Joystick) einordnet.
+func setupAbsolute(fd uintptr, width, height int32) error {
+	if err := ioctl(fd, uiSetEvBit, evKey); err != nil {
+		return fmt.Errorf("fehler beim Anmelden der Tastenereignisse: %w", err)
+	}
+	if err := ioctl(fd, uiSetKeyBit, btnLeft); err != nil {
+		return fmt.Errorf("fehler beim Anmelden der Taste %d: %w", btnLeft, err)
+	}
+	if err := ioctl(fd, uiSetPropBit, inputPropPointer); err != nil {
+		return fmt.Errorf("fehler beim Anmelden der Zeiger-Eigenschaft: %w", err)
+	}
+	if err := ioctl(fd, uiSetEvBit, evAbs); err != nil {
+		return fmt.Errorf("fehler beim Anmelden der absoluten Zeigerereignisse: %w", err)
+	}
+
+	for _, axis := range []struct {
+		code uint16
+		max  int32
+	}{{absX, width - 1}, {absY, height - 1}} {
+		if err := ioctl(fd, uiSetAbsBit, uintptr(axis.code)); err != nil {
+			return fmt.Errorf("fehler beim Anmelden der Achse %d: %w", axis.code, err)
+		}
+		// struct uinput_abs_setup: code (u16), Füllbytes, input_absinfo (value, minimum, maximum,
+		// fuzz, flat, resolution als s32)
+		var config [28]byte
+		binary.NativeEndian.PutUint16(config[0:], axis.code)
+		binary.NativeEndian.PutUint32(config[12:], uint32(axis.max))
+		// Die Umwandlung des Zeigers muss direkt im Syscall-Aufruf stehen
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uiAbsSetup, uintptr(unsafe.Pointer(&config[0]))); errno != 0 {
+			return fmt.Errorf("fehler beim Einrichten der Achse %d: %w", axis.code, errno)
+		}
+	}
+	return nil
+}
+
+// Key drückt (press) bzw. löst eine Taste und schließt das Ereignis mit SYN_REPORT ab
+func (d *Device) Key(code uint16, press bool) error {
+	value := int32(0)
+	if press {
+		value = 1
+	}
+	if err := d.write(encodeEvent(evKey, code, value)); err != nil {
+		return fmt.Errorf("fehler beim Senden der Taste %d: %w", code, err)
+	}
+	return nil
+}
+
+// Move bewegt den Zeiger relativ um dx/dy
+func (d *Device) Move(dx, dy int32) error {
+	if err := d.write(relEvents(relX, dx, relY, dy)...); err != nil {
+		return fmt.Errorf("fehler beim Bewegen des Zeigers: %w", err)
+	}
+	return nil
+}
+
+// MoveTo setzt den Zeiger eines absoluten Geräts auf x/y. Der Kernel verwirft absolute Werte,
+// die sich nicht geändert haben; steht der Zeiger inzwischen woanders, würde eine Wiederholung
+// derselben Position daher ignoriert. In diesem Fall wird zuerst ein benachbarter Punkt gesendet.
+func (d *Device) MoveTo(x, y int32) error {
+	d.mutex.Lock()
+	repeated := d.positioned && d.lastX == x && d.lastY == y
+	d.mutex.Unlock()
+
+	if repeated {
+		nudge := int32(1)
+		if x > 0 {
+			nudge = -1
+		}
+		if err := d.write(encodeEvent(evAbs, absX, x+nudge)); err != nil {
+			return fmt.Errorf("fehler beim Setzen des Zeigers: %w", err)
+		}
+	}
+	if err := d.write(encodeEvent(evAbs, absX, x), encodeEvent(evAbs, absY, y)); err != nil {
+		return fmt.Errorf("fehler beim Setzen des Zeigers: %w", err)
+	}
+
+	d.mutex.Lock()
+	d.lastX, d.lastY, d.positioned = x, y, true
+	d.mutex.Unlock()
+	return nil
+}
+
+// Scroll dreht das Mausrad um vertical (positiv = nach oben) bzw. horizontal (positiv = nach rechts) Rasten
+func (d *Device) Scroll(vertical, horizontal int32) error {
+	if err := d.write(relEvents(relWheel, vertical, relHWheel, horizontal)...); err != nil {
+		return fmt.Errorf("fehler beim Scrollen: %w", err)
+	}
+	return nil
+}
+
+// relEvents kodiert die Achsen mit einem Wert ungleich 0 (Paare aus Achse und Wert)
+func relEvents(pairs ...int32) [][]byte {
+	var events [][]byte
+	for i := 0; i < len(pairs); i += 2 {
+		if pairs[i+1] != 0 {
+			events = append(events, encodeEvent(evRel, uint16(pairs[i]), pairs[i+1]))
+		}
+	}
+	return events
+}
+
+// write sendet die Ereignisse als ein Paket, abgeschlossen mit SYN_REPORT
+func (d *Device) write(events ...[]byte) error {
+	var buf []byte
+	for _, event := range events {
+		buf = append(buf, event...)
+	}
+	buf = append(buf, encodeEvent(evSyn, synReport, 0)...)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.file == nil {
+		return fmt.Errorf("uinput-gerät geschlossen")
+	}
+	_, err := d.file.Write(buf)
+	return err
+}
+
+// Close entfernt das virtuelle Gerät
+func (d *Device) Close() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.file == nil {
+		return nil
+	}
+	ioctl(d.file.Fd(), uiDevDestroy, 0)
+	err := d.file.Close()
+	d.file = nil
+	return err
+}
+
+// encodeEvent kodiert ein struct input_event. Der Zeitstempel bleibt leer, der Kernel setzt ihn.
+func encodeEvent(eventType, code uint16, value int32) []byte {
+	buf := make([]byte, timevalSize+8)
+	binary.NativeEndian.PutUint16(buf[timevalSize:], eventType)
+	binary.NativeEndian.PutUint16(buf[timevalSize+2:], code)
+	binary.NativeEndian.PutUint32(buf[timevalSize+4:], uint32(value))
+	return buf
+}
+
+func ioctl(fd, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
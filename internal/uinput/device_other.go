@@ -0,0 +1,43 @@
+//go:build !linux
+
+package uinput
+
+import "fmt"
+
+// Device ist ein virtuelles Eingabegerät (nur unter Linux verfügbar)
+type Device struct{}
+
+// Open meldet, dass uinput auf dieser Plattform nicht existiert
+func Open(name string) (*Device, error) {
+	return nil, fmt.Errorf("uinput wird nur unter Linux unterstützt")
+}
+
+// OpenAbsolute meldet, dass uinput auf dieser Plattform nicht existiert
+func OpenAbsolute(name string, width, height int32) (*Device, error) {
+	return nil, fmt.Errorf("uinput wird nur unter Linux unterstützt")
+}
+
+// Key ist ohne Gerät wirkungslos
+func (d *Device) Key(code uint16, press bool) error {
+	return fmt.Errorf("uinput wird nur unter Linux unterstützt")
+}
+
+// Move ist ohne Gerät wirkungslos
+func (d *Device) Move(dx, dy int32) error {
+	return fmt.Errorf("uinput wird nur unter Linux unterstützt")
+}
+
+// MoveTo ist ohne Gerät wirkungslos
+func (d *Device) MoveTo(x, y int32) error {
+	return fmt.Errorf("uinput wird nur unter Linux unterstützt")
+}
+
+// Scroll ist ohne Gerät wirkungslos
+func (d *Device) Scroll(vertical, horizontal int32) error {
+	return fmt.Errorf("uinput wird nur unter Linux unterstützt")
+}
+
+// Close ist ohne Gerät wirkungslos
+func (d *Device) Close() error {
+	return nil
+}
@@ -0,0 +1,58 @@
+// Package uinput erzeugt ein virtuelles Eingabegerät über /dev/uinput.
+// Die Ereignisse gelangen über den Kernel an alle Programme, die Eingabegeräte lesen – unter
+// Wayland-Compositoren ebenso wie auf der Konsole, wo X11-Eingaben nicht ankommen.
+package uinput
+
+import (
+	"errors"
+	"time"
+)
+
+// Path ist der Standardpfad der uinput-Schnittstelle
+const Path = "/dev/uinput"
+
+// Ereignistypen und -codes aus linux/input-event-codes.h
+const (
+	evSyn = 0x00
+	evKey = 0x01
+	evRel = 0x02
+	evAbs = 0x03
+
+	synReport = 0
+
+	relX      = 0x00
+	relY      = 0x01
+	relHWheel = 0x06
+	relWheel  = 0x08
+
+	absX = 0x00
+	absY = 0x01
+
+	btnLeft = 0x110
+
+	inputPropPointer = 0x00
+)
+
+// relAxes sind die relativen Achsen des Geräts: Zeigerbewegung und Mausrad
+var relAxes = []uintptr{relX, relY, relHWheel, relWheel}
+
+// keyRanges sind die Tastencodes, die das virtuelle Gerät anmeldet: Tastatur, Maustasten und
+// erweiterte Tasten. Joystick-, Gamepad- und Touch-Codes fehlen bewusst, da libinput das Gerät
+// sonst als Joystick bzw. Touchscreen einordnet.
+var keyRanges = [][2]uintptr{
+	{1, 0xff},      // KEY_ESC bis KEY_MICMUTE und Reserve
+	{0x110, 0x117}, // BTN_LEFT bis BTN_TASK
+	{0x160, 0x2bf}, // KEY_OK bis KEY_KBD_LCD_MENU5
+}
+
+// settleDelay ist die Wartezeit nach dem Anlegen des Geräts, bis Compositor und libinput es erkannt haben.
+// Ereignisse davor gehen verloren.
+const settleDelay = 200 * time.Millisecond
+
+// ErrPermission wird zurückgegeben, wenn /dev/uinput nicht geöffnet werden darf
+var ErrPermission = errors.New("keine Berechtigung für " + Path +
+	": den Benutzer z.B. zur Gruppe \"input\" hinzufügen und eine udev-Regel wie " +
+	"KERNEL==\"uinput\", GROUP=\"input\", MODE=\"0660\" anlegen")
+
+// ErrUnavailable wird zurückgegeben, wenn der Kernel kein uinput anbietet
+var ErrUnavailable = errors.New(Path + " nicht vorhanden: das Kernel-Modul mit \"modprobe uinput\" laden")
@@ -0,0 +1,106 @@
+package x11
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Adressfamilien in der Xauthority-Datei
+const (
+	familyInternet = 0
+	familyLocal    = 256
+	familyWild     = 65535
+)
+
+// cookieAuthName ist das einzige unterstützte Authentifizierungsverfahren
+const cookieAuthName = "MIT-MAGIC-COOKIE-1"
+
+// authEntry ist ein Eintrag der Xauthority-Datei
+type authEntry struct {
+	family  uint16
+	address string
+	number  string
+	name    string
+	data    []byte
+}
+
+// lookupAuth sucht das Cookie für ein Display in $XAUTHORITY bzw. ~/.Xauthority.
+// Ohne passenden Eintrag wird ohne Authentifizierung verbunden (z.B. bei Xvfb ohne -auth).
+func lookupAuth(d Display) (string, []byte) {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", nil
+	}
+	defer file.Close()
+
+	entries, _ := readAuthEntries(file)
+	hostname, _ := os.Hostname()
+	return matchAuth(entries, d, hostname)
+}
+
+// matchAuth wählt den passenden Eintrag für ein Display
+func matchAuth(entries []authEntry, d Display, hostname string) (string, []byte) {
+	number := strconv.Itoa(d.Number)
+	local := d.Host == "" || d.Host == "localhost" || d.Host == hostname
+	// Einträge für TCP-Verbindungen enthalten die IPv4-Adresse als Bytes
+	var address string
+	if ip := net.ParseIP(d.Host).To4(); ip != nil {
+		address = string(ip)
+	}
+	for _, entry := range entries {
+		if entry.name != cookieAuthName || (entry.number != "" && entry.number != number) {
+			continue
+		}
+		switch {
+		case entry.family == familyWild,
+			entry.family == familyLocal && local && entry.address == hostname,
+			entry.family == familyInternet && address != "" && entry.address == address:
+			return entry.name, entry.data
+		}
+	}
+	return "", nil
+}
+
+// readAuthEntries liest alle Einträge einer Xauthority-Datei (Big-Endian-Längenfelder)
+func readAuthEntries(r io.Reader) ([]authEntry, error) {
+	var entries []authEntry
+	for {
+		var family uint16
+		if err := binary.Read(r, binary.BigEndian, &family); err != nil {
+			if err == io.EOF {
+				return entries, nil
+			}
+			return entries, err
+		}
+		fields := make([][]byte, 4)
+		for i := range fields {
+			var length uint16
+			if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+				return entries, err
+			}
+			fields[i] = make([]byte, length)
+			if _, err := io.ReadFull(r, fields[i]); err != nil {
+				return entries, err
+			}
+		}
+		entries = append(entries, authEntry{
+			family:  family,
+			address: string(fields[0]),
+			number:  string(fields[1]),
+			name:    string(fields[2]),
+			data:    fields[3],
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package x11
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// writer kodiert Anfragen in Little-Endian-Byte-Reihenfolge
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) u8(v uint8) {
+	w.buf = append(w.buf, v)
+}
+
+func (w *writer) u16(v uint16) {
+	w.buf = binary.LittleEndian.AppendUint16(w.buf, v)
+}
+
+func (w *writer) u32(v uint32) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+}
+
+func (w *writer) pad(n int) {
+	w.buf = append(w.buf, make([]byte, n)...)
+}
+
+// bytes hängt Daten an und füllt auf ein Vielfaches von 4 auf
+func (w *writer) bytes(data []byte) {
+	w.buf = append(w.buf, data...)
+	w.pad(pad4(len(data)) - len(data))
+}
+
+// reader dekodiert Antworten; der erste Fehler bleibt in err stehen
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.pos+n > len(r.data) {
+		r.err = fmt.Errorf("unerwartetes Ende der Daten bei Byte %d", r.pos)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) skip(n int) {
+	r.take(n)
+}
+
+func (r *reader) u8() uint8 {
+	if b := r.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) u16() uint16 {
+	if b := r.take(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) u32() uint32 {
+	if b := r.take(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
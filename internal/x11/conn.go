@@ -0,0 +1,445 @@
+// Package x11 implementiert einen minimalen Client für das X11-Protokoll.
+// Der Client verbindet sich direkt über den Socket des X-Servers (ohne Xlib) und unterstützt
+// Tastaturbelegung und simulierte Eingaben über die XTEST-Erweiterung.
+package x11
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Protokollkonstanten
+const (
+	protocolMajor = 11
+	protocolMinor = 0
+
+	// ioTimeout begrenzt Anfragen ohne Deadline im Context, damit ein hängender Server nicht blockiert
+	ioTimeout = 5 * time.Second
+
+	// maxReplyBytes begrenzt die Größe einer Antwort
+	maxReplyBytes = 16 << 20
+)
+
+// Opcodes der Kern-Anfragen
+const (
+	opcodeQueryPointer          = 38
+	opcodeGetInputFocus         = 43
+	opcodeQueryKeymap           = 44
+	opcodeQueryExtension        = 98
+	opcodeChangeKeyboardMapping = 100
+	opcodeGetKeyboardMapping    = 101
+)
+
+// ErrClosed wird zurückgegeben, wenn die Verbindung geschlossen wurde
+var ErrClosed = errors.New("x11-verbindung geschlossen")
+
+// errorNames enthält die Namen der X11-Fehlercodes
+var errorNames = map[uint8]string{
+	1:  "BadRequest",
+	2:  "BadValue",
+	3:  "BadWindow",
+	4:  "BadPixmap",
+	5:  "BadAtom",
+	6:  "BadCursor",
+	7:  "BadFont",
+	8:  "BadMatch",
+	9:  "BadDrawable",
+	10: "BadAccess",
+	11: "BadAlloc",
+	12: "BadColormap",
+	13: "BadGContext",
+	14: "BadIDChoice",
+	15: "BadName",
+	16: "BadLength",
+	17: "BadImplementation",
+}
+
+// Error ist ein vom Server gemeldeter Fehler
+type Error struct {
+	Code     uint8
+	Major    uint8
+	Minor    uint16
+	Sequence uint16
+	Value    uint32
+}
+
+// Error gibt die Fehlermeldung zurück
+func (e *Error) Error() string {
+	name, ok := errorNames[e.Code]
+	if !ok {
+		name = fmt.Sprintf("fehler %d", e.Code)
+	}
+	return fmt.Sprintf("x11-fehler %s (Anfrage %d.%d, Wert %d)", name, e.Major, e.Minor, e.Value)
+}
+
+// Screen beschreibt einen Bildschirm des X-Servers
+type Screen struct {
+	Root   uint32
+	Width  uint16
+	Height uint16
+}
+
+// Conn ist eine Verbindung zu einem X-Server. Anfragen werden nacheinander abgearbeitet;
+// Fehler von Anfragen ohne Antwort meldet erst der nächste Sync.
+type Conn struct {
+	conn net.Conn
+
+	MinKeycode uint8
+	MaxKeycode uint8
+	Screens    []Screen
+
+	mutex    sync.Mutex
+	seq      uint16
+	asyncErr error
+	err      error
+
+	xtestOpcode uint8
+}
+
+// Display beschreibt eine Angabe wie ":0", "unix:1.0" oder "host:10"
+type Display struct {
+	Host   string
+	Number int
+	Screen int
+}
+
+// ParseDisplay zerlegt eine DISPLAY-Angabe
+func ParseDisplay(display string) (Display, error) {
+	if display == "" {
+		return Display{}, fmt.Errorf("DISPLAY ist nicht gesetzt")
+	}
+	colon := strings.LastIndex(display, ":")
+	if colon < 0 {
+		return Display{}, fmt.Errorf("ungültiges DISPLAY: %s", display)
+	}
+
+	host := display[:colon]
+	// Optionales Protokoll-Präfix ("unix/", "tcp/")
+	if slash := strings.LastIndex(host, "/"); slash >= 0 {
+		if host[:slash] == "unix" {
+			host = ""
+		} else {
+			host = host[slash+1:]
+		}
+	}
+	if host == "unix" {
+		host = ""
+	}
+
+	number, screen := display[colon+1:], "0"
+	if dot := strings.Index(number, "."); dot >= 0 {
+		number, screen = number[:dot], number[dot+1:]
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 0 {
+		return Display{}, fmt.Errorf("ungültiges DISPLAY: %s", display)
+	}
+	s, err := strconv.Atoi(screen)
+	if err != nil || s < 0 {
+		return Display{}, fmt.Errorf("ungültiges DISPLAY: %s", display)
+	}
+	return Display{Host: host, Number: n, Screen: s}, nil
+}
+
+// Dial verbindet sich mit dem X-Server aus display (leer = $DISPLAY) und initialisiert XTEST
+func Dial(ctx context.Context, display string) (*Conn, error) {
+	if display == "" {
+		display = os.Getenv("DISPLAY")
+	}
+	d, err := ParseDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+
+	var dialer net.Dialer
+	var conn net.Conn
+	if d.Host == "" {
+		path := fmt.Sprintf("/tmp/.X11-unix/X%d", d.Number)
+		conn, err = dialer.DialContext(ctx, "unix", path)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(d.Host, strconv.Itoa(6000+d.Number)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Verbinden mit dem X-Server %s: %w", display, err)
+	}
+
+	authName, authData := lookupAuth(d)
+	c, err := newConn(ctx, conn, authName, authData)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("fehler beim Verbindungsaufbau mit %s: %w", display, err)
+	}
+	if err := c.initXTest(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// newConn führt den Verbindungsaufbau (Setup) über eine bestehende Verbindung durch
+func newConn(ctx context.Context, conn net.Conn, authName string, authData []byte) (*Conn, error) {
+	c := &Conn{conn: conn}
+	c.setDeadline(ctx)
+
+	// Setup-Anfrage in Little-Endian-Byte-Reihenfolge
+	w := &writer{}
+	w.u8('l')
+	w.pad(1)
+	w.u16(protocolMajor)
+	w.u16(protocolMinor)
+	w.u16(uint16(len(authName)))
+	w.u16(uint16(len(authData)))
+	w.pad(2)
+	w.bytes([]byte(authName))
+	w.bytes(authData)
+	if _, err := conn.Write(w.buf); err != nil {
+		return nil, fmt.Errorf("fehler beim Senden: %w", err)
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen der Setup-Antwort: %w", err)
+	}
+	length := int(binary.LittleEndian.Uint16(header[6:])) * 4
+	body := make([]byte, length)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen der Setup-Antwort: %w", err)
+	}
+
+	switch header[0] {
+	case 0:
+		reason := body[:min(int(header[1]), len(body))]
+		return nil, fmt.Errorf("verbindung abgelehnt: %s", strings.TrimSpace(string(reason)))
+	case 2:
+		return nil, fmt.Errorf("verbindung abgelehnt: %s", strings.TrimSpace(strings.TrimRight(string(body), "\x00")))
+	case 1:
+	default:
+		return nil, fmt.Errorf("unerwartete Setup-Antwort %d", header[0])
+	}
+
+	r := &reader{data: body}
+	r.skip(16) // Release, Ressourcen-IDs, Motion-Puffer
+	vendorLength := int(r.u16())
+	r.skip(2) // maximale Anfragelänge
+	screens := int(r.u8())
+	formats := int(r.u8())
+	r.skip(4) // Byte- und Bit-Reihenfolge, Scanline
+	c.MinKeycode = r.u8()
+	c.MaxKeycode = r.u8()
+	r.skip(4)
+	r.skip(pad4(vendorLength))
+	r.skip(8 * formats)
+	for i := 0; i < screens; i++ {
+		var screen Screen
+		screen.Root = r.u32()
+		r.skip(16) // Colormap, Farben, Event-Masken
+		screen.Width = r.u16()
+		screen.Height = r.u16()
+		r.skip(12) // Millimeter, Colormaps, Root-Visual
+		r.skip(3)  // Backing-Store, Save-Unders, Tiefe
+		depths := int(r.u8())
+		for j := 0; j < depths; j++ {
+			r.skip(2)
+			visuals := int(r.u16())
+			r.skip(4 + 24*visuals)
+		}
+		c.Screens = append(c.Screens, screen)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("ungültige Setup-Antwort: %w", r.err)
+	}
+	if len(c.Screens) == 0 {
+		return nil, fmt.Errorf("der X-Server meldet keinen Bildschirm")
+	}
+	return c, nil
+}
+
+// Err gibt den Grund zurück, aus dem die Verbindung unbrauchbar wurde (nil solange sie besteht)
+func (c *Conn) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.err
+}
+
+// Close schließt die Verbindung
+func (c *Conn) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err == nil {
+		c.err = ErrClosed
+	}
+	return c.conn.Close()
+}
+
+// Sync wartet, bis der Server alle bisherigen Anfragen verarbeitet hat, und meldet deren Fehler
+func (c *Conn) Sync(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, err := c.roundTrip(ctx, request(opcodeGetInputFocus, 0, nil))
+	if err != nil {
+		return err
+	}
+	err, c.asyncErr = c.asyncErr, nil
+	return err
+}
+
+// QueryExtension fragt den Major-Opcode einer Erweiterung ab
+func (c *Conn) QueryExtension(ctx context.Context, name string) (uint8, bool, error) {
+	w := &writer{}
+	w.u16(uint16(len(name)))
+	w.pad(2)
+	w.bytes([]byte(name))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	reply, err := c.roundTrip(ctx, request(opcodeQueryExtension, 0, w.buf))
+	if err != nil {
+		return 0, false, err
+	}
+	return reply[9], reply[8] != 0, nil
+}
+
+// queryKeymap gibt den Zustand aller Tasten zurück (ein Bit je Keycode)
+func (c *Conn) queryKeymap(ctx context.Context) ([32]byte, error) {
+	var keys [32]byte
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	reply, err := c.roundTrip(ctx, request(opcodeQueryKeymap, 0, nil))
+	if err != nil {
+		return keys, err
+	}
+	copy(keys[:], reply[8:40])
+	return keys, nil
+}
+
+// queryPointer gibt die Position des Mauszeigers auf dem Bildschirm zurück
+func (c *Conn) queryPointer(ctx context.Context) (x, y int16, err error) {
+	w := &writer{}
+	w.u32(c.Screens[0].Root)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	reply, err := c.roundTrip(ctx, request(opcodeQueryPointer, 0, w.buf))
+	if err != nil {
+		return 0, 0, err
+	}
+	r := &reader{data: reply[16:20]}
+	return int16(r.u16()), int16(r.u16()), nil
+}
+
+// send schreibt eine Anfrage ohne Antwort; der Aufrufer hält c.mutex
+func (c *Conn) send(ctx context.Context, req []byte) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.setDeadline(ctx)
+	if _, err := c.conn.Write(req); err != nil {
+		return c.fail(fmt.Errorf("fehler beim Senden: %w", err))
+	}
+	c.seq++
+	return nil
+}
+
+// roundTrip sendet eine Anfrage und liest bis zu ihrer Antwort; der Aufrufer hält c.mutex.
+// Fehler früherer Anfragen ohne Antwort werden für den nächsten Sync gesammelt.
+func (c *Conn) roundTrip(ctx context.Context, req []byte) ([]byte, error) {
+	if err := c.send(ctx, req); err != nil {
+		return nil, err
+	}
+	seq := c.seq
+	for {
+		response, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		switch response[0] {
+		case 0:
+			xerr := &Error{
+				Code:     response[1],
+				Sequence: binary.LittleEndian.Uint16(response[2:]),
+				Value:    binary.LittleEndian.Uint32(response[4:]),
+				Minor:    binary.LittleEndian.Uint16(response[8:]),
+				Major:    response[10],
+			}
+			if xerr.Sequence == seq {
+				return nil, xerr
+			}
+			if c.asyncErr == nil {
+				c.asyncErr = xerr
+			}
+		case 1:
+			if binary.LittleEndian.Uint16(response[2:]) == seq {
+				return response, nil
+			}
+		}
+		// Ereignisse werden nicht abonniert und verworfen
+	}
+}
+
+// read liest eine Antwort, einen Fehler oder ein Ereignis
+func (c *Conn) read() ([]byte, error) {
+	response := make([]byte, 32)
+	if _, err := io.ReadFull(c.conn, response); err != nil {
+		return nil, c.fail(fmt.Errorf("fehler beim Lesen: %w", err))
+	}
+	// Antworten und generische Ereignisse (35) haben zusätzliche Daten
+	if response[0] == 1 || response[0]&0x7f == 35 {
+		extra := int(binary.LittleEndian.Uint32(response[4:])) * 4
+		if extra > maxReplyBytes {
+			return nil, c.fail(fmt.Errorf("antwort zu groß: %d Bytes", extra))
+		}
+		if extra > 0 {
+			response = append(response, make([]byte, extra)...)
+			if _, err := io.ReadFull(c.conn, response[32:]); err != nil {
+				return nil, c.fail(fmt.Errorf("fehler beim Lesen: %w", err))
+			}
+		}
+	}
+	return response, nil
+}
+
+// fail markiert die Verbindung als unbrauchbar
+func (c *Conn) fail(err error) error {
+	if c.err == nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+			err = ErrClosed
+		}
+		c.err = err
+		c.conn.Close()
+	}
+	return c.err
+}
+
+// setDeadline übernimmt die Deadline des Contexts (sonst ioTimeout) für Lesen und Schreiben
+func (c *Conn) setDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(ioTimeout)
+	}
+	c.conn.SetDeadline(deadline)
+}
+
+// request baut eine Anfrage aus Opcode, Datenbyte und Rumpf (wird auf 4 Bytes aufgefüllt)
+func request(opcode, data uint8, body []byte) []byte {
+	length := 4 + pad4(len(body))
+	req := make([]byte, length)
+	req[0] = opcode
+	req[1] = data
+	binary.LittleEndian.PutUint16(req[2:], uint16(length/4))
+	copy(req[4:], body)
+	return req
+}
+
+// pad4 rundet auf ein Vielfaches von 4 auf
+func pad4(n int) int {
+	return (n + 3) &^ 3
+}
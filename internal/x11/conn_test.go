@@ -0,0 +1,403 @@
+package x11
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// fakeXTestOpcode ist der Opcode, unter dem der simulierte Server XTEST anbietet
+const fakeXTestOpcode = 132
+
+// fakeEvent ist ein vom simulierten Server empfangenes XTEST-Ereignis
+type fakeEvent struct {
+	eventType uint8
+	detail    uint8
+	x, y      int16
+}
+
+// fakeServer spricht genug X11, um eine Tastatur mit XTEST zu simulieren
+type fakeServer struct {
+	t       *testing.T
+	mutex   sync.Mutex
+	perKey  int
+	keysyms map[uint8][]Keysym
+	events  []fakeEvent
+	setups  [][]byte
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	return &fakeServer{
+		t:      t,
+		perKey: 2,
+		keysyms: map[uint8][]Keysym{
+			10:  {'1', '!'},
+			23:  {KeysymTab},
+			36:  {KeysymReturn},
+			37:  {0xffe3}, // Control_L
+			38:  {'a', 'A'},
+			50:  {0xffe1}, // Shift_L
+			64:  {0xffe9}, // Alt_L
+			65:  {' '},
+			67:  {0xffbe}, // F1
+			111: {0xff52}, // Up
+		},
+	}
+}
+
+// dial verbindet einen Client über einen Unix-Socket mit dem simulierten Server
+func (s *fakeServer) dial(t *testing.T) *Conn {
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "X0"))
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		if server, err := listener.Accept(); err == nil {
+			s.serve(server)
+		}
+	}()
+
+	client, err := net.Dial("unix", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	c, err := newConn(context.Background(), client, cookieAuthName, []byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("newConn: %v", err)
+	}
+	if err := c.initXTest(context.Background()); err != nil {
+		t.Fatalf("initXTest: %v", err)
+	}
+	return c
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	// Setup-Anfrage
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	nameLength := int(binary.LittleEndian.Uint16(header[6:]))
+	dataLength := int(binary.LittleEndian.Uint16(header[8:]))
+	auth := make([]byte, pad4(nameLength)+pad4(dataLength))
+	if _, err := io.ReadFull(conn, auth); err != nil {
+		return
+	}
+	s.mutex.Lock()
+	s.setups = append(s.setups, append(header, auth...))
+	s.mutex.Unlock()
+	conn.Write(s.setupReply())
+
+	var seq uint16
+	for {
+		head := make([]byte, 4)
+		if _, err := io.ReadFull(conn, head); err != nil {
+			return
+		}
+		body := make([]byte, int(binary.LittleEndian.Uint16(head[2:]))*4-4)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+		seq++
+
+		switch opcode := head[0]; opcode {
+		case opcodeQueryExtension:
+			name := string(body[4 : 4+binary.LittleEndian.Uint16(body)])
+			reply := s.reply(seq, nil)
+			if name == "XTEST" {
+				reply[8], reply[9] = 1, fakeXTestOpcode
+			}
+			conn.Write(reply)
+		case opcodeGetKeyboardMapping:
+			first, count := body[0], int(body[1])
+			w := &writer{}
+			for i := 0; i < count; i++ {
+				syms := s.keysyms[first+uint8(i)]
+				for level := 0; level < s.perKey; level++ {
+					if level < len(syms) {
+						w.u32(uint32(syms[level]))
+					} else {
+						w.u32(0)
+					}
+				}
+			}
+			reply := s.reply(seq, w.buf)
+			reply[1] = uint8(s.perKey)
+			conn.Write(reply)
+		case opcodeChangeKeyboardMapping:
+			first, perKey := body[0], int(body[1])
+			r := &reader{data: body[4:]}
+			s.mutex.Lock()
+			for i := 0; i < int(head[1]); i++ {
+				syms := make([]Keysym, perKey)
+				for level := range syms {
+					syms[level] = Keysym(r.u32())
+				}
+				s.keysyms[first+uint8(i)] = syms
+			}
+			s.mutex.Unlock()
+		case opcodeGetInputFocus:
+			conn.Write(s.reply(seq, nil))
+		case fakeXTestOpcode:
+			if head[1] != xtestFakeInput || len(body) != 32 {
+				conn.Write(s.error(seq, 16, opcode))
+				continue
+			}
+			if body[0] <= eventKeyRelease && body[1] < 8 {
+				conn.Write(s.error(seq, 2, opcode))
+				continue
+			}
+			s.mutex.Lock()
+			s.events = append(s.events, fakeEvent{
+				eventType: body[0],
+				detail:    body[1],
+				x:         int16(binary.LittleEndian.Uint16(body[20:])),
+				y:         int16(binary.LittleEndian.Uint16(body[22:])),
+			})
+			s.mutex.Unlock()
+		default:
+			conn.Write(s.error(seq, 1, opcode))
+		}
+	}
+}
+
+// setupReply baut eine erfolgreiche Setup-Antwort mit einem Bildschirm
+func (s *fakeServer) setupReply() []byte {
+	w := &writer{}
+	w.u32(12101004)   // Release
+	w.u32(0x00400000) // Ressourcen-ID-Basis
+	w.u32(0x001fffff) // Ressourcen-ID-Maske
+	w.u32(256)        // Motion-Puffer
+	vendor := "Fake X"
+	w.u16(uint16(len(vendor)))
+	w.u16(0xffff)
+	w.u8(1) // Bildschirme
+	w.u8(1) // Formate
+	w.pad(4)
+	w.u8(8)   // Min-Keycode
+	w.u8(255) // Max-Keycode
+	w.pad(4)
+	w.bytes([]byte(vendor))
+	w.pad(8) // Format
+	// Bildschirm
+	w.u32(0x1e3) // Root
+	w.pad(16)
+	w.u16(1920)
+	w.u16(1080)
+	w.pad(12)
+	w.pad(3)
+	w.u8(1) // eine Tiefe
+	w.u8(24)
+	w.pad(1)
+	w.u16(1) // ein Visual
+	w.pad(4)
+	w.pad(24)
+
+	header := &writer{}
+	header.u8(1)
+	header.pad(1)
+	header.u16(protocolMajor)
+	header.u16(protocolMinor)
+	header.u16(uint16(len(w.buf) / 4))
+	return append(header.buf, w.buf...)
+}
+
+// reply baut eine Antwort mit zusätzlichen Daten
+func (s *fakeServer) reply(seq uint16, extra []byte) []byte {
+	reply := make([]byte, 32, 32+len(extra))
+	reply[0] = 1
+	binary.LittleEndian.PutUint16(reply[2:], seq)
+	binary.LittleEndian.PutUint32(reply[4:], uint32(len(extra)/4))
+	return append(reply, extra...)
+}
+
+// error baut eine Fehlermeldung
+func (s *fakeServer) error(seq uint16, code, major uint8) []byte {
+	response := make([]byte, 32)
+	response[1] = code
+	binary.LittleEndian.PutUint16(response[2:], seq)
+	response[10] = major
+	return response
+}
+
+func (s *fakeServer) recorded() []fakeEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]fakeEvent(nil), s.events...)
+}
+
+func TestParseDisplay(t *testing.T) {
+	tests := map[string]Display{
+		":0":              {Number: 0},
+		":1.2":            {Number: 1, Screen: 2},
+		"unix:3":          {Number: 3},
+		"unix/:4":         {Number: 4},
+		"localhost:10.0":  {Host: "localhost", Number: 10},
+		"tcp/10.0.0.5:11": {Host: "10.0.0.5", Number: 11},
+	}
+	for display, want := range tests {
+		got, err := ParseDisplay(display)
+		if err != nil || got != want {
+			t.Fatalf("ParseDisplay(%q) = %+v, %v; want %+v", display, got, err, want)
+		}
+	}
+	for _, display := range []string{"", "0", ":x", ":1.y"} {
+		if _, err := ParseDisplay(display); err == nil {
+			t.Fatalf("expected error for %q", display)
+		}
+	}
+}
+
+func TestAuthEntries(t *testing.T) {
+	var file bytes.Buffer
+	write := func(family uint16, fields ...string) {
+		binary.Write(&file, binary.BigEndian, family)
+		for _, field := range fields {
+			binary.Write(&file, binary.BigEndian, uint16(len(field)))
+			file.WriteString(field)
+		}
+	}
+	write(familyLocal, "other", "0", cookieAuthName, "wrong-host")
+	write(familyLocal, "myhost", "1", cookieAuthName, "wrong-display")
+	write(familyLocal, "myhost", "0", cookieAuthName, "cookie-local")
+	write(familyInternet, string([]byte{10, 0, 0, 5}), "11", cookieAuthName, "cookie-tcp")
+
+	entries, err := readAuthEntries(&file)
+	if err != nil || len(entries) != 4 {
+		t.Fatalf("readAuthEntries: %d entries, %v", len(entries), err)
+	}
+	if name, data := matchAuth(entries, Display{Number: 0}, "myhost"); name != cookieAuthName || string(data) != "cookie-local" {
+		t.Fatalf("local cookie = %q %q", name, data)
+	}
+	if _, data := matchAuth(entries, Display{Host: "10.0.0.5", Number: 11}, "myhost"); string(data) != "cookie-tcp" {
+		t.Fatalf("tcp cookie = %q", data)
+	}
+	if name, _ := matchAuth(entries, Display{Number: 7}, "myhost"); name != "" {
+		t.Fatalf("unexpected cookie for unknown display")
+	}
+}
+
+func TestFakeKey(t *testing.T) {
+	server := newFakeServer(t)
+	c := server.dial(t)
+	ctx := context.Background()
+
+	if c.MinKeycode != 8 || c.MaxKeycode != 255 || len(c.Screens) != 1 || c.Screens[0].Width != 1920 {
+		t.Fatalf("unexpected setup: %+v", c)
+	}
+	if setup := server.setups[0]; setup[0] != 'l' || !bytes.Contains(setup, []byte(cookieAuthName)) {
+		t.Fatalf("unexpected setup request: %q", setup)
+	}
+
+	mapping, err := c.KeyboardMapping(ctx)
+	if err != nil {
+		t.Fatalf("KeyboardMapping: %v", err)
+	}
+	if keycode, level, ok := mapping.Lookup(RuneKeysym('!')); !ok || keycode != 10 || level != 1 {
+		t.Fatalf("Lookup('!') = %d %d %v", keycode, level, ok)
+	}
+	if keycode, level, ok := mapping.Lookup(RuneKeysym('\n')); !ok || keycode != 36 || level != 0 {
+		t.Fatalf("Lookup('\\n') = %d %d %v", keycode, level, ok)
+	}
+	if _, _, ok := mapping.Lookup(RuneKeysym('ö')); ok {
+		t.Fatalf("unexpected keycode for 'ö'")
+	}
+
+	c.FakeKey(ctx, 38, true)
+	c.FakeKey(ctx, 38, false)
+	if err := c.Sync(ctx); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	events := server.recorded()
+	if len(events) != 2 || events[0] != (fakeEvent{eventType: eventKeyPress, detail: 38}) || events[1] != (fakeEvent{eventType: eventKeyRelease, detail: 38}) {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	c.FakeButton(ctx, 3, true)
+	c.FakeButton(ctx, 3, false)
+	if err := c.Sync(ctx); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if events := server.recorded()[2:]; len(events) != 2 || events[0] != (fakeEvent{eventType: eventButtonPress, detail: 3}) || events[1] != (fakeEvent{eventType: eventButtonRelease, detail: 3}) {
+		t.Fatalf("unexpected button events: %+v", events)
+	}
+
+	c.FakeMotion(ctx, 640, 480, false)
+	c.FakeMotion(ctx, -5, 12, true)
+	if err := c.Sync(ctx); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	events = server.recorded()[4:]
+	if len(events) != 2 || events[0] != (fakeEvent{eventType: eventMotionNotify, x: 640, y: 480}) || events[1] != (fakeEvent{eventType: eventMotionNotify, detail: 1, x: -5, y: 12}) {
+		t.Fatalf("unexpected motion events: %+v", events)
+	}
+
+	// Fehler einer Anfrage ohne Antwort meldet der nächste Sync
+	c.FakeKey(ctx, 3, true)
+	if err := c.Sync(ctx); err == nil {
+		t.Fatalf("expected error for invalid keycode")
+	}
+	if err := c.Sync(ctx); err != nil {
+		t.Fatalf("error reported twice: %v", err)
+	}
+}
+
+func TestChangeKeyboardMapping(t *testing.T) {
+	server := newFakeServer(t)
+	c := server.dial(t)
+	ctx := context.Background()
+
+	mapping, err := c.KeyboardMapping(ctx)
+	if err != nil {
+		t.Fatalf("KeyboardMapping: %v", err)
+	}
+	spare, ok := mapping.Unused()
+	if !ok || spare != 255 {
+		t.Fatalf("Unused() = %d %v", spare, ok)
+	}
+	sym := RuneKeysym('ő')
+	if err := c.ChangeKeyboardMapping(ctx, spare, mapping.PerKeycode, sym, sym); err != nil {
+		t.Fatalf("ChangeKeyboardMapping: %v", err)
+	}
+	if err := c.Sync(ctx); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	mapping, err = c.KeyboardMapping(ctx)
+	if err != nil {
+		t.Fatalf("KeyboardMapping: %v", err)
+	}
+	if keycode, level, ok := mapping.Lookup(sym); !ok || keycode != spare || level != 0 {
+		t.Fatalf("Lookup after change = %d %d %v", keycode, level, ok)
+	}
+	if next, _ := mapping.Unused(); next != 254 {
+		t.Fatalf("Unused() after change = %d", next)
+	}
+}
+
+func TestRuneKeysym(t *testing.T) {
+	tests := map[rune]Keysym{
+		'a':  0x61,
+		'ä':  0xe4,
+		'€':  0x20ac,
+		'ő':  0x01000151,
+		'😀':  0x0101f600,
+		'\n': KeysymReturn,
+		0x07: NoSymbol,
+	}
+	for r, want := range tests {
+		if got := RuneKeysym(r); got != want {
+			t.Fatalf("RuneKeysym(%q) = %#x, want %#x", r, got, want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package x11
+
+import (
+	"context"
+	"fmt"
+)
+
+// Keysym ist ein X11-Tastensymbol
+type Keysym uint32
+
+// NoSymbol kennzeichnet eine leere Ebene der Tastaturbelegung
+const NoSymbol Keysym = 0
+
+// unicodeKeysymOffset kennzeichnet Keysyms, die direkt einen Unicode-Codepoint enthalten
+const unicodeKeysymOffset = 0x01000000
+
+// Keysyms für Steuerzeichen in Texten und Modifier-Tasten
+const (
+	KeysymShift     Keysym = 0xffe1 // Shift_L
+	KeysymAltGr     Keysym = 0xfe03 // ISO_Level3_Shift
+	KeysymBackSpace Keysym = 0xff08
+	KeysymTab       Keysym = 0xff09
+	KeysymReturn    Keysym = 0xff0d
+)
+
+// legacyKeysyms enthält Zeichen, die Tastaturbelegungen nicht als Unicode-Keysym führen
+var legacyKeysyms = map[rune]Keysym{
+	'€': 0x20ac, // EuroSign
+}
+
+// RuneKeysym gibt das Keysym eines Zeichens zurück (NoSymbol für nicht darstellbare Steuerzeichen)
+func RuneKeysym(r rune) Keysym {
+	switch {
+	case r == '\n' || r == '\r':
+		return KeysymReturn
+	case r == '\t':
+		return KeysymTab
+	case r == '\b':
+		return KeysymBackSpace
+	case r < 0x20 || r == 0x7f:
+		return NoSymbol
+	case r < 0x7f || (r >= 0xa0 && r <= 0xff):
+		// Latin-1 entspricht direkt den Keysyms
+		return Keysym(r)
+	}
+	if sym, ok := legacyKeysyms[r]; ok {
+		return sym
+	}
+	return Keysym(unicodeKeysymOffset + r)
+}
+
+// Ebenen der Kern-Tastaturbelegung, wie XKB sie abbildet: zuerst Gruppe 1 und 2 mit je zwei Ebenen,
+// danach die AltGr-Ebenen (Level 3 und 4) der ersten Gruppe
+const (
+	LevelBase       = 0
+	LevelShift      = 1
+	LevelAltGr      = 4
+	LevelAltGrShift = 5
+)
+
+// KeyboardMapping ist die Tastaturbelegung des Servers: je Keycode die Keysyms aller Ebenen.
+// Ebene 0 ist die Taste ohne, Ebene 1 mit Shift; weitere Ebenen gehören zu anderen Gruppen bzw. AltGr.
+type KeyboardMapping struct {
+	MinKeycode uint8
+	PerKeycode int
+	Keysyms    []Keysym
+}
+
+// KeyboardMapping fragt die aktuelle Tastaturbelegung ab
+func (c *Conn) KeyboardMapping(ctx context.Context) (*KeyboardMapping, error) {
+	count := int(c.MaxKeycode) - int(c.MinKeycode) + 1
+	w := &writer{}
+	w.u8(c.MinKeycode)
+	w.u8(uint8(count))
+	w.pad(2)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	reply, err := c.roundTrip(ctx, request(opcodeGetKeyboardMapping, 0, w.buf))
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Abrufen der Tastaturbelegung: %w", err)
+	}
+
+	m := &KeyboardMapping{MinKeycode: c.MinKeycode, PerKeycode: int(reply[1])}
+	r := &reader{data: reply[32:]}
+	for i := 0; i < count*m.PerKeycode; i++ {
+		m.Keysyms = append(m.Keysyms, Keysym(r.u32()))
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("ungültige Tastaturbelegung: %w", r.err)
+	}
+	return m, nil
+}
+
+// Keysym gibt das Keysym einer Taste auf einer Ebene zurück
+func (m *KeyboardMapping) Keysym(keycode uint8, level int) Keysym {
+	if keycode < m.MinKeycode || level < 0 || level >= m.PerKeycode {
+		return NoSymbol
+	}
+	index := int(keycode-m.MinKeycode)*m.PerKeycode + level
+	if index >= len(m.Keysyms) {
+		return NoSymbol
+	}
+	return m.Keysyms[index]
+}
+
+// Lookup sucht die Taste für ein Keysym. Niedrige Ebenen werden bevorzugt, damit z.B. "a"
+// ohne Shift getippt wird, auch wenn eine andere Taste es auf einer höheren Ebene führt.
+func (m *KeyboardMapping) Lookup(sym Keysym) (keycode uint8, level int, ok bool) {
+	levels := make([]int, m.PerKeycode)
+	for i := range levels {
+		levels[i] = i
+	}
+	return m.Find(sym, levels...)
+}
+
+// Find sucht die Taste für ein Keysym auf den angegebenen Ebenen (in dieser Reihenfolge)
+func (m *KeyboardMapping) Find(sym Keysym, levels ...int) (keycode uint8, level int, ok bool) {
+	if sym == NoSymbol || m.PerKeycode == 0 {
+		return 0, 0, false
+	}
+	count := len(m.Keysyms) / m.PerKeycode
+	for _, level := range levels {
+		if level < 0 || level >= m.PerKeycode {
+			continue
+		}
+		for i := 0; i < count; i++ {
+			if m.Keysyms[i*m.PerKeycode+level] == sym {
+				return m.MinKeycode + uint8(i), level, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// Unused gibt den höchsten Keycode zurück, dem kein Keysym zugeordnet ist. Solche Tasten
+// können vorübergehend mit beliebigen Zeichen belegt werden.
+func (m *KeyboardMapping) Unused() (uint8, bool) {
+	if m.PerKeycode == 0 {
+		return 0, false
+	}
+	for i := len(m.Keysyms)/m.PerKeycode - 1; i >= 0; i-- {
+		used := false
+		for _, sym := range m.Keysyms[i*m.PerKeycode : (i+1)*m.PerKeycode] {
+			used = used || sym != NoSymbol
+		}
+		if !used {
+			return m.MinKeycode + uint8(i), true
+		}
+	}
+	return 0, false
+}
+
+// Set ändert die Keysyms einer Taste in der lokalen Kopie der Belegung (fehlende Ebenen werden geleert)
+func (m *KeyboardMapping) Set(keycode uint8, syms ...Keysym) {
+	if keycode < m.MinKeycode {
+		return
+	}
+	index := int(keycode-m.MinKeycode) * m.PerKeycode
+	if index+m.PerKeycode > len(m.Keysyms) {
+		return
+	}
+	for level := 0; level < m.PerKeycode; level++ {
+		sym := NoSymbol
+		if level < len(syms) {
+			sym = syms[level]
+		}
+		m.Keysyms[index+level] = sym
+	}
+}
+
+// ChangeKeyboardMapping belegt eine Taste auf dem Server neu (fehlende Ebenen werden geleert).
+// Fehler meldet der nächste Sync.
+func (c *Conn) ChangeKeyboardMapping(ctx context.Context, keycode uint8, perKeycode int, syms ...Keysym) error {
+	w := &writer{}
+	w.u8(keycode)
+	w.u8(uint8(perKeycode))
+	w.pad(2)
+	for level := 0; level < perKeycode; level++ {
+		sym := NoSymbol
+		if level < len(syms) {
+			sym = syms[level]
+		}
+		w.u32(uint32(sym))
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.send(ctx, request(opcodeChangeKeyboardMapping, 1, w.buf))
+}
@@ -0,0 +1,81 @@
+package x11
+
+import (
+	"context"
+	"fmt"
+)
+
+// Anfragen und Ereignistypen der XTEST-Erweiterung
+const (
+	xtestFakeInput = 2
+
+	eventKeyPress      = 2
+	eventKeyRelease    = 3
+	eventButtonPress   = 4
+	eventButtonRelease = 5
+	eventMotionNotify  = 6
+)
+
+// initXTest ermittelt den Opcode der XTEST-Erweiterung
+func (c *Conn) initXTest(ctx context.Context) error {
+	opcode, present, err := c.QueryExtension(ctx, "XTEST")
+	if err != nil {
+		return fmt.Errorf("fehler beim Abfragen der XTEST-Erweiterung: %w", err)
+	}
+	if !present {
+		return fmt.Errorf("der X-Server unterstützt die XTEST-Erweiterung nicht")
+	}
+	c.xtestOpcode = opcode
+	return nil
+}
+
+// FakeKey simuliert das Drücken (press) bzw. Loslassen einer Taste. Fehler meldet der nächste Sync.
+func (c *Conn) FakeKey(ctx context.Context, keycode uint8, press bool) error {
+	eventType := uint8(eventKeyRelease)
+	if press {
+		eventType = eventKeyPress
+	}
+	return c.fakeInput(ctx, eventType, keycode, 0, 0)
+}
+
+// FakeButton simuliert das Drücken (press) bzw. Loslassen einer Maustaste (1 = links, 2 = Mitte,
+// 3 = rechts, 4/5 = Mausrad, 8/9 = zurück/vor). Fehler meldet der nächste Sync.
+func (c *Conn) FakeButton(ctx context.Context, button uint8, press bool) error {
+	eventType := uint8(eventButtonRelease)
+	if press {
+		eventType = eventButtonPress
+	}
+	return c.fakeInput(ctx, eventType, button, 0, 0)
+}
+
+// FakeMotion bewegt den Mauszeiger an eine Position auf dem Bildschirm, auf dem er sich befindet,
+// bzw. mit relative um x/y Pixel. Fehler meldet der nächste Sync.
+func (c *Conn) FakeMotion(ctx context.Context, x, y int16, relative bool) error {
+	detail := uint8(0)
+	if relative {
+		detail = 1
+	}
+	return c.fakeInput(ctx, eventMotionNotify, detail, x, y)
+}
+
+// fakeInput sendet ein simuliertes Eingabeereignis an den Server
+func (c *Conn) fakeInput(ctx context.Context, eventType, detail uint8, x, y int16) error {
+	w := &writer{}
+	w.u8(eventType)
+	w.u8(detail)
+	w.pad(2)
+	w.u32(0) // Zeit: sofort
+	w.u32(0) // Root-Fenster: aktuelles
+	w.pad(8)
+	w.u16(uint16(x)) // nur für Mausbewegungen
+	w.u16(uint16(y))
+	w.pad(7)
+	w.u8(0) // Gerät: Kern-Tastatur bzw. -Zeiger
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.xtestOpcode == 0 {
+		return fmt.Errorf("XTEST ist nicht initialisiert")
+	}
+	return c.send(ctx, request(c.xtestOpcode, xtestFakeInput, w.buf))
+}
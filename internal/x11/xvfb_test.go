@@ -0,0 +1,111 @@
+package x11
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// startXvfb startet einen Xvfb-Server auf einem freien Display und gibt dessen Namen zurück.
+// Ohne installiertes Xvfb wird der Test übersprungen.
+func startXvfb(t *testing.T) string {
+	path, err := exec.LookPath("Xvfb")
+	if err != nil {
+		t.Skip("Xvfb nicht installiert")
+	}
+
+	number := 99
+	for ; number < 200; number++ {
+		if _, err := os.Stat(fmt.Sprintf("/tmp/.X11-unix/X%d", number)); os.IsNotExist(err) {
+			break
+		}
+	}
+	display := fmt.Sprintf(":%d", number)
+	cmd := exec.Command(path, display, "-nolisten", "tcp", "-screen", "0", "640x480x24")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Xvfb: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	socket := fmt.Sprintf("/tmp/.X11-unix/X%d", number)
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if _, err := os.Stat(socket); err == nil {
+			return display
+		}
+	}
+	t.Fatalf("Xvfb ist nicht gestartet")
+	return ""
+}
+
+func TestXvfbFakeKey(t *testing.T) {
+	display := startXvfb(t)
+	ctx := context.Background()
+
+	c, err := Dial(ctx, display)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.Close()
+
+	mapping, err := c.KeyboardMapping(ctx)
+	if err != nil {
+		t.Fatalf("KeyboardMapping: %v", err)
+	}
+	keycode, level, ok := mapping.Lookup(RuneKeysym('a'))
+	if !ok || level != 0 {
+		t.Fatalf("Lookup('a') = %d %d %v", keycode, level, ok)
+	}
+
+	pressed := func() bool {
+		keys, err := c.queryKeymap(ctx)
+		if err != nil {
+			t.Fatalf("queryKeymap: %v", err)
+		}
+		return keys[keycode/8]&(1<<(keycode%8)) != 0
+	}
+
+	c.FakeKey(ctx, keycode, true)
+	if err := c.Sync(ctx); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if !pressed() {
+		t.Fatalf("key %d not pressed", keycode)
+	}
+	c.FakeKey(ctx, keycode, false)
+	if err := c.Sync(ctx); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if pressed() {
+		t.Fatalf("key %d still pressed", keycode)
+	}
+}
+
+func TestXvfbFakeMotion(t *testing.T) {
+	display := startXvfb(t)
+	ctx := context.Background()
+
+	c, err := Dial(ctx, display)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.Close()
+
+	c.FakeMotion(ctx, 100, 200, false)
+	c.FakeMotion(ctx, 10, -20, true)
+	if err := c.Sync(ctx); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	x, y, err := c.queryPointer(ctx)
+	if err != nil {
+		t.Fatalf("queryPointer: %v", err)
+	}
+	if x != 110 || y != 180 {
+		t.Fatalf("pointer at %d,%d, want 110,180", x, y)
+	}
+}